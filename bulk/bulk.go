@@ -0,0 +1,80 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bulk registers the native bulk loaders of the database drivers for
+// BulkLoad, it should be imported for the side effects
+//
+//	import _ "xorm.io/xorm/bulk"
+//
+// The loaders are COPY FROM of pgx, CopyIn of lib/pq, LOAD DATA LOCAL INFILE
+// with a reader handler of go-sql-driver/mysql and the bulk copy of
+// go-mssqldb. The other drivers like SQLite use the batched inserts.
+package bulk
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"xorm.io/xorm"
+)
+
+func init() {
+	xorm.RegisterBulkLoader("pgx", pgxLoader{})
+	xorm.RegisterBulkLoader("postgres", pqLoader{})
+	xorm.RegisterBulkLoader("mysql", mysqlLoader{})
+	xorm.RegisterBulkLoader("mssql", mssqlLoader{})
+	xorm.RegisterBulkLoader("sqlserver", mssqlLoader{})
+}
+
+// splitTableName splits the table name to the schema and the table
+func splitTableName(tableName string) (string, string) {
+	if i := strings.LastIndex(tableName, "."); i >= 0 {
+		return tableName[:i], tableName[i+1:]
+	}
+	return "", tableName
+}
+
+// copyIn executes the copy statement of the driver once per record and once
+// more without arguments to flush the records, in a new transaction if tx is
+// nil
+func copyIn(ctx context.Context, db *sql.DB, tx *sql.Tx, query string, records xorm.BulkRecords) (int64, error) {
+	if tx == nil {
+		newTx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return 0, err
+		}
+		affected, err := copyIn(ctx, db, newTx, query, records)
+		if err != nil {
+			_ = newTx.Rollback()
+			return 0, err
+		}
+		return affected, newTx.Commit()
+	}
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var affected int64
+	for records.Next() {
+		values, err := records.Values()
+		if err != nil {
+			return 0, err
+		}
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			return 0, err
+		}
+		affected++
+	}
+	if err := records.Err(); err != nil {
+		return 0, err
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return 0, err
+	}
+	return affected, nil
+}
@@ -0,0 +1,26 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bulk
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+	"xorm.io/xorm"
+)
+
+// mssqlLoader loads the records by the bulk copy of go-mssqldb
+type mssqlLoader struct{}
+
+func (mssqlLoader) BulkLoad(ctx context.Context, db *sql.DB, tx *sql.Tx, tableName string, columns []string, records xorm.BulkRecords) (int64, error) {
+	// the table name is used as is in the statements of the bulk copy
+	parts := strings.Split(tableName, ".")
+	for i, part := range parts {
+		parts[i] = "[" + strings.ReplaceAll(part, "]", "]]") + "]"
+	}
+	return copyIn(ctx, db, tx, mssql.CopyIn(strings.Join(parts, "."), mssql.BulkOptions{}, columns...), records)
+}
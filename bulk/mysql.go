@@ -0,0 +1,226 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bulk
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"xorm.io/xorm"
+)
+
+var readerSeq int64
+
+// mysqlLoader loads the records by LOAD DATA LOCAL INFILE reading from a
+// reader handler of go-sql-driver/mysql. The server skips the records of the
+// duplicate keys and adjusts the invalid values with warnings instead of
+// errors for LOAD DATA LOCAL, so the load fails if any record is not loaded
+// as sent or there is any warning, and it's rolled back in a new transaction
+// if tx is nil.
+type mysqlLoader struct{}
+
+func (l mysqlLoader) BulkLoad(ctx context.Context, db *sql.DB, tx *sql.Tx, tableName string, columns []string, records xorm.BulkRecords) (int64, error) {
+	if tx == nil {
+		newTx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return 0, err
+		}
+		affected, err := l.BulkLoad(ctx, db, newTx, tableName, columns, records)
+		if err != nil {
+			_ = newTx.Rollback()
+			return 0, err
+		}
+		return affected, newTx.Commit()
+	}
+
+	var (
+		name    = fmt.Sprintf("xorm_bulk_%d", atomic.AddInt64(&readerSeq, 1))
+		pr, pw  = io.Pipe()
+		done    = make(chan struct{})
+		started bool
+		sent    int64
+	)
+	// the records are read only after the server asks for the file, so
+	// nothing is read if LOAD DATA LOCAL is disabled
+	mysql.RegisterReaderHandler(name, func() io.Reader {
+		started = true
+		go func() {
+			defer close(done)
+			var err error
+			sent, err = writeMySQLRecords(pw, records)
+			_ = pw.CloseWithError(err)
+		}()
+		return pr
+	})
+	defer mysql.DeregisterReaderHandler(name)
+	// close the reader to stop the writer if the statement fails before
+	// reading all the records
+	wait := func() {
+		_ = pr.Close()
+		if started {
+			<-done
+		}
+	}
+
+	quotedCols := make([]string, 0, len(columns))
+	for _, col := range columns {
+		quotedCols = append(quotedCols, quoteMySQL(col))
+	}
+	parts := strings.Split(tableName, ".")
+	for i, part := range parts {
+		parts[i] = quoteMySQL(part)
+	}
+	query := fmt.Sprintf("LOAD DATA LOCAL INFILE 'Reader::%s' INTO TABLE %s CHARACTER SET utf8mb4 (%s)",
+		name, strings.Join(parts, "."), strings.Join(quotedCols, ","))
+
+	res, err := tx.ExecContext(ctx, query)
+	wait()
+	if err != nil {
+		if !started && isLocalInfileDisabled(err) {
+			return 0, xorm.ErrBulkLoadUnsupported
+		}
+		return 0, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if err := checkMySQLWarnings(ctx, tx, affected, sent); err != nil {
+		return 0, err
+	}
+	return affected, nil
+}
+
+// isLocalInfileDisabled returns true if the error is returned since
+// local_infile is OFF on the server
+func isLocalInfileDisabled(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	// ER_NOT_ALLOWED_COMMAND and ER_CLIENT_LOCAL_FILES_DISABLED of MySQL 8
+	return mysqlErr.Number == 1148 || mysqlErr.Number == 3948
+}
+
+// checkMySQLWarnings returns an error if the records affected are not the
+// ones sent or there is any warning of the load
+func checkMySQLWarnings(ctx context.Context, tx *sql.Tx, affected, sent int64) error {
+	rows, err := tx.QueryContext(ctx, "SHOW WARNINGS")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var warnings []string
+	for rows.Next() {
+		var (
+			level, message string
+			code           int
+		)
+		if err := rows.Scan(&level, &code, &message); err != nil {
+			return err
+		}
+		if level != "Note" {
+			warnings = append(warnings, message)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if affected == sent && len(warnings) == 0 {
+		return nil
+	}
+	return fmt.Errorf("bulk load of %d records affected %d rows with warnings: %s",
+		sent, affected, strings.Join(warnings, "; "))
+}
+
+func quoteMySQL(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// writeMySQLRecords writes the records as the default format of LOAD DATA,
+// the fields are terminated by tabs, the lines by newlines and the special
+// characters are escaped by backslashes. It returns the number of the records
+// written.
+func writeMySQLRecords(w io.Writer, records xorm.BulkRecords) (int64, error) {
+	var (
+		bw    = bufio.NewWriter(w)
+		count int64
+	)
+	for records.Next() {
+		values, err := records.Values()
+		if err != nil {
+			return count, err
+		}
+		for i, v := range values {
+			if i > 0 {
+				_ = bw.WriteByte('\t')
+			}
+			writeMySQLValue(bw, v)
+		}
+		if err := bw.WriteByte('\n'); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := records.Err(); err != nil {
+		return count, err
+	}
+	return count, bw.Flush()
+}
+
+func writeMySQLValue(bw *bufio.Writer, v interface{}) {
+	var s string
+	switch t := v.(type) {
+	case nil:
+		_, _ = bw.WriteString(`\N`)
+		return
+	case bool:
+		if t {
+			s = "1"
+		} else {
+			s = "0"
+		}
+	case []byte:
+		s = string(t)
+	case string:
+		s = t
+	case time.Time:
+		s = t.Format("2006-01-02 15:04:05.999999")
+	case int64:
+		s = strconv.FormatInt(t, 10)
+	case float64:
+		s = strconv.FormatFloat(t, 'g', -1, 64)
+	default:
+		s = fmt.Sprint(t)
+	}
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			_, _ = bw.WriteString(`\\`)
+		case '\t':
+			_, _ = bw.WriteString(`\t`)
+		case '\n':
+			_, _ = bw.WriteString(`\n`)
+		case '\r':
+			_, _ = bw.WriteString(`\r`)
+		case 0:
+			_, _ = bw.WriteString(`\0`)
+		default:
+			_ = bw.WriteByte(c)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bulk
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+type sliceRecords struct {
+	records [][]interface{}
+	idx     int
+}
+
+func (r *sliceRecords) Next() bool {
+	r.idx++
+	return r.idx <= len(r.records)
+}
+
+func (r *sliceRecords) Values() ([]interface{}, error) {
+	return r.records[r.idx-1], nil
+}
+
+func (r *sliceRecords) Err() error {
+	return nil
+}
+
+func TestWriteMySQLRecords(t *testing.T) {
+	var buf bytes.Buffer
+	count, err := writeMySQLRecords(&buf, &sliceRecords{records: [][]interface{}{
+		{int64(1), "a\tb\nc\\d", nil},
+		{int64(2), true, time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{float64(1.5), []byte{'x', 0}, "e"},
+	}})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, count)
+	assert.EqualValues(t, "1\ta\\tb\\nc\\\\d\t\\N\n"+
+		"2\t1\t2023-01-02 03:04:05\n"+
+		"1.5\tx\\0\te\n", buf.String())
+}
+
+func TestSplitTableName(t *testing.T) {
+	schema, table := splitTableName("public.user")
+	assert.EqualValues(t, "public", schema)
+	assert.EqualValues(t, "user", table)
+
+	schema, table = splitTableName("user")
+	assert.EqualValues(t, "", schema)
+	assert.EqualValues(t, "user", table)
+}
+
+func TestIsLocalInfileDisabled(t *testing.T) {
+	assert.True(t, isLocalInfileDisabled(&mysql.MySQLError{Number: 3948}))
+	assert.True(t, isLocalInfileDisabled(fmt.Errorf("load: %w", &mysql.MySQLError{Number: 1148})))
+	assert.False(t, isLocalInfileDisabled(&mysql.MySQLError{Number: 1062}))
+	assert.False(t, isLocalInfileDisabled(errors.New("bad connection")))
+}
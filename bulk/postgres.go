@@ -0,0 +1,59 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bulk
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/stdlib"
+	"github.com/lib/pq"
+	"xorm.io/xorm"
+)
+
+// pgxLoader loads the records by COPY FROM of pgx
+type pgxLoader struct{}
+
+func (pgxLoader) BulkLoad(ctx context.Context, db *sql.DB, tx *sql.Tx, tableName string, columns []string, records xorm.BulkRecords) (int64, error) {
+	// the connection of a transaction of database/sql is unreachable
+	if tx != nil {
+		return 0, xorm.ErrBulkLoadUnsupported
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	identifier := pgx.Identifier{tableName}
+	if schema, table := splitTableName(tableName); schema != "" {
+		identifier = pgx.Identifier{schema, table}
+	}
+
+	var affected int64
+	err = conn.Raw(func(driverConn interface{}) error {
+		c, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errors.New("the connection is not of pgx")
+		}
+		affected, err = c.Conn().CopyFrom(ctx, identifier, columns, records)
+		return err
+	})
+	return affected, err
+}
+
+// pqLoader loads the records by CopyIn of lib/pq
+type pqLoader struct{}
+
+func (pqLoader) BulkLoad(ctx context.Context, db *sql.DB, tx *sql.Tx, tableName string, columns []string, records xorm.BulkRecords) (int64, error) {
+	query := pq.CopyIn(tableName, columns...)
+	if schema, table := splitTableName(tableName); schema != "" {
+		query = pq.CopyInSchema(schema, table, columns...)
+	}
+	return copyIn(ctx, db, tx, query, records)
+}
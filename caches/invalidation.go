@@ -0,0 +1,185 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package caches
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+)
+
+// InvalidationKind represents the kind of an invalidation
+type InvalidationKind string
+
+// enumerate all the invalidations broadcasted
+const (
+	InvalidateIds   InvalidationKind = "ClearIds"
+	InvalidateBeans InvalidationKind = "ClearBeans"
+	InvalidateBean  InvalidationKind = "DelBean"
+	// InvalidateAll is sent by a bus to its subscribers when some
+	// invalidations may have been lost, e.g. the ones published while
+	// resubscribing, so all the tables cached are cleared
+	InvalidateAll InvalidationKind = "ClearAll"
+)
+
+// Invalidation represents an invalidation of a cacher broadcasted to the
+// cachers of the other engines
+type Invalidation struct {
+	Kind   InvalidationKind `json:"kind"`
+	Table  string           `json:"table"`
+	ID     string           `json:"id,omitempty"`
+	Source string           `json:"source"` // the cacher publishing it
+}
+
+// InvalidationBus broadcasts the invalidations to all the subscribers
+// including the publisher itself. A bus which could lose the invalidations
+// should send InvalidateAll to the subscribers once it has recovered.
+type InvalidationBus interface {
+	Publish(inv Invalidation) error
+	Subscribe(handler func(inv Invalidation)) error
+}
+
+// MemoryBus implements InvalidationBus in the process, it broadcasts the
+// invalidations to the engines of the same process
+type MemoryBus struct {
+	handlers []func(inv Invalidation)
+	mutex    sync.RWMutex
+}
+
+var _ InvalidationBus = &MemoryBus{}
+
+// NewMemoryBus creates a bus in memory
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{}
+}
+
+// Publish implements InvalidationBus
+func (b *MemoryBus) Publish(inv Invalidation) error {
+	b.mutex.RLock()
+	handlers := b.handlers
+	b.mutex.RUnlock()
+	for _, handler := range handlers {
+		handler(inv)
+	}
+	return nil
+}
+
+// Subscribe implements InvalidationBus
+func (b *MemoryBus) Subscribe(handler func(inv Invalidation)) error {
+	b.mutex.Lock()
+	b.handlers = append(b.handlers, handler)
+	b.mutex.Unlock()
+	return nil
+}
+
+// BroadcastCacher wraps a cacher to broadcast its ClearIds, ClearBeans and
+// DelBean to the cachers of all the engines sharing the bus, so a write of an
+// engine doesn't leave stale beans in the others
+type BroadcastCacher struct {
+	Cacher
+	bus    InvalidationBus
+	source string
+
+	tables      map[string]bool // the tables cached to be cleared by InvalidateAll
+	tablesMutex sync.Mutex
+
+	// OnError is called if an invalidation couldn't be published
+	OnError func(err error)
+}
+
+var _ Cacher = &BroadcastCacher{}
+
+// NewBroadcastCacher wraps the cacher and subscribes it to the bus
+func NewBroadcastCacher(cacher Cacher, bus InvalidationBus) (*BroadcastCacher, error) {
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+	c := &BroadcastCacher{
+		Cacher: cacher,
+		bus:    bus,
+		source: hex.EncodeToString(id[:]),
+		tables: make(map[string]bool),
+	}
+	if err := bus.Subscribe(c.handle); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// handle applies the invalidations published by the other cachers
+func (c *BroadcastCacher) handle(inv Invalidation) {
+	if inv.Source == c.source {
+		return
+	}
+	switch inv.Kind {
+	case InvalidateIds:
+		c.Cacher.ClearIds(inv.Table)
+	case InvalidateBeans:
+		c.Cacher.ClearBeans(inv.Table)
+	case InvalidateBean:
+		c.Cacher.DelBean(inv.Table, inv.ID)
+	case InvalidateAll:
+		c.tablesMutex.Lock()
+		tables := make([]string, 0, len(c.tables))
+		for tableName := range c.tables {
+			tables = append(tables, tableName)
+		}
+		c.tablesMutex.Unlock()
+		for _, tableName := range tables {
+			c.Cacher.ClearIds(tableName)
+			c.Cacher.ClearBeans(tableName)
+		}
+	}
+}
+
+// addTable records the table cached
+func (c *BroadcastCacher) addTable(tableName string) {
+	c.tablesMutex.Lock()
+	c.tables[tableName] = true
+	c.tablesMutex.Unlock()
+}
+
+// PutIds implements Cacher
+func (c *BroadcastCacher) PutIds(tableName, sql string, ids interface{}) {
+	c.addTable(tableName)
+	c.Cacher.PutIds(tableName, sql, ids)
+}
+
+// PutBean implements Cacher
+func (c *BroadcastCacher) PutBean(tableName string, id string, obj interface{}) {
+	c.addTable(tableName)
+	c.Cacher.PutBean(tableName, id, obj)
+}
+
+func (c *BroadcastCacher) publish(kind InvalidationKind, tableName, id string) {
+	err := c.bus.Publish(Invalidation{
+		Kind:   kind,
+		Table:  tableName,
+		ID:     id,
+		Source: c.source,
+	})
+	if err != nil && c.OnError != nil {
+		c.OnError(err)
+	}
+}
+
+// ClearIds implements Cacher
+func (c *BroadcastCacher) ClearIds(tableName string) {
+	c.Cacher.ClearIds(tableName)
+	c.publish(InvalidateIds, tableName, "")
+}
+
+// ClearBeans implements Cacher
+func (c *BroadcastCacher) ClearBeans(tableName string) {
+	c.Cacher.ClearBeans(tableName)
+	c.publish(InvalidateBeans, tableName, "")
+}
+
+// DelBean implements Cacher
+func (c *BroadcastCacher) DelBean(tableName string, id string) {
+	c.Cacher.DelBean(tableName, id)
+	c.publish(InvalidateBean, tableName, id)
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package caches
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroadcastCacher(t *testing.T) {
+	bus := NewMemoryBus()
+
+	// two engines with their own stores
+	cacherA, err := NewBroadcastCacher(NewLRUCacher(NewMemoryStore(), 100), bus)
+	assert.NoError(t, err)
+	cacherB, err := NewBroadcastCacher(NewLRUCacher(NewMemoryStore(), 100), bus)
+	assert.NoError(t, err)
+
+	for _, cacher := range []Cacher{cacherA, cacherB} {
+		assert.Nil(t, cacher.GetBean("user", "1"))
+		cacher.PutBean("user", "1", "bean1")
+		assert.Nil(t, cacher.GetBean("user", "2"))
+		cacher.PutBean("user", "2", "bean2")
+		cacher.PutIds("user", "SELECT id FROM user", "ids")
+	}
+
+	cacherA.DelBean("user", "1")
+	assert.Nil(t, cacherA.GetBean("user", "1"))
+	assert.Nil(t, cacherB.GetBean("user", "1"))
+	assert.NotNil(t, cacherB.GetBean("user", "2"))
+
+	cacherB.PutIds("user", "SELECT id FROM user", "ids")
+	cacherA.ClearIds("user")
+	assert.Nil(t, cacherB.GetIds("user", "SELECT id FROM user"))
+
+	cacherB.ClearBeans("user")
+	assert.Nil(t, cacherA.GetBean("user", "2"))
+	assert.Nil(t, cacherB.GetBean("user", "2"))
+}
+
+func TestBroadcastCacherInvalidateAll(t *testing.T) {
+	bus := NewMemoryBus()
+	cacher, err := NewBroadcastCacher(NewLRUCacher(NewMemoryStore(), 100), bus)
+	assert.NoError(t, err)
+
+	assert.Nil(t, cacher.GetBean("user", "1"))
+	cacher.PutBean("user", "1", "bean1")
+	assert.Nil(t, cacher.GetIds("group", "SELECT id FROM group"))
+	cacher.PutIds("group", "SELECT id FROM group", "ids")
+
+	// sent by the bus itself after losing the invalidations
+	assert.NoError(t, bus.Publish(Invalidation{Kind: InvalidateAll}))
+	assert.Nil(t, cacher.GetBean("user", "1"))
+	assert.Nil(t, cacher.GetIds("group", "SELECT id FROM group"))
+}
+
+type errBus struct {
+	MemoryBus
+}
+
+func (b *errBus) Publish(inv Invalidation) error {
+	return errors.New("unreachable")
+}
+
+func TestBroadcastCacherError(t *testing.T) {
+	cacher, err := NewBroadcastCacher(NewLRUCacher(NewMemoryStore(), 100), &errBus{})
+	assert.NoError(t, err)
+
+	var publishErr error
+	cacher.OnError = func(err error) {
+		publishErr = err
+	}
+	cacher.ClearBeans("user")
+	assert.EqualError(t, publishErr, "unreachable")
+}
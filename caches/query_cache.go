@@ -0,0 +1,208 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package caches
+
+import (
+	"container/list"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultQueryCacheBytes is the default max bytes of the query cache of an
+// engine
+const DefaultQueryCacheBytes = 64 << 20
+
+type queryEntry struct {
+	key      string
+	value    interface{}
+	tables   []string
+	size     int64
+	expireAt time.Time
+}
+
+// QueryCache caches the results of the queries by their keys. An entry is
+// invalidated when it's expired or when a table referenced by its query is
+// written, the least recently used entries are evicted when the approximate
+// bytes exceed the max bytes.
+type QueryCache struct {
+	mutex    sync.Mutex
+	lru      *list.List
+	entries  map[string]*list.Element
+	tables   map[string]map[string]struct{} // table name -> keys
+	bytes    int64
+	maxBytes int64
+
+	// generation is increased by every invalidation, the results of the
+	// queries started before the last invalidation of their tables are not
+	// kept since they may be stale
+	generation  uint64
+	invalidated map[string]uint64
+	cleared     uint64
+
+	now func() time.Time
+}
+
+// NewQueryCache creates a query cache, maxBytes <= 0 means no limit
+func NewQueryCache(maxBytes int64) *QueryCache {
+	if maxBytes <= 0 {
+		maxBytes = math.MaxInt64
+	}
+	return &QueryCache{
+		lru:         list.New(),
+		entries:     make(map[string]*list.Element),
+		tables:      make(map[string]map[string]struct{}),
+		maxBytes:    maxBytes,
+		invalidated: make(map[string]uint64),
+		now:         time.Now,
+	}
+}
+
+// Generation returns the generation of the cache which should be got before
+// running the query and given to Put
+func (c *QueryCache) Generation() uint64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.generation
+}
+
+// Get returns the result of the key
+func (c *QueryCache) Get(key string) (interface{}, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*queryEntry)
+	if !entry.expireAt.IsZero() && c.now().After(entry.expireAt) {
+		c.remove(el)
+		return nil, false
+	}
+	c.lru.MoveToBack(el)
+	return entry.value, true
+}
+
+// Put keeps the result of the key for the ttl, ttl <= 0 means it's kept until
+// it's invalidated or evicted. The tables are the ones referenced by the
+// query, generation is the one got before running the query.
+func (c *QueryCache) Put(key string, value interface{}, ttl time.Duration, tables []string, generation uint64) {
+	tables = lowerTables(tables)
+	entry := &queryEntry{
+		key:    key,
+		value:  value,
+		tables: tables,
+		size:   entryOverhead + int64(len(key)) + estimateSize(reflect.ValueOf(value), 0),
+	}
+	if ttl > 0 {
+		entry.expireAt = c.now().Add(ttl)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.cleared > generation {
+		return
+	}
+	for _, table := range tables {
+		if c.invalidated[table] > generation {
+			return
+		}
+	}
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	if entry.size > c.maxBytes {
+		return
+	}
+	c.entries[key] = c.lru.PushBack(entry)
+	c.bytes += entry.size
+	for _, table := range tables {
+		keys, ok := c.tables[table]
+		if !ok {
+			keys = make(map[string]struct{})
+			c.tables[table] = keys
+		}
+		keys[key] = struct{}{}
+	}
+	for c.bytes > c.maxBytes {
+		c.remove(c.lru.Front())
+	}
+}
+
+// remove removes the element, the lock has to be held
+func (c *QueryCache) remove(el *list.Element) {
+	entry := el.Value.(*queryEntry)
+	c.lru.Remove(el)
+	delete(c.entries, entry.key)
+	c.bytes -= entry.size
+	for _, table := range entry.tables {
+		if keys, ok := c.tables[table]; ok {
+			delete(keys, entry.key)
+			if len(keys) == 0 {
+				delete(c.tables, table)
+			}
+		}
+	}
+}
+
+// Del removes the results of the keys
+func (c *QueryCache) Del(keys ...string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, key := range keys {
+		if el, ok := c.entries[key]; ok {
+			c.remove(el)
+		}
+	}
+}
+
+// Invalidate removes the results of the queries referencing the tables, the
+// names of the tables are case insensitive
+func (c *QueryCache) Invalidate(tables ...string) {
+	if len(tables) == 0 {
+		return
+	}
+	tables = lowerTables(tables)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.generation++
+	for _, table := range tables {
+		c.invalidated[table] = c.generation
+		for key := range c.tables[table] {
+			c.remove(c.entries[key])
+		}
+	}
+}
+
+// Clear removes all the results
+func (c *QueryCache) Clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.generation++
+	c.lru.Init()
+	c.entries = make(map[string]*list.Element)
+	c.tables = make(map[string]map[string]struct{})
+	c.bytes = 0
+	// the results of the queries running are not kept
+	c.cleared = c.generation
+	c.invalidated = make(map[string]uint64)
+}
+
+// Len returns the number of the results
+func (c *QueryCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.lru.Len()
+}
+
+func lowerTables(tables []string) []string {
+	lowers := make([]string, len(tables))
+	for i, table := range tables {
+		lowers[i] = strings.ToLower(table)
+	}
+	return lowers
+}
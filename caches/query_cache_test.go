@@ -0,0 +1,89 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package caches
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryCache(t *testing.T) {
+	cache := NewQueryCache(0)
+
+	gen := cache.Generation()
+	cache.Put("users", []string{"a", "b"}, 0, []string{"user"}, gen)
+	cache.Put("orders", 10, 0, []string{"User", "order"}, gen)
+	v, ok := cache.Get("users")
+	assert.True(t, ok)
+	assert.EqualValues(t, []string{"a", "b"}, v)
+	assert.EqualValues(t, 2, cache.Len())
+
+	cache.Invalidate("ORDER")
+	_, ok = cache.Get("orders")
+	assert.False(t, ok)
+	_, ok = cache.Get("users")
+	assert.True(t, ok)
+
+	// a query started before the invalidation of its table is not kept
+	cache.Put("orders", 10, 0, []string{"user", "order"}, gen)
+	_, ok = cache.Get("orders")
+	assert.False(t, ok)
+	cache.Put("orders", 10, 0, []string{"user", "order"}, cache.Generation())
+	_, ok = cache.Get("orders")
+	assert.True(t, ok)
+
+	cache.Invalidate("user")
+	assert.EqualValues(t, 0, cache.Len())
+
+	cache.Put("users", 1, 0, nil, cache.Generation())
+	cache.Del("users")
+	_, ok = cache.Get("users")
+	assert.False(t, ok)
+
+	gen = cache.Generation()
+	cache.Put("users", 1, 0, nil, gen)
+	cache.Clear()
+	assert.EqualValues(t, 0, cache.Len())
+	cache.Put("users", 1, 0, nil, gen)
+	assert.EqualValues(t, 0, cache.Len())
+}
+
+func TestQueryCacheExpiration(t *testing.T) {
+	now := time.Now()
+	cache := NewQueryCache(0)
+	cache.now = func() time.Time { return now }
+
+	cache.Put("a", 1, time.Minute, nil, cache.Generation())
+	cache.Put("b", 1, 0, nil, cache.Generation())
+	now = now.Add(2 * time.Minute)
+	_, ok := cache.Get("a")
+	assert.False(t, ok)
+	_, ok = cache.Get("b")
+	assert.True(t, ok)
+}
+
+func TestQueryCacheEviction(t *testing.T) {
+	value := strings.Repeat("x", 100)
+	size := entryOverhead + 1 + estimateSize(reflect.ValueOf(value), 0)
+	cache := NewQueryCache(3 * size)
+
+	for _, key := range []string{"a", "b", "c"} {
+		cache.Put(key, value, 0, []string{"t"}, cache.Generation())
+	}
+	_, ok := cache.Get("a")
+	assert.True(t, ok)
+	cache.Put("d", value, 0, []string{"t"}, cache.Generation())
+	assert.EqualValues(t, 3, cache.Len())
+	_, ok = cache.Get("b")
+	assert.False(t, ok)
+
+	cache.Invalidate("t")
+	assert.EqualValues(t, 0, cache.Len())
+	assert.EqualValues(t, 0, cache.bytes)
+}
@@ -0,0 +1,269 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package caches
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RedisOptions represents the options to connect to a server speaking the
+// Redis protocol (RESP), i.e. Redis, KeyDB, Dragonfly or Valkey
+type RedisOptions struct {
+	Addr     string
+	Password string
+	DB       int
+
+	// Prefix is prepended to the keys of the store, it's "xorm:" if empty
+	Prefix string
+	// Expiration is the TTL of the keys of the store, 0 means no expiration
+	Expiration time.Duration
+
+	DialTimeout time.Duration
+	ReadTimeout time.Duration
+	// PoolSize is the max number of the idle connections, it's 10 if 0
+	PoolSize int
+}
+
+const (
+	defaultRedisPrefix   = "xorm:"
+	defaultRedisPoolSize = 10
+)
+
+// ErrRedisClosed represents an error of a closed redis store or bus
+var ErrRedisClosed = errors.New("xorm/cache: redis is closed")
+
+// RedisError represents an error replied by the server
+type RedisError string
+
+func (err RedisError) Error() string {
+	return "xorm/cache: redis: " + string(err)
+}
+
+// redisConn represents a connection speaking RESP
+type redisConn struct {
+	conn        net.Conn
+	r           *bufio.Reader
+	w           *bufio.Writer
+	readTimeout time.Duration
+}
+
+func dialRedis(opts *RedisOptions) (*redisConn, error) {
+	conn, err := net.DialTimeout("tcp", opts.Addr, opts.DialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &redisConn{
+		conn:        conn,
+		r:           bufio.NewReader(conn),
+		w:           bufio.NewWriter(conn),
+		readTimeout: opts.ReadTimeout,
+	}
+	if opts.Password != "" {
+		if _, err := c.do("AUTH", opts.Password); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	if opts.DB != 0 {
+		if _, err := c.do("SELECT", strconv.Itoa(opts.DB)); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *redisConn) Close() error {
+	return c.conn.Close()
+}
+
+// send writes a command as an array of bulk strings
+func (c *redisConn) send(args ...interface{}) error {
+	if _, err := fmt.Fprintf(c.w, "*%d\r\n", len(args)); err != nil {
+		return err
+	}
+	for _, arg := range args {
+		var b []byte
+		switch t := arg.(type) {
+		case []byte:
+			b = t
+		case string:
+			b = []byte(t)
+		default:
+			b = []byte(fmt.Sprint(t))
+		}
+		if _, err := fmt.Fprintf(c.w, "$%d\r\n", len(b)); err != nil {
+			return err
+		}
+		if _, err := c.w.Write(b); err != nil {
+			return err
+		}
+		if _, err := c.w.WriteString("\r\n"); err != nil {
+			return err
+		}
+	}
+	return c.w.Flush()
+}
+
+// receive reads a reply, a RedisError is returned as the value so the
+// connection is still usable
+func (c *redisConn) receive() (interface{}, error) {
+	if c.readTimeout > 0 {
+		if err := c.conn.SetReadDeadline(time.Now().Add(c.readTimeout)); err != nil {
+			return nil, err
+		}
+	}
+	return readRESP(c.r)
+}
+
+// do sends a command and reads its reply
+func (c *redisConn) do(args ...interface{}) (interface{}, error) {
+	if err := c.send(args...); err != nil {
+		return nil, err
+	}
+	reply, err := c.receive()
+	if err != nil {
+		return nil, err
+	}
+	if redisErr, ok := reply.(RedisError); ok {
+		return nil, redisErr
+	}
+	return reply, nil
+}
+
+// readRESP reads a RESP value, the simple strings are string, the errors are
+// RedisError, the integers are int64, the bulk strings are []byte and the
+// arrays are []interface{}, nil bulk strings and arrays are nil
+func readRESP(r *bufio.Reader) (interface{}, error) {
+	line, err := readRESPLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("xorm/cache: redis: empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return RedisError(line[1:]), nil
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		values := make([]interface{}, n)
+		for i := range values {
+			if values[i], err = readRESP(r); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("xorm/cache: redis: unknown reply %q", line)
+}
+
+// readRESPLine reads a line without the trailing CRLF
+func readRESPLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("xorm/cache: redis: bad line %q", line)
+	}
+	return line[:len(line)-2], nil
+}
+
+// redisPool keeps the idle connections
+type redisPool struct {
+	opts   RedisOptions
+	mutex  sync.Mutex
+	idle   []*redisConn
+	closed bool
+}
+
+func newRedisPool(opts RedisOptions) *redisPool {
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = defaultRedisPoolSize
+	}
+	return &redisPool{opts: opts}
+}
+
+func (p *redisPool) get() (*redisConn, error) {
+	p.mutex.Lock()
+	if p.closed {
+		p.mutex.Unlock()
+		return nil, ErrRedisClosed
+	}
+	if n := len(p.idle); n > 0 {
+		c := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.mutex.Unlock()
+		return c, nil
+	}
+	p.mutex.Unlock()
+	return dialRedis(&p.opts)
+}
+
+// put returns the connection to the pool, the broken ones are closed
+func (p *redisPool) put(c *redisConn, broken bool) {
+	p.mutex.Lock()
+	if broken || p.closed || len(p.idle) >= p.opts.PoolSize {
+		p.mutex.Unlock()
+		c.Close()
+		return
+	}
+	p.idle = append(p.idle, c)
+	p.mutex.Unlock()
+}
+
+// do runs a command on a connection of the pool
+func (p *redisPool) do(args ...interface{}) (interface{}, error) {
+	c, err := p.get()
+	if err != nil {
+		return nil, err
+	}
+	reply, err := c.do(args...)
+	_, isRedisErr := err.(RedisError)
+	p.put(c, err != nil && !isRedisErr)
+	return reply, err
+}
+
+func (p *redisPool) Close() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.closed = true
+	for _, c := range p.idle {
+		c.Close()
+	}
+	p.idle = nil
+	return nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package caches
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// redisResubscribeInterval is the interval to reconnect a broken subscription
+const redisResubscribeInterval = time.Second
+
+// RedisBus implements InvalidationBus by the PUBLISH and SUBSCRIBE of a
+// server speaking the Redis protocol, so the invalidations reach the engines
+// of all the processes
+type RedisBus struct {
+	pool    *redisPool
+	opts    RedisOptions
+	channel string
+
+	mutex  sync.Mutex
+	subs   []*redisConn
+	closed bool
+}
+
+var _ InvalidationBus = &RedisBus{}
+
+// NewRedisBus creates a bus publishing the invalidations to the channel
+func NewRedisBus(opts RedisOptions, channel string) *RedisBus {
+	// the subscriptions wait for the messages without a deadline
+	subOpts := opts
+	subOpts.ReadTimeout = 0
+	return &RedisBus{
+		pool:    newRedisPool(opts),
+		opts:    subOpts,
+		channel: channel,
+	}
+}
+
+// Publish implements InvalidationBus
+func (b *RedisBus) Publish(inv Invalidation) error {
+	payload, err := json.Marshal(inv)
+	if err != nil {
+		return err
+	}
+	_, err = b.pool.do("PUBLISH", b.channel, payload)
+	return err
+}
+
+// Subscribe implements InvalidationBus, the subscription is done when it
+// returns and it's renewed in the background if the connection is broken.
+// The messages of Redis are delivered at most once, the ones published while
+// the connection is broken are lost, so the handler receives InvalidateAll
+// after resubscribing. A non-zero Expiration of the store still bounds how
+// long a lost invalidation leaves a stale entry.
+func (b *RedisBus) Subscribe(handler func(inv Invalidation)) error {
+	c, err := b.subscribe()
+	if err != nil {
+		return err
+	}
+	go func() {
+		for {
+			b.receive(c, handler)
+			for {
+				if b.isClosed() {
+					return
+				}
+				time.Sleep(redisResubscribeInterval)
+				if c, err = b.subscribe(); err == nil {
+					handler(Invalidation{Kind: InvalidateAll})
+					break
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (b *RedisBus) isClosed() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.closed
+}
+
+// subscribe dials a connection subscribing the channel
+func (b *RedisBus) subscribe() (*redisConn, error) {
+	c, err := dialRedis(&b.opts)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.do("SUBSCRIBE", b.channel); err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.closed {
+		c.Close()
+		return nil, ErrRedisClosed
+	}
+	b.subs = append(b.subs, c)
+	return c, nil
+}
+
+// receive handles the messages until the connection is broken or closed
+func (b *RedisBus) receive(c *redisConn, handler func(inv Invalidation)) {
+	defer func() {
+		c.Close()
+		b.mutex.Lock()
+		for i, sub := range b.subs {
+			if sub == c {
+				b.subs = append(b.subs[:i], b.subs[i+1:]...)
+				break
+			}
+		}
+		b.mutex.Unlock()
+	}()
+
+	for {
+		reply, err := c.receive()
+		if err != nil {
+			return
+		}
+		msg, ok := reply.([]interface{})
+		if !ok || len(msg) != 3 {
+			continue
+		}
+		if kind, _ := msg[0].([]byte); string(kind) != "message" {
+			continue
+		}
+		payload, _ := msg[2].([]byte)
+		var inv Invalidation
+		if err := json.Unmarshal(payload, &inv); err != nil {
+			continue
+		}
+		handler(inv)
+	}
+}
+
+// Close closes the connections and the subscriptions of the bus
+func (b *RedisBus) Close() error {
+	b.mutex.Lock()
+	b.closed = true
+	for _, c := range b.subs {
+		c.Close()
+	}
+	b.subs = nil
+	b.mutex.Unlock()
+	return b.pool.Close()
+}
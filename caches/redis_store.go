@@ -0,0 +1,88 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package caches
+
+import (
+	"encoding/gob"
+	"strconv"
+
+	"xorm.io/xorm/schemas"
+)
+
+func init() {
+	// the ids of the queries are put by LRUCacher.PutIds
+	gob.Register(schemas.PK{})
+	gob.Register([]schemas.PK{})
+}
+
+// RedisStore implements CacheStore by a server speaking the Redis protocol,
+// so the cache could be shared by the engines of many processes. The values
+// are encoded by gob, the types of the beans have to be registered by
+// gob.Register.
+type RedisStore struct {
+	pool   *redisPool
+	prefix string
+	ttl    string
+}
+
+var _ CacheStore = &RedisStore{}
+
+// NewRedisStore creates a redis store, the connections are dialed when the
+// store is used
+func NewRedisStore(opts RedisOptions) *RedisStore {
+	s := &RedisStore{
+		pool:   newRedisPool(opts),
+		prefix: opts.Prefix,
+	}
+	if s.prefix == "" {
+		s.prefix = defaultRedisPrefix
+	}
+	if opts.Expiration > 0 {
+		s.ttl = strconv.FormatInt(opts.Expiration.Milliseconds(), 10)
+	}
+	return s
+}
+
+// Put implements CacheStore
+func (s *RedisStore) Put(key string, value interface{}) error {
+	val, err := Encode(value)
+	if err != nil {
+		return err
+	}
+	if s.ttl != "" {
+		_, err = s.pool.do("SET", s.prefix+key, val, "PX", s.ttl)
+	} else {
+		_, err = s.pool.do("SET", s.prefix+key, val)
+	}
+	return err
+}
+
+// Get implements CacheStore
+func (s *RedisStore) Get(key string) (interface{}, error) {
+	reply, err := s.pool.do("GET", s.prefix+key)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := reply.([]byte)
+	if !ok {
+		return nil, ErrNotExist
+	}
+	var v interface{}
+	if err := Decode(data, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Del implements CacheStore
+func (s *RedisStore) Del(key string) error {
+	_, err := s.pool.do("DEL", s.prefix+key)
+	return err
+}
+
+// Close closes the connections of the store
+func (s *RedisStore) Close() error {
+	return s.pool.Close()
+}
@@ -0,0 +1,366 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package caches
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"xorm.io/xorm/schemas"
+)
+
+// respServer is a tiny in process server speaking the subset of RESP used by
+// RedisStore and RedisBus
+type respServer struct {
+	listener net.Listener
+	password string
+
+	mutex       sync.Mutex
+	values      map[string][]byte
+	expires     map[string]time.Time
+	subscribers map[string][]*respServerConn
+	conns       []net.Conn
+}
+
+type respServerConn struct {
+	conn  net.Conn
+	w     *bufio.Writer
+	mutex sync.Mutex
+}
+
+func (c *respServerConn) write(format string, args ...interface{}) {
+	c.mutex.Lock()
+	fmt.Fprintf(c.w, format, args...)
+	c.w.Flush()
+	c.mutex.Unlock()
+}
+
+func (c *respServerConn) writeBulk(b []byte) {
+	if b == nil {
+		c.write("$-1\r\n")
+		return
+	}
+	c.write("$%d\r\n%s\r\n", len(b), b)
+}
+
+func newRESPServer(t *testing.T, password string) *respServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	s := &respServer{
+		listener:    listener,
+		password:    password,
+		values:      make(map[string][]byte),
+		expires:     make(map[string]time.Time),
+		subscribers: make(map[string][]*respServerConn),
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.mutex.Lock()
+			s.conns = append(s.conns, conn)
+			s.mutex.Unlock()
+			go s.serve(conn)
+		}
+	}()
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *respServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close closes the listener and all the connections
+func (s *respServer) Close() {
+	s.listener.Close()
+	s.mutex.Lock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+	s.mutex.Unlock()
+}
+
+func (s *respServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	c := &respServerConn{conn: conn, w: bufio.NewWriter(conn)}
+	authed := s.password == ""
+	for {
+		reply, err := readRESP(r)
+		if err != nil {
+			return
+		}
+		values, _ := reply.([]interface{})
+		args := make([]string, len(values))
+		for i, v := range values {
+			b, _ := v.([]byte)
+			args[i] = string(b)
+		}
+		if len(args) == 0 {
+			c.write("-ERR empty command\r\n")
+			continue
+		}
+		cmd := strings.ToUpper(args[0])
+		if !authed && cmd != "AUTH" {
+			c.write("-NOAUTH Authentication required.\r\n")
+			continue
+		}
+		switch cmd {
+		case "AUTH":
+			if len(args) == 2 && args[1] == s.password {
+				authed = true
+				c.write("+OK\r\n")
+			} else {
+				c.write("-WRONGPASS invalid password\r\n")
+			}
+		case "PING":
+			c.write("+PONG\r\n")
+		case "SELECT":
+			c.write("+OK\r\n")
+		case "SET":
+			s.mutex.Lock()
+			s.values[args[1]] = []byte(args[2])
+			delete(s.expires, args[1])
+			if len(args) == 5 && strings.ToUpper(args[3]) == "PX" {
+				ms, _ := strconv.Atoi(args[4])
+				s.expires[args[1]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+			}
+			s.mutex.Unlock()
+			c.write("+OK\r\n")
+		case "GET":
+			s.mutex.Lock()
+			v, ok := s.values[args[1]]
+			if expire, has := s.expires[args[1]]; has && time.Now().After(expire) {
+				delete(s.values, args[1])
+				delete(s.expires, args[1])
+				v, ok = nil, false
+			}
+			s.mutex.Unlock()
+			if !ok {
+				v = nil
+			}
+			c.writeBulk(v)
+		case "DEL":
+			var n int
+			s.mutex.Lock()
+			for _, key := range args[1:] {
+				if _, ok := s.values[key]; ok {
+					n++
+				}
+				delete(s.values, key)
+				delete(s.expires, key)
+			}
+			s.mutex.Unlock()
+			c.write(":%d\r\n", n)
+		case "PUBLISH":
+			s.mutex.Lock()
+			subs := append([]*respServerConn(nil), s.subscribers[args[1]]...)
+			s.mutex.Unlock()
+			for _, sub := range subs {
+				sub.write("*3\r\n$7\r\nmessage\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n", len(args[1]), args[1], len(args[2]), args[2])
+			}
+			c.write(":%d\r\n", len(subs))
+		case "SUBSCRIBE":
+			s.mutex.Lock()
+			for i, channel := range args[1:] {
+				s.subscribers[channel] = append(s.subscribers[channel], c)
+				c.write("*3\r\n$9\r\nsubscribe\r\n$%d\r\n%s\r\n:%d\r\n", len(channel), channel, i+1)
+			}
+			s.mutex.Unlock()
+		default:
+			c.write("-ERR unknown command '%s'\r\n", args[0])
+		}
+	}
+}
+
+type redisTestBean struct {
+	Id   int64
+	Name string
+}
+
+func init() {
+	gob.Register(&redisTestBean{})
+}
+
+func TestRedisStore(t *testing.T) {
+	server := newRESPServer(t, "secret")
+
+	store := NewRedisStore(RedisOptions{
+		Addr:     server.Addr(),
+		Password: "secret",
+		DB:       1,
+	})
+	defer store.Close()
+
+	var kvs = map[string]interface{}{
+		"a":           "b",
+		"user-p-1":    &redisTestBean{Id: 1, Name: "xlw"},
+		"SELECT 1-[]": []schemas.PK{{int64(1)}, {int64(2), "b"}},
+	}
+	for k, v := range kvs {
+		assert.NoError(t, store.Put(k, v))
+	}
+
+	for k, v := range kvs {
+		val, err := store.Get(k)
+		assert.NoError(t, err)
+		assert.EqualValues(t, v, val)
+	}
+
+	for k := range kvs {
+		err := store.Del(k)
+		assert.NoError(t, err)
+	}
+
+	for k := range kvs {
+		_, err := store.Get(k)
+		assert.EqualValues(t, ErrNotExist, err)
+	}
+
+	// the keys are prefixed
+	assert.NoError(t, store.Put("a", "b"))
+	server.mutex.Lock()
+	_, ok := server.values["xorm:a"]
+	server.mutex.Unlock()
+	assert.True(t, ok)
+
+	assert.NoError(t, store.Close())
+	_, err := store.Get("a")
+	assert.EqualValues(t, ErrRedisClosed, err)
+}
+
+func TestRedisStoreExpiration(t *testing.T) {
+	server := newRESPServer(t, "")
+
+	store := NewRedisStore(RedisOptions{
+		Addr:       server.Addr(),
+		Prefix:     "test:",
+		Expiration: 20 * time.Millisecond,
+	})
+	defer store.Close()
+
+	assert.NoError(t, store.Put("a", "b"))
+	val, err := store.Get("a")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "b", val)
+
+	time.Sleep(50 * time.Millisecond)
+	_, err = store.Get("a")
+	assert.EqualValues(t, ErrNotExist, err)
+}
+
+func TestRedisStoreAuth(t *testing.T) {
+	server := newRESPServer(t, "secret")
+
+	store := NewRedisStore(RedisOptions{
+		Addr:     server.Addr(),
+		Password: "wrong",
+	})
+	defer store.Close()
+
+	err := store.Put("a", "b")
+	assert.Error(t, err)
+	_, ok := err.(RedisError)
+	assert.True(t, ok)
+}
+
+func TestRedisBus(t *testing.T) {
+	server := newRESPServer(t, "")
+	opts := RedisOptions{Addr: server.Addr()}
+
+	// two engines share the store and the bus
+	newCacher := func() (*BroadcastCacher, *RedisBus) {
+		store := NewRedisStore(opts)
+		t.Cleanup(func() { store.Close() })
+		bus := NewRedisBus(opts, "xorm:invalidation")
+		t.Cleanup(func() { bus.Close() })
+		cacher, err := NewBroadcastCacher(NewLRUCacher(store, 100), bus)
+		assert.NoError(t, err)
+		return cacher, bus
+	}
+	cacherA, _ := newCacher()
+	cacherB, busB := newCacher()
+
+	// the sql is only known by the engine B
+	ids := []schemas.PK{{int64(1)}, {int64(2)}}
+	cacherB.PutIds("user", "SELECT id FROM user", ids)
+	assert.EqualValues(t, ids, cacherB.GetIds("user", "SELECT id FROM user"))
+
+	cacherA.ClearIds("user")
+	assert.Eventually(t, func() bool {
+		return cacherB.GetIds("user", "SELECT id FROM user") == nil
+	}, time.Second, 5*time.Millisecond)
+
+	assert.Nil(t, cacherB.GetBean("user", "1"))
+	cacherB.PutBean("user", "1", &redisTestBean{Id: 1, Name: "xlw"})
+	assert.NotNil(t, cacherA.GetBean("user", "1"))
+
+	cacherB.ClearBeans("user")
+	assert.Eventually(t, func() bool {
+		return cacherA.GetBean("user", "1") == nil
+	}, time.Second, 5*time.Millisecond)
+
+	// the subscription is closed with the bus
+	assert.NoError(t, busB.Close())
+	assert.EqualValues(t, ErrRedisClosed, busB.Publish(Invalidation{Kind: InvalidateIds, Table: "user"}))
+}
+
+func TestRedisBusResubscribe(t *testing.T) {
+	server := newRESPServer(t, "")
+	opts := RedisOptions{Addr: server.Addr()}
+
+	bus := NewRedisBus(opts, "xorm:invalidation")
+	defer bus.Close()
+
+	received := make(chan Invalidation, 10)
+	assert.NoError(t, bus.Subscribe(func(inv Invalidation) {
+		received <- inv
+	}))
+
+	// break the connections but keep the server
+	server.mutex.Lock()
+	for _, conn := range server.conns {
+		conn.Close()
+	}
+	server.conns = nil
+	server.subscribers = make(map[string][]*respServerConn)
+	server.mutex.Unlock()
+
+	assert.Eventually(t, func() bool {
+		server.mutex.Lock()
+		defer server.mutex.Unlock()
+		return len(server.subscribers["xorm:invalidation"]) == 1
+	}, 3*time.Second, 10*time.Millisecond)
+
+	// the invalidations published while resubscribing are lost
+	select {
+	case inv := <-received:
+		assert.EqualValues(t, InvalidateAll, inv.Kind)
+	case <-time.After(time.Second):
+		t.Fatal("the subscriber is not told to clear all")
+	}
+
+	assert.NoError(t, bus.Publish(Invalidation{Kind: InvalidateBean, Table: "user", ID: "1"}))
+	select {
+	case inv := <-received:
+		assert.EqualValues(t, InvalidateBean, inv.Kind)
+		assert.EqualValues(t, "user", inv.Table)
+		assert.EqualValues(t, "1", inv.ID)
+	case <-time.After(time.Second):
+		t.Fatal("the invalidation is not received")
+	}
+}
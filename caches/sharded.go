@@ -0,0 +1,384 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package caches
+
+import (
+	"container/list"
+	"hash/fnv"
+	"math"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultCacheShards is the default number of the shards of ShardedCacher
+	DefaultCacheShards = 16
+
+	// entryOverhead is the approximate bytes of the list element and the
+	// indexes of an entry
+	entryOverhead = 128
+	// maxSizeDepth limits the walk of the values to estimate their size
+	maxSizeDepth = 8
+)
+
+// CacheStats represents the counters of the cache of a table
+type CacheStats struct {
+	Hits        int64
+	Misses      int64
+	Evictions   int64 // the entries removed to keep the size under the limit
+	Expirations int64 // the entries removed since they are expired
+}
+
+type cacheCounters struct {
+	hits, misses, evictions, expirations int64
+}
+
+type entryKind uint8
+
+const (
+	kindIds entryKind = iota
+	kindBean
+)
+
+type shardEntry struct {
+	kind     entryKind
+	table    string
+	key      string
+	value    interface{}
+	size     int64
+	expireAt time.Time
+}
+
+type tableKey struct {
+	kind  entryKind
+	table string
+}
+
+// cacheShard is an LRU list of a part of the entries
+type cacheShard struct {
+	mutex    sync.Mutex
+	lru      *list.List
+	tables   map[tableKey]map[string]*list.Element
+	bytes    int64
+	maxBytes int64
+}
+
+// ShardedCacher implements Cacher by the LRU lists of the shards, the entries
+// are spread to the shards by the table and the key so the shards are locked
+// separately. The entries are evicted when the approximate bytes of a shard
+// exceed its part of the max bytes, and the expired entries are removed when
+// they are visited, no goroutine is needed.
+type ShardedCacher struct {
+	shards  []*cacheShard
+	mask    uint32
+	expired time.Duration
+	stats   sync.Map // table name -> *cacheCounters
+
+	now func() time.Time
+}
+
+var _ Cacher = &ShardedCacher{}
+
+// NewShardedCacher creates a cacher, the number of the shards is rounded up to
+// a power of 2 and it's DefaultCacheShards if shards <= 0, maxBytes <= 0
+// means no limit and expired <= 0 means the entries never expire
+func NewShardedCacher(shards int, maxBytes int64, expired time.Duration) *ShardedCacher {
+	if shards <= 0 {
+		shards = DefaultCacheShards
+	}
+	if maxBytes <= 0 {
+		maxBytes = math.MaxInt64
+	}
+	n := 1
+	for n < shards {
+		n <<= 1
+	}
+	m := &ShardedCacher{
+		shards:  make([]*cacheShard, n),
+		mask:    uint32(n - 1),
+		expired: expired,
+		now:     time.Now,
+	}
+	for i := range m.shards {
+		m.shards[i] = &cacheShard{
+			lru:      list.New(),
+			tables:   make(map[tableKey]map[string]*list.Element),
+			maxBytes: maxBytes / int64(n),
+		}
+	}
+	return m
+}
+
+func (m *ShardedCacher) shard(tableName, key string) *cacheShard {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(tableName))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(key))
+	return m.shards[h.Sum32()&m.mask]
+}
+
+func (m *ShardedCacher) counters(tableName string) *cacheCounters {
+	if c, ok := m.stats.Load(tableName); ok {
+		return c.(*cacheCounters)
+	}
+	c, _ := m.stats.LoadOrStore(tableName, &cacheCounters{})
+	return c.(*cacheCounters)
+}
+
+// Stats returns the counters of the table
+func (m *ShardedCacher) Stats(tableName string) CacheStats {
+	c := m.counters(tableName)
+	return CacheStats{
+		Hits:        atomic.LoadInt64(&c.hits),
+		Misses:      atomic.LoadInt64(&c.misses),
+		Evictions:   atomic.LoadInt64(&c.evictions),
+		Expirations: atomic.LoadInt64(&c.expirations),
+	}
+}
+
+// AllStats returns the counters of all the tables visited
+func (m *ShardedCacher) AllStats() map[string]CacheStats {
+	stats := make(map[string]CacheStats)
+	m.stats.Range(func(k, _ interface{}) bool {
+		stats[k.(string)] = m.Stats(k.(string))
+		return true
+	})
+	return stats
+}
+
+// Bytes returns the approximate bytes of the entries
+func (m *ShardedCacher) Bytes() int64 {
+	var bytes int64
+	for _, s := range m.shards {
+		s.mutex.Lock()
+		bytes += s.bytes
+		s.mutex.Unlock()
+	}
+	return bytes
+}
+
+// Len returns the number of the entries
+func (m *ShardedCacher) Len() int {
+	var n int
+	for _, s := range m.shards {
+		s.mutex.Lock()
+		n += s.lru.Len()
+		s.mutex.Unlock()
+	}
+	return n
+}
+
+func (m *ShardedCacher) isExpired(entry *shardEntry) bool {
+	return m.expired > 0 && m.now().After(entry.expireAt)
+}
+
+// remove removes the element from the shard, the lock has to be held
+func (s *cacheShard) remove(el *list.Element) {
+	entry := el.Value.(*shardEntry)
+	s.lru.Remove(el)
+	s.bytes -= entry.size
+	tk := tableKey{entry.kind, entry.table}
+	if idx, ok := s.tables[tk]; ok {
+		delete(idx, entry.key)
+		if len(idx) == 0 {
+			delete(s.tables, tk)
+		}
+	}
+}
+
+func (m *ShardedCacher) get(kind entryKind, tableName, key string) interface{} {
+	s := m.shard(tableName, key)
+	c := m.counters(tableName)
+
+	s.mutex.Lock()
+	el, ok := s.tables[tableKey{kind, tableName}][key]
+	if !ok {
+		s.mutex.Unlock()
+		atomic.AddInt64(&c.misses, 1)
+		return nil
+	}
+	entry := el.Value.(*shardEntry)
+	if m.isExpired(entry) {
+		s.remove(el)
+		s.mutex.Unlock()
+		atomic.AddInt64(&c.expirations, 1)
+		atomic.AddInt64(&c.misses, 1)
+		return nil
+	}
+	s.lru.MoveToBack(el)
+	s.mutex.Unlock()
+	atomic.AddInt64(&c.hits, 1)
+	return entry.value
+}
+
+func (m *ShardedCacher) put(kind entryKind, tableName, key string, value interface{}) {
+	s := m.shard(tableName, key)
+	entry := &shardEntry{
+		kind:  kind,
+		table: tableName,
+		key:   key,
+		value: value,
+		size:  entryOverhead + int64(len(tableName)+len(key)) + estimateSize(reflect.ValueOf(value), 0),
+	}
+	if m.expired > 0 {
+		entry.expireAt = m.now().Add(m.expired)
+	}
+
+	s.mutex.Lock()
+	tk := tableKey{kind, tableName}
+	if el, ok := s.tables[tk][key]; ok {
+		s.remove(el)
+	}
+	if entry.size > s.maxBytes {
+		s.mutex.Unlock()
+		atomic.AddInt64(&m.counters(tableName).evictions, 1)
+		return
+	}
+	idx, ok := s.tables[tk]
+	if !ok {
+		idx = make(map[string]*list.Element)
+		s.tables[tk] = idx
+	}
+	idx[key] = s.lru.PushBack(entry)
+	s.bytes += entry.size
+
+	// the expired entries and then the least recently used ones are removed
+	var evicted, expired []string
+	for s.bytes > s.maxBytes || (s.lru.Len() > 1 && m.isExpired(s.lru.Front().Value.(*shardEntry))) {
+		front := s.lru.Front()
+		old := front.Value.(*shardEntry)
+		if m.isExpired(old) {
+			expired = append(expired, old.table)
+		} else {
+			evicted = append(evicted, old.table)
+		}
+		s.remove(front)
+	}
+	s.mutex.Unlock()
+
+	for _, table := range evicted {
+		atomic.AddInt64(&m.counters(table).evictions, 1)
+	}
+	for _, table := range expired {
+		atomic.AddInt64(&m.counters(table).expirations, 1)
+	}
+}
+
+func (m *ShardedCacher) del(kind entryKind, tableName, key string) {
+	s := m.shard(tableName, key)
+	s.mutex.Lock()
+	if el, ok := s.tables[tableKey{kind, tableName}][key]; ok {
+		s.remove(el)
+	}
+	s.mutex.Unlock()
+}
+
+func (m *ShardedCacher) clear(kind entryKind, tableName string) {
+	tk := tableKey{kind, tableName}
+	for _, s := range m.shards {
+		s.mutex.Lock()
+		for _, el := range s.tables[tk] {
+			s.remove(el)
+		}
+		s.mutex.Unlock()
+	}
+}
+
+// GetIds implements Cacher
+func (m *ShardedCacher) GetIds(tableName, sql string) interface{} {
+	return m.get(kindIds, tableName, sql)
+}
+
+// GetBean implements Cacher
+func (m *ShardedCacher) GetBean(tableName string, id string) interface{} {
+	return m.get(kindBean, tableName, id)
+}
+
+// PutIds implements Cacher
+func (m *ShardedCacher) PutIds(tableName, sql string, ids interface{}) {
+	m.put(kindIds, tableName, sql, ids)
+}
+
+// PutBean implements Cacher
+func (m *ShardedCacher) PutBean(tableName string, id string, obj interface{}) {
+	m.put(kindBean, tableName, id, obj)
+}
+
+// DelIds implements Cacher
+func (m *ShardedCacher) DelIds(tableName, sql string) {
+	m.del(kindIds, tableName, sql)
+}
+
+// DelBean implements Cacher, the ids of the table are cleared too since they
+// may contain the bean
+func (m *ShardedCacher) DelBean(tableName string, id string) {
+	m.del(kindBean, tableName, id)
+	m.clear(kindIds, tableName)
+}
+
+// ClearIds implements Cacher
+func (m *ShardedCacher) ClearIds(tableName string) {
+	m.clear(kindIds, tableName)
+}
+
+// ClearBeans implements Cacher
+func (m *ShardedCacher) ClearBeans(tableName string) {
+	m.clear(kindBean, tableName)
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// estimateSize returns the approximate bytes of the value
+func estimateSize(v reflect.Value, depth int) int64 {
+	if !v.IsValid() {
+		return 0
+	}
+	if depth > maxSizeDepth {
+		return int64(v.Type().Size())
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return int64(v.Type().Size()) + int64(v.Len())
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return int64(v.Type().Size())
+		}
+		return int64(v.Type().Size()) + estimateSize(v.Elem(), depth+1)
+	case reflect.Slice:
+		size := int64(v.Type().Size())
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return size + int64(v.Len())
+		}
+		for i := 0; i < v.Len(); i++ {
+			size += estimateSize(v.Index(i), depth+1)
+		}
+		return size
+	case reflect.Array:
+		var size int64
+		for i := 0; i < v.Len(); i++ {
+			size += estimateSize(v.Index(i), depth+1)
+		}
+		return size
+	case reflect.Map:
+		size := int64(v.Type().Size())
+		iter := v.MapRange()
+		for iter.Next() {
+			size += estimateSize(iter.Key(), depth+1) + estimateSize(iter.Value(), depth+1)
+		}
+		return size
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return int64(v.Type().Size())
+		}
+		var size int64
+		for i := 0; i < v.NumField(); i++ {
+			size += estimateSize(v.Field(i), depth+1)
+		}
+		return size
+	}
+	return int64(v.Type().Size())
+}
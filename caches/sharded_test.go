@@ -0,0 +1,211 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package caches
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"xorm.io/xorm/schemas"
+)
+
+func TestShardedCacher(t *testing.T) {
+	type CacheObject1 struct {
+		Id int64
+	}
+
+	cacher := NewShardedCacher(0, 0, 0)
+	assert.EqualValues(t, DefaultCacheShards, len(cacher.shards))
+
+	tableName := "cache_object1"
+	pks := []schemas.PK{
+		{1},
+		{2},
+	}
+
+	for _, pk := range pks {
+		sid, err := pk.ToString()
+		assert.NoError(t, err)
+
+		cacher.PutIds(tableName, "select * from cache_object1", sid)
+		ids := cacher.GetIds(tableName, "select * from cache_object1")
+		assert.EqualValues(t, sid, ids)
+
+		cacher.ClearIds(tableName)
+		ids2 := cacher.GetIds(tableName, "select * from cache_object1")
+		assert.Nil(t, ids2)
+
+		obj2 := cacher.GetBean(tableName, sid)
+		assert.Nil(t, obj2)
+
+		var obj = new(CacheObject1)
+		cacher.PutBean(tableName, sid, obj)
+		obj3 := cacher.GetBean(tableName, sid)
+		assert.EqualValues(t, obj, obj3)
+
+		// the ids of the table are cleared with the bean
+		cacher.PutIds(tableName, "select * from cache_object1", sid)
+		cacher.DelBean(tableName, sid)
+		obj4 := cacher.GetBean(tableName, sid)
+		assert.Nil(t, obj4)
+		assert.Nil(t, cacher.GetIds(tableName, "select * from cache_object1"))
+	}
+
+	stats := cacher.Stats(tableName)
+	assert.EqualValues(t, 4, stats.Hits)
+	assert.EqualValues(t, 8, stats.Misses)
+	assert.EqualValues(t, 0, stats.Evictions)
+	assert.EqualValues(t, CacheStats{}, cacher.Stats("other"))
+	assert.EqualValues(t, 0, cacher.Len())
+	assert.EqualValues(t, 0, cacher.Bytes())
+}
+
+func TestShardedCacherEviction(t *testing.T) {
+	// a shard keeps about 4 beans
+	value := strings.Repeat("x", 100)
+	size := entryOverhead + int64(len("user")+len("00")) + estimateSize(reflect.ValueOf(value), 0)
+	cacher := NewShardedCacher(1, 4*size, 0)
+
+	for i := 0; i < 10; i++ {
+		cacher.PutBean("user", fmt.Sprintf("%02d", i), value)
+	}
+	assert.EqualValues(t, 4, cacher.Len())
+	assert.EqualValues(t, 4*size, cacher.Bytes())
+	assert.EqualValues(t, 6, cacher.Stats("user").Evictions)
+
+	// the least recently used are evicted
+	assert.Nil(t, cacher.GetBean("user", "05"))
+	assert.NotNil(t, cacher.GetBean("user", "06"))
+	cacher.PutBean("user", "10", value)
+	assert.NotNil(t, cacher.GetBean("user", "06"))
+	assert.Nil(t, cacher.GetBean("user", "07"))
+
+	// a value larger than the shard is not kept
+	cacher.PutBean("user", "big", strings.Repeat("x", int(4*size)))
+	assert.Nil(t, cacher.GetBean("user", "big"))
+	assert.EqualValues(t, 4, cacher.Len())
+
+	// replacing a value doesn't count the old one
+	cacher.PutBean("user", "06", value)
+	assert.EqualValues(t, 4*size, cacher.Bytes())
+
+	cacher.ClearBeans("user")
+	assert.EqualValues(t, 0, cacher.Len())
+	assert.EqualValues(t, 0, cacher.Bytes())
+}
+
+func TestShardedCacherExpiration(t *testing.T) {
+	now := time.Now()
+	cacher := NewShardedCacher(1, 0, time.Minute)
+	cacher.now = func() time.Time { return now }
+
+	cacher.PutBean("user", "1", "bean1")
+	cacher.PutIds("user", "select * from user", "ids")
+	now = now.Add(30 * time.Second)
+	cacher.PutBean("user", "2", "bean2")
+	assert.EqualValues(t, "bean1", cacher.GetBean("user", "1"))
+
+	// expired lazily when it's visited
+	now = now.Add(31 * time.Second)
+	assert.Nil(t, cacher.GetBean("user", "1"))
+	assert.EqualValues(t, "bean2", cacher.GetBean("user", "2"))
+	assert.EqualValues(t, 1, cacher.Stats("user").Expirations)
+
+	// or when an entry is put after it
+	cacher.PutBean("user", "3", "bean3")
+	assert.EqualValues(t, 2, cacher.Len())
+	assert.EqualValues(t, 2, cacher.Stats("user").Expirations)
+	assert.Nil(t, cacher.GetIds("user", "select * from user"))
+}
+
+func TestShardedCacherConcurrency(t *testing.T) {
+	cacher := NewShardedCacher(8, 64*1024, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			table := fmt.Sprintf("table%d", i%2)
+			for j := 0; j < 1000; j++ {
+				id := fmt.Sprint(j % 100)
+				if cacher.GetBean(table, id) == nil {
+					cacher.PutBean(table, id, &struct{ Id int }{j})
+				}
+				cacher.PutIds(table, "select "+id, id)
+				if j%50 == 0 {
+					cacher.ClearIds(table)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	stats := cacher.AllStats()
+	assert.EqualValues(t, 2, len(stats))
+	for _, s := range stats {
+		assert.True(t, s.Hits > 0)
+		assert.EqualValues(t, 4000, s.Hits+s.Misses)
+	}
+	assert.True(t, cacher.Bytes() <= 64*1024)
+}
+
+func TestEstimateSize(t *testing.T) {
+	type Bean struct {
+		Id      int64
+		Name    string
+		Data    []byte
+		Tags    []string
+		Created time.Time
+	}
+	bean := &Bean{
+		Name: strings.Repeat("a", 100),
+		Data: make([]byte, 1000),
+		Tags: []string{"a", "b"},
+	}
+	size := estimateSize(reflect.ValueOf(bean), 0)
+	assert.True(t, size > 1100)
+	assert.True(t, size < 1400)
+	assert.EqualValues(t, 0, estimateSize(reflect.ValueOf(nil), 0))
+
+	// the cycles are bounded
+	type Node struct {
+		Next *Node
+	}
+	node := &Node{}
+	node.Next = node
+	assert.True(t, estimateSize(reflect.ValueOf(node), 0) > 0)
+}
+
+func BenchmarkShardedCacher(b *testing.B) {
+	cacher := NewShardedCacher(0, 0, time.Hour)
+	benchmarkCacher(b, cacher)
+}
+
+func BenchmarkLRUCacher(b *testing.B) {
+	cacher := NewLRUCacher(NewMemoryStore(), 100000)
+	benchmarkCacher(b, cacher)
+}
+
+func benchmarkCacher(b *testing.B, cacher Cacher) {
+	for i := 0; i < 1000; i++ {
+		id := fmt.Sprint(i)
+		cacher.GetBean("user", id)
+		cacher.PutBean("user", id, id)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		var i int
+		for pb.Next() {
+			cacher.GetBean("user", fmt.Sprint(i%1000))
+			i++
+		}
+	})
+}
@@ -8,27 +8,46 @@ import (
 	"context"
 	"database/sql"
 	"time"
+
+	"xorm.io/xorm/schemas"
 )
 
 // ContextHook represents a hook context
 type ContextHook struct {
 	start       time.Time
 	Ctx         context.Context
-	SQL         string        // log content or SQL
-	Args        []interface{} // if it's a SQL, it's the arguments
+	SQL         string        // log content or SQL, it could be rewritten in BeforeProcess
+	Args        []interface{} // if it's a SQL, it's the arguments, they could be rewritten in BeforeProcess
 	Result      sql.Result
 	ExecuteTime time.Duration
 	Err         error // SQL executed error
+
+	// the operation of the session running the SQL, they are empty if the
+	// SQL is not run by a session
+	Operation Operation
+	Table     *schemas.Table
+	Beans     []interface{}
+	SessionID string
+
+	shortCircuited bool
+	rows           *SyntheticRows
 }
 
 // NewContextHook return context for hook
 func NewContextHook(ctx context.Context, sql string, args []interface{}) *ContextHook {
-	return &ContextHook{
+	c := &ContextHook{
 		start: time.Now(),
 		Ctx:   ctx,
 		SQL:   sql,
 		Args:  args,
 	}
+	if md := MetadataFrom(ctx); md != nil {
+		c.Operation = md.Operation
+		c.Table = md.Table
+		c.Beans = md.Beans
+		c.SessionID = md.SessionID
+	}
+	return c
 }
 
 // End finish the hook invokation
@@ -39,6 +58,34 @@ func (c *ContextHook) End(ctx context.Context, result sql.Result, err error) {
 	c.ExecuteTime = time.Since(c.start)
 }
 
+// ShortCircuit skips the execution of the SQL when it's called in
+// BeforeProcess, the exec returns the result and the query returns no rows
+func (c *ContextHook) ShortCircuit(result sql.Result) {
+	c.shortCircuited = true
+	c.Result = result
+}
+
+// ShortCircuitRows skips the execution of the query when it's called in
+// BeforeProcess, the query returns the rows of the columns
+func (c *ContextHook) ShortCircuitRows(columns []string, rows [][]interface{}) {
+	c.shortCircuited = true
+	c.rows = &SyntheticRows{Columns: columns, Rows: rows}
+}
+
+// ShortCircuited reports whether the execution of the SQL is skipped
+func (c *ContextHook) ShortCircuited() bool {
+	return c.shortCircuited
+}
+
+// SyntheticRows returns the rows given by ShortCircuitRows, it's empty rows
+// if the SQL is short circuited by ShortCircuit
+func (c *ContextHook) SyntheticRows() *SyntheticRows {
+	if c.rows == nil {
+		return &SyntheticRows{}
+	}
+	return c.rows
+}
+
 // Hook represents a hook behaviour
 type Hook interface {
 	BeforeProcess(c *ContextHook) (context.Context, error)
@@ -64,6 +111,10 @@ func (h *Hooks) BeforeProcess(c *ContextHook) (context.Context, error) {
 		if err != nil {
 			return nil, err
 		}
+		// the next hook gets the context of the previous one
+		if ctx != nil {
+			c.Ctx = ctx
+		}
 	}
 	return ctx, nil
 }
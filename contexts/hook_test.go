@@ -82,6 +82,28 @@ func TestBeforeProcess(t *testing.T) {
 	}
 }
 
+func TestBeforeProcessContext(t *testing.T) {
+	type key struct{}
+	hooks := Hooks{}
+	hooks.AddHook(
+		&testHook{
+			before: func(c *ContextHook) (context.Context, error) {
+				return context.WithValue(c.Ctx, key{}, "first"), nil
+			},
+		},
+		&testHook{},
+	)
+	ctx, err := hooks.BeforeProcess(&ContextHook{
+		Ctx: context.Background(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := ctx.Value(key{}); v != "first" {
+		t.Errorf("got %v, expect the context of the first hook", v)
+	}
+}
+
 func TestAfterProcess(t *testing.T) {
 	expectErr := errors.New("expect err")
 	tests := []struct {
@@ -138,3 +160,54 @@ func TestAfterProcess(t *testing.T) {
 		})
 	}
 }
+
+func TestContextHookMetadata(t *testing.T) {
+	c := NewContextHook(context.Background(), "SELECT 1", nil)
+	if c.Operation != "" || c.Table != nil || c.SessionID != "" {
+		t.Errorf("got metadata %v %v %v, expect none", c.Operation, c.Table, c.SessionID)
+	}
+
+	bean := struct{ Id int64 }{}
+	ctx := WithMetadata(context.Background(), &Metadata{
+		Operation: OperationInsert,
+		Beans:     []interface{}{&bean},
+		SessionID: "abc",
+	})
+	c = NewContextHook(ctx, "INSERT INTO t (id) VALUES (?)", []interface{}{1})
+	if c.Operation != OperationInsert {
+		t.Errorf("got operation %v, expect %v", c.Operation, OperationInsert)
+	}
+	if len(c.Beans) != 1 || c.Beans[0] != &bean {
+		t.Errorf("got beans %v, expect the inserted bean", c.Beans)
+	}
+	if c.SessionID != "abc" {
+		t.Errorf("got session id %v, expect abc", c.SessionID)
+	}
+}
+
+func TestContextHookShortCircuit(t *testing.T) {
+	c := NewContextHook(context.Background(), "SELECT 1", nil)
+	if c.ShortCircuited() {
+		t.Fatal("the hook context should not be short circuited")
+	}
+	if rows := c.SyntheticRows(); len(rows.Columns) != 0 || len(rows.Rows) != 0 {
+		t.Errorf("got rows %v, expect empty rows", rows)
+	}
+
+	c.ShortCircuit(SyntheticResult{LastID: 3, Affected: 1})
+	if !c.ShortCircuited() {
+		t.Fatal("the hook context should be short circuited")
+	}
+	if id, _ := c.Result.LastInsertId(); id != 3 {
+		t.Errorf("got last insert id %v, expect 3", id)
+	}
+
+	c = NewContextHook(context.Background(), "SELECT id FROM t", nil)
+	c.ShortCircuitRows([]string{"id"}, [][]interface{}{{1}, {2}})
+	if !c.ShortCircuited() {
+		t.Fatal("the hook context should be short circuited")
+	}
+	if rows := c.SyntheticRows(); len(rows.Rows) != 2 || rows.Columns[0] != "id" {
+		t.Errorf("got rows %v, expect the given rows", rows)
+	}
+}
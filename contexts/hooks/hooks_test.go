@@ -0,0 +1,96 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"xorm.io/xorm/contexts"
+	"xorm.io/xorm/log"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testLogger struct {
+	messages []string
+}
+
+func (l *testLogger) Warnf(format string, v ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, v...))
+}
+
+func runHook(hook contexts.Hook, ctx context.Context, sql string, args []interface{}, executeTime time.Duration) error {
+	c := contexts.NewContextHook(ctx, sql, args)
+	ctx, err := hook.BeforeProcess(c)
+	if err != nil {
+		return err
+	}
+	c.End(ctx, nil, nil)
+	c.ExecuteTime = executeTime
+	return hook.AfterProcess(c)
+}
+
+func TestSlowQueryHook(t *testing.T) {
+	logger := &testLogger{}
+	hook := NewSlowQueryHook(100*time.Millisecond, logger)
+	var slow []string
+	hook.OnSlowQuery = func(c *contexts.ContextHook) {
+		slow = append(slow, c.SQL)
+	}
+
+	ctx := context.WithValue(context.Background(), log.SessionIDKey, "abc")
+	assert.NoError(t, runHook(hook, ctx, "SELECT 1", nil, time.Millisecond))
+	assert.NoError(t, runHook(hook, ctx, "SELECT 2", []interface{}{1}, time.Second))
+	assert.EqualValues(t, []string{"SELECT 2"}, slow)
+	assert.EqualValues(t, 1, len(logger.messages))
+	assert.Contains(t, logger.messages[0], "[SLOW SQL] [abc] SELECT 2 [1] - 1s")
+}
+
+func TestNPlusOneDetector(t *testing.T) {
+	logger := &testLogger{}
+	detector := NewNPlusOneDetector(3, logger)
+	var reports []*NPlusOneReport
+	detector.OnDetect = func(report *NPlusOneReport) {
+		reports = append(reports, report)
+	}
+
+	// the statements out of the requests are not counted
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, runHook(detector, context.Background(), "SELECT * FROM `user` WHERE `id`=?", []interface{}{i}, 0))
+	}
+	assert.Empty(t, reports)
+
+	ctx := detector.NewContext(context.Background())
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, runHook(detector, ctx, fmt.Sprintf("SELECT * FROM `user` WHERE `id`=%d", i), nil, 0))
+		assert.NoError(t, runHook(detector, ctx, "COMMIT", nil, 0))
+	}
+	if assert.EqualValues(t, 1, len(reports)) {
+		assert.EqualValues(t, "SELECT * FROM `user` WHERE `id`=?", reports[0].Shape)
+		assert.EqualValues(t, 4, reports[0].Count)
+		assert.Contains(t, reports[0].Stack, "TestNPlusOneDetector")
+	}
+	assert.EqualValues(t, 1, len(logger.messages))
+
+	// another request counts from zero
+	ctx = detector.NewContext(context.Background())
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, runHook(detector, ctx, "SELECT * FROM `user` WHERE `id`=?", []interface{}{i}, 0))
+	}
+	assert.EqualValues(t, 1, len(reports))
+
+	// the statements of a session
+	ctx = context.WithValue(context.Background(), log.SessionIDKey, "abc")
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, runHook(detector, ctx, "SELECT * FROM `user` WHERE `id` IN (?,?)", []interface{}{i, i + 1}, 0))
+	}
+	assert.EqualValues(t, 2, len(reports))
+	detector.EndSession("abc")
+	assert.NoError(t, runHook(detector, ctx, "SELECT * FROM `user` WHERE `id` IN (?,?)", []interface{}{1, 2}, 0))
+	assert.EqualValues(t, 2, len(reports))
+}
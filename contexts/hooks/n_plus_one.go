@@ -0,0 +1,179 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+
+	"xorm.io/xorm/contexts"
+	"xorm.io/xorm/log"
+)
+
+// maxDetectedSessions is the max number of the sessions of which the
+// statements are counted, the counts are dropped beyond it
+const maxDetectedSessions = 10000
+
+// NPlusOneReport represents the statement repeated more than the threshold
+type NPlusOneReport struct {
+	// Shape is the SQL normalized by contexts.NormalizeSQL
+	Shape string
+	SQL   string
+	Count int
+	// Stack is the stack trace of the caller running the statement
+	Stack string
+}
+
+// NPlusOneDetector counts the statements of the same shape in a request and
+// reports when the same shape repeats more than the threshold, which is
+// usually a query in a loop which should be one query. The request is the
+// context created by NewContext and passed by engine.Context(ctx), or the
+// session if the session ids are enabled by engine.EnableSessionID(true).
+type NPlusOneDetector struct {
+	Threshold int
+	Logger    Logger
+	// OnDetect is called once per shape per request if it's not nil
+	OnDetect func(report *NPlusOneReport)
+
+	mutex    sync.Mutex
+	sessions map[string]*nPlusOneScope
+}
+
+var _ contexts.Hook = &NPlusOneDetector{}
+
+type nPlusOneScopeKey struct {
+	detector *NPlusOneDetector
+}
+
+type nPlusOneScope struct {
+	mutex  sync.Mutex
+	counts map[string]int
+}
+
+// NewNPlusOneDetector creates a detector reporting the shapes repeated more
+// than the threshold times, the reports are logged to stdout if the logger
+// is nil
+func NewNPlusOneDetector(threshold int, logger Logger) *NPlusOneDetector {
+	return &NPlusOneDetector{
+		Threshold: threshold,
+		Logger:    defaultLogger(logger),
+		sessions:  make(map[string]*nPlusOneScope),
+	}
+}
+
+// NewContext returns a context of a request in which the statements are
+// counted
+func (d *NPlusOneDetector) NewContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, nPlusOneScopeKey{d}, &nPlusOneScope{counts: make(map[string]int)})
+}
+
+// EndSession drops the counts of the session
+func (d *NPlusOneDetector) EndSession(sessionID string) {
+	d.mutex.Lock()
+	delete(d.sessions, sessionID)
+	d.mutex.Unlock()
+}
+
+func (d *NPlusOneDetector) scope(ctx context.Context) *nPlusOneScope {
+	if ctx == nil {
+		return nil
+	}
+	if scope, ok := ctx.Value(nPlusOneScopeKey{d}).(*nPlusOneScope); ok {
+		return scope
+	}
+
+	sessionID, ok := ctx.Value(log.SessionIDKey).(string)
+	if !ok {
+		return nil
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.sessions == nil || len(d.sessions) >= maxDetectedSessions {
+		d.sessions = make(map[string]*nPlusOneScope)
+	}
+	scope, ok := d.sessions[sessionID]
+	if !ok {
+		scope = &nPlusOneScope{counts: make(map[string]int)}
+		d.sessions[sessionID] = scope
+	}
+	return scope
+}
+
+// isTxStatement reports whether the hook is of a transaction or a prepare
+func isTxStatement(sqlStr string) bool {
+	switch sqlStr {
+	case "BEGIN TRANSACTION", "COMMIT", "ROLLBACK", "PREPARE":
+		return true
+	}
+	return false
+}
+
+// BeforeProcess implements contexts.Hook
+func (d *NPlusOneDetector) BeforeProcess(c *contexts.ContextHook) (context.Context, error) {
+	return c.Ctx, nil
+}
+
+// AfterProcess implements contexts.Hook
+func (d *NPlusOneDetector) AfterProcess(c *contexts.ContextHook) error {
+	if c.Err != nil || isTxStatement(c.SQL) {
+		return nil
+	}
+	scope := d.scope(c.Ctx)
+	if scope == nil {
+		return nil
+	}
+
+	shape := contexts.NormalizeSQL(c.SQL)
+	scope.mutex.Lock()
+	scope.counts[shape]++
+	count := scope.counts[shape]
+	scope.mutex.Unlock()
+	// report once when the count exceeds the threshold
+	if count != d.Threshold+1 {
+		return nil
+	}
+
+	report := &NPlusOneReport{
+		Shape: shape,
+		SQL:   c.SQL,
+		Count: count,
+		Stack: callerStack(),
+	}
+	if d.Logger != nil {
+		d.Logger.Warnf("[N+1 SQL]%s %s executed %d times in one request\n%s",
+			sessionPart(c.Ctx), report.Shape, report.Count, report.Stack)
+	}
+	if d.OnDetect != nil {
+		d.OnDetect(report)
+	}
+	return nil
+}
+
+// callerStack returns the stack trace without the frames of the hooks, the
+// database/sql and the runtime
+func callerStack() string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var buf strings.Builder
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "xorm.io/xorm/contexts.") &&
+			!strings.HasPrefix(frame.Function, "xorm.io/xorm/contexts/hooks.(") &&
+			!strings.HasPrefix(frame.Function, "xorm.io/xorm/core.") &&
+			!strings.HasPrefix(frame.Function, "database/sql.") &&
+			!strings.HasPrefix(frame.Function, "runtime.") {
+			fmt.Fprintf(&buf, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		}
+		if !more {
+			break
+		}
+	}
+	return buf.String()
+}
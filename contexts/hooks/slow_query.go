@@ -0,0 +1,82 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package hooks provides the hooks consuming the hook contexts of the
+// executed SQL, they are added by engine.AddHook
+//
+//	engine.AddHook(hooks.NewSlowQueryHook(time.Second, engine.Logger()))
+//	engine.AddHook(hooks.NewNPlusOneDetector(10, engine.Logger()))
+package hooks
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"xorm.io/xorm/contexts"
+	"xorm.io/xorm/log"
+)
+
+// Logger represents the logger of the hooks, log.Logger and
+// log.ContextLogger are both Loggers
+type Logger interface {
+	Warnf(format string, v ...interface{})
+}
+
+func defaultLogger(logger Logger) Logger {
+	if logger == nil {
+		return log.NewSimpleLogger(os.Stdout)
+	}
+	return logger
+}
+
+// sessionPart returns the session id of the context like the SQL logger
+func sessionPart(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if id, ok := ctx.Value(log.SessionIDKey).(string); ok {
+		return " [" + id + "]"
+	}
+	return ""
+}
+
+// SlowQueryHook logs the SQL executed longer than the threshold
+type SlowQueryHook struct {
+	Threshold time.Duration
+	Logger    Logger
+	// OnSlowQuery is called with the hook context of every slow SQL if it's
+	// not nil
+	OnSlowQuery func(c *contexts.ContextHook)
+}
+
+var _ contexts.Hook = &SlowQueryHook{}
+
+// NewSlowQueryHook creates a slow query hook, the SQL is logged to stdout if
+// the logger is nil
+func NewSlowQueryHook(threshold time.Duration, logger Logger) *SlowQueryHook {
+	return &SlowQueryHook{
+		Threshold: threshold,
+		Logger:    defaultLogger(logger),
+	}
+}
+
+// BeforeProcess implements contexts.Hook
+func (h *SlowQueryHook) BeforeProcess(c *contexts.ContextHook) (context.Context, error) {
+	return c.Ctx, nil
+}
+
+// AfterProcess implements contexts.Hook
+func (h *SlowQueryHook) AfterProcess(c *contexts.ContextHook) error {
+	if c.ExecuteTime < h.Threshold {
+		return nil
+	}
+	if h.Logger != nil {
+		h.Logger.Warnf("[SLOW SQL]%s %s %v - %v", sessionPart(c.Ctx), c.SQL, c.Args, c.ExecuteTime)
+	}
+	if h.OnSlowQuery != nil {
+		h.OnSlowQuery(c)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package contexts
+
+import (
+	"context"
+	"database/sql/driver"
+
+	"xorm.io/xorm/schemas"
+)
+
+// Operation represents the kind of the operation running the SQL
+type Operation string
+
+// enumerate all the operations
+const (
+	OperationGet    Operation = "Get"
+	OperationFind   Operation = "Find"
+	OperationInsert Operation = "Insert"
+	OperationUpdate Operation = "Update"
+	OperationDelete Operation = "Delete"
+	OperationExec   Operation = "Exec"
+	OperationQuery  Operation = "Query"
+	OperationDDL    Operation = "DDL"
+)
+
+// Metadata represents the operation of a session, it's carried by the
+// context of the SQL to the hooks
+type Metadata struct {
+	Operation Operation
+	Table     *schemas.Table
+	Beans     []interface{}
+	SessionID string
+}
+
+type metadataKey struct{}
+
+// WithMetadata returns a context carrying the metadata
+func WithMetadata(ctx context.Context, md *Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, md)
+}
+
+// MetadataFrom returns the metadata of the context, it's nil if there is no
+// metadata
+func MetadataFrom(ctx context.Context) *Metadata {
+	if ctx == nil {
+		return nil
+	}
+	md, _ := ctx.Value(metadataKey{}).(*Metadata)
+	return md
+}
+
+// SyntheticRows represents the rows returned by a short circuited query
+type SyntheticRows struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// SyntheticResult represents the result of a short circuited exec
+type SyntheticResult struct {
+	LastID   int64
+	Affected int64
+}
+
+var _ driver.Result = SyntheticResult{}
+
+// LastInsertId implements sql.Result
+func (r SyntheticResult) LastInsertId() (int64, error) {
+	return r.LastID, nil
+}
+
+// RowsAffected implements sql.Result
+func (r SyntheticResult) RowsAffected() (int64, error) {
+	return r.Affected, nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package contexts
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	placeholderListRe = regexp.MustCompile(`\(\s*\?(\s*,\s*\?)*\s*\)`)
+	placeholderRowsRe = regexp.MustCompile(`\(\?\)(\s*,\s*\(\?\))+`)
+)
+
+// NormalizeSQL returns the shape of the SQL, the literals and the
+// placeholders like $1, :1 or @p1 are replaced by ?, the lists of the
+// placeholders like IN (?, ?, ?) or the rows of VALUES are collapsed to (?)
+// and the spaces are collapsed to one, so the SQL of the same statement with
+// the different arguments has the same shape
+func NormalizeSQL(sqlStr string) string {
+	var (
+		buf       strings.Builder
+		lastSpace = true
+	)
+	buf.Grow(len(sqlStr))
+	for i := 0; i < len(sqlStr); i++ {
+		c := sqlStr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if !lastSpace {
+				buf.WriteByte(' ')
+				lastSpace = true
+			}
+			continue
+		case c == '\'':
+			// the escaped quote is two quotes
+			for i++; i < len(sqlStr); i++ {
+				if sqlStr[i] == '\'' {
+					if i+1 < len(sqlStr) && sqlStr[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			buf.WriteByte('?')
+		case c == '`' || c == '"' || c == '[':
+			end := c
+			if c == '[' {
+				end = ']'
+			}
+			j := strings.IndexByte(sqlStr[i+1:], end)
+			if j < 0 {
+				buf.WriteString(sqlStr[i:])
+				i = len(sqlStr)
+			} else {
+				buf.WriteString(sqlStr[i : i+j+2])
+				i += j + 1
+			}
+		case (c == '$' || c == ':') && i+1 < len(sqlStr) && isDigit(sqlStr[i+1]):
+			for i+1 < len(sqlStr) && isDigit(sqlStr[i+1]) {
+				i++
+			}
+			buf.WriteByte('?')
+		case c == '@' && i+2 < len(sqlStr) && (sqlStr[i+1] == 'p' || sqlStr[i+1] == 'P') && isDigit(sqlStr[i+2]):
+			i++
+			for i+1 < len(sqlStr) && isDigit(sqlStr[i+1]) {
+				i++
+			}
+			buf.WriteByte('?')
+		case isDigit(c) && (i == 0 || !isIdentChar(sqlStr[i-1])):
+			for i+1 < len(sqlStr) && (isDigit(sqlStr[i+1]) || sqlStr[i+1] == '.') {
+				i++
+			}
+			buf.WriteByte('?')
+		case isIdentChar(c):
+			// keep the digits in the identifiers like t1
+			j := i
+			for j+1 < len(sqlStr) && isIdentChar(sqlStr[j+1]) {
+				j++
+			}
+			buf.WriteString(sqlStr[i : j+1])
+			i = j
+		default:
+			buf.WriteByte(c)
+		}
+		lastSpace = false
+	}
+
+	shape := strings.TrimSpace(buf.String())
+	shape = placeholderListRe.ReplaceAllString(shape, "(?)")
+	return placeholderRowsRe.ReplaceAllString(shape, "(?)")
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
@@ -0,0 +1,30 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package contexts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeSQL(t *testing.T) {
+	kases := []struct {
+		sql   string
+		shape string
+	}{
+		{"SELECT * FROM `user` WHERE `id`=?", "SELECT * FROM `user` WHERE `id`=?"},
+		{"SELECT * FROM \"user\" WHERE \"id\"=$1 AND \"name\"=$2", "SELECT * FROM \"user\" WHERE \"id\"=? AND \"name\"=?"},
+		{"SELECT * FROM [user] WHERE [id]=@p1", "SELECT * FROM [user] WHERE [id]=?"},
+		{"SELECT  *\n FROM t1 WHERE a = 10 AND b = 'it''s' AND c = 1.5", "SELECT * FROM t1 WHERE a = ? AND b = ? AND c = ?"},
+		{"SELECT * FROM t WHERE id IN (?, ?,?)", "SELECT * FROM t WHERE id IN (?)"},
+		{"SELECT * FROM t WHERE id IN (1,2,3)", "SELECT * FROM t WHERE id IN (?)"},
+		{"INSERT INTO t (a,b) VALUES (?,?),(?,?)", "INSERT INTO t (a,b) VALUES (?)"},
+		{"SELECT '5' FROM `t2` WHERE `a1`=:1", "SELECT ? FROM `t2` WHERE `a1`=?"},
+	}
+	for _, kase := range kases {
+		assert.EqualValues(t, kase.shape, NormalizeSQL(kase.sql), kase.sql)
+	}
+}
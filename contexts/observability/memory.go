@@ -0,0 +1,175 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package observability
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RecordedSpan represents a span recorded by InMemoryExporter
+type RecordedSpan struct {
+	Name       string
+	Attributes map[string]interface{}
+	Err        error
+	Start      time.Time
+	End        time.Time
+	Ended      bool
+}
+
+// RecordedValue represents a value of a metric recorded by InMemoryExporter
+type RecordedValue struct {
+	Value      float64
+	Attributes map[string]interface{}
+}
+
+// InMemoryExporter is a Tracer and a Meter keeping the spans and the metrics
+// in memory for the tests
+type InMemoryExporter struct {
+	mutex   sync.Mutex
+	spans   []*RecordedSpan
+	metrics map[string][]RecordedValue
+}
+
+var (
+	_ Tracer = &InMemoryExporter{}
+	_ Meter  = &InMemoryExporter{}
+)
+
+// NewInMemoryExporter creates an in memory exporter
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{
+		metrics: make(map[string][]RecordedValue),
+	}
+}
+
+func attributesMap(attrs []Attribute) map[string]interface{} {
+	m := make(map[string]interface{}, len(attrs))
+	for _, attr := range attrs {
+		m[attr.Key] = attr.Value
+	}
+	return m
+}
+
+// Start implements Tracer
+func (e *InMemoryExporter) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	span := &RecordedSpan{
+		Name:       name,
+		Attributes: attributesMap(attrs),
+		Start:      time.Now(),
+	}
+	e.mutex.Lock()
+	e.spans = append(e.spans, span)
+	e.mutex.Unlock()
+	return ctx, &memorySpan{exporter: e, span: span}
+}
+
+// Spans returns the copies of the recorded spans
+func (e *InMemoryExporter) Spans() []RecordedSpan {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	spans := make([]RecordedSpan, 0, len(e.spans))
+	for _, span := range e.spans {
+		s := *span
+		s.Attributes = make(map[string]interface{}, len(span.Attributes))
+		for k, v := range span.Attributes {
+			s.Attributes[k] = v
+		}
+		spans = append(spans, s)
+	}
+	return spans
+}
+
+// Counter implements Meter
+func (e *InMemoryExporter) Counter(name string) Counter {
+	return &memoryInstrument{exporter: e, name: name}
+}
+
+// Histogram implements Meter
+func (e *InMemoryExporter) Histogram(name string) Histogram {
+	return &memoryInstrument{exporter: e, name: name}
+}
+
+// Values returns the recorded values of the metric
+func (e *InMemoryExporter) Values(name string) []RecordedValue {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]RecordedValue(nil), e.metrics[name]...)
+}
+
+// Sum returns the sum of the values of the metric which have the attributes
+func (e *InMemoryExporter) Sum(name string, attrs ...Attribute) float64 {
+	var sum float64
+	for _, v := range e.Values(name) {
+		matched := true
+		for _, attr := range attrs {
+			if v.Attributes[attr.Key] != attr.Value {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			sum += v.Value
+		}
+	}
+	return sum
+}
+
+// Reset drops the recorded spans and metrics
+func (e *InMemoryExporter) Reset() {
+	e.mutex.Lock()
+	e.spans = nil
+	e.metrics = make(map[string][]RecordedValue)
+	e.mutex.Unlock()
+}
+
+type memorySpan struct {
+	exporter *InMemoryExporter
+	span     *RecordedSpan
+}
+
+func (s *memorySpan) SetAttributes(attrs ...Attribute) {
+	s.exporter.mutex.Lock()
+	for _, attr := range attrs {
+		s.span.Attributes[attr.Key] = attr.Value
+	}
+	s.exporter.mutex.Unlock()
+}
+
+func (s *memorySpan) RecordError(err error) {
+	s.exporter.mutex.Lock()
+	s.span.Err = err
+	s.exporter.mutex.Unlock()
+}
+
+func (s *memorySpan) End() {
+	s.exporter.mutex.Lock()
+	s.span.End = time.Now()
+	s.span.Ended = true
+	s.exporter.mutex.Unlock()
+}
+
+type memoryInstrument struct {
+	exporter *InMemoryExporter
+	name     string
+}
+
+func (i *memoryInstrument) record(value float64, attrs []Attribute) {
+	i.exporter.mutex.Lock()
+	i.exporter.metrics[i.name] = append(i.exporter.metrics[i.name], RecordedValue{
+		Value:      value,
+		Attributes: attributesMap(attrs),
+	})
+	i.exporter.mutex.Unlock()
+}
+
+func (i *memoryInstrument) Add(ctx context.Context, n int64, attrs ...Attribute) {
+	i.record(float64(n), attrs)
+}
+
+func (i *memoryInstrument) Record(ctx context.Context, value float64, attrs ...Attribute) {
+	i.record(value, attrs)
+}
@@ -0,0 +1,171 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package observability provides a hook tracing every statement as a span
+// and recording the rate, the errors and the duration of the statements. The
+// spans and the metrics are sent to the small Tracer and Meter interfaces, so
+// OpenTelemetry, Prometheus or others could be adapted without xorm
+// depending on them.
+//
+//	exporter := observability.NewInMemoryExporter()
+//	engine.AddHook(observability.NewHook(engine.Dialect().URI().DBType, exporter, exporter))
+package observability
+
+import (
+	"context"
+	"time"
+
+	"xorm.io/xorm/contexts"
+	"xorm.io/xorm/schemas"
+)
+
+// The attribute keys follow the semantic conventions of OpenTelemetry
+const (
+	AttrDBSystem     = "db.system"
+	AttrDBOperation  = "db.operation"
+	AttrDBTable      = "db.sql.table"
+	AttrDBStatement  = "db.statement"
+	AttrRowsAffected = "db.rows_affected"
+	AttrError        = "error"
+)
+
+// The names of the metrics
+const (
+	MetricStatements = "xorm.statements"
+	MetricErrors     = "xorm.errors"
+	MetricDuration   = "xorm.statement.duration"
+)
+
+// Attribute represents a key value pair of a span or a metric
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Span represents a span of a statement
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Tracer starts the spans
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Counter represents a monotonic counter
+type Counter interface {
+	Add(ctx context.Context, n int64, attrs ...Attribute)
+}
+
+// Histogram represents a distribution of the values
+type Histogram interface {
+	Record(ctx context.Context, value float64, attrs ...Attribute)
+}
+
+// Meter creates the instruments of the metrics
+type Meter interface {
+	Counter(name string) Counter
+	Histogram(name string) Histogram
+}
+
+type spanKey struct{}
+
+// Hook traces and measures the statements, it implements contexts.Hook
+type Hook struct {
+	dbType     schemas.DBType
+	tracer     Tracer
+	statements Counter
+	errors     Counter
+	duration   Histogram
+}
+
+var _ contexts.Hook = &Hook{}
+
+// NewHook creates a hook of the database type, the tracer or the meter could
+// be nil to disable the spans or the metrics
+func NewHook(dbType schemas.DBType, tracer Tracer, meter Meter) *Hook {
+	h := &Hook{
+		dbType: dbType,
+		tracer: tracer,
+	}
+	if meter != nil {
+		h.statements = meter.Counter(MetricStatements)
+		h.errors = meter.Counter(MetricErrors)
+		h.duration = meter.Histogram(MetricDuration)
+	}
+	return h
+}
+
+// attributes returns the attributes of the operation and the table
+func (h *Hook) attributes(operation, table string) []Attribute {
+	attrs := []Attribute{
+		{Key: AttrDBSystem, Value: string(h.dbType)},
+		{Key: AttrDBOperation, Value: operation},
+	}
+	if table != "" {
+		attrs = append(attrs, Attribute{Key: AttrDBTable, Value: table})
+	}
+	return attrs
+}
+
+// statement returns the operation and the table of the statement, the table
+// of the session is preferred to the one parsed from the SQL
+func statement(c *contexts.ContextHook) (string, string) {
+	operation, table := parseStatement(c.SQL)
+	if c.Table != nil && c.Table.Name != "" {
+		table = c.Table.Name
+	}
+	return operation, table
+}
+
+// BeforeProcess implements contexts.Hook
+func (h *Hook) BeforeProcess(c *contexts.ContextHook) (context.Context, error) {
+	if h.tracer == nil {
+		return c.Ctx, nil
+	}
+
+	operation, table := statement(c)
+	name := operation
+	if table != "" {
+		name += " " + table
+	}
+	attrs := append(h.attributes(operation, table), Attribute{Key: AttrDBStatement, Value: contexts.NormalizeSQL(c.SQL)})
+	ctx, span := h.tracer.Start(c.Ctx, name, attrs...)
+	return context.WithValue(ctx, spanKey{}, span), nil
+}
+
+// AfterProcess implements contexts.Hook
+func (h *Hook) AfterProcess(c *contexts.ContextHook) error {
+	if c.Ctx != nil {
+		if span, ok := c.Ctx.Value(spanKey{}).(Span); ok {
+			if c.Result != nil {
+				if affected, err := c.Result.RowsAffected(); err == nil {
+					span.SetAttributes(Attribute{Key: AttrRowsAffected, Value: affected})
+				}
+			}
+			if c.Err != nil {
+				span.RecordError(c.Err)
+			}
+			span.End()
+		}
+	}
+
+	if h.statements == nil {
+		return nil
+	}
+	ctx := c.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	operation, table := statement(c)
+	attrs := append(h.attributes(operation, table), Attribute{Key: AttrError, Value: c.Err != nil})
+	h.statements.Add(ctx, 1, attrs...)
+	if c.Err != nil {
+		h.errors.Add(ctx, 1, attrs...)
+	}
+	h.duration.Record(ctx, float64(c.ExecuteTime)/float64(time.Second), attrs...)
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package observability
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"xorm.io/xorm/contexts"
+	"xorm.io/xorm/schemas"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testResult int64
+
+func (r testResult) LastInsertId() (int64, error) { return 0, nil }
+func (r testResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+func runHook(t *testing.T, hook contexts.Hook, sql string, args []interface{}, result testResult, err error) {
+	c := contexts.NewContextHook(context.Background(), sql, args)
+	ctx, beforeErr := hook.BeforeProcess(c)
+	assert.NoError(t, beforeErr)
+	if result > 0 {
+		c.End(ctx, result, err)
+	} else {
+		c.End(ctx, nil, err)
+	}
+	c.ExecuteTime = 10 * time.Millisecond
+	assert.NoError(t, hook.AfterProcess(c))
+}
+
+func TestHook(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	hook := NewHook(schemas.MYSQL, exporter, exporter)
+
+	runHook(t, hook, "SELECT `id`, `name` FROM `user` WHERE `id` IN (?,?)", []interface{}{1, 2}, 0, nil)
+	runHook(t, hook, "UPDATE `user` SET `name`=? WHERE `id`=?", []interface{}{"a", 1}, 1, nil)
+	runHook(t, hook, "INSERT INTO `user` (`name`) VALUES (?)", []interface{}{"b"}, 0, errors.New("duplicated"))
+
+	spans := exporter.Spans()
+	assert.EqualValues(t, 3, len(spans))
+	assert.EqualValues(t, "SELECT user", spans[0].Name)
+	assert.EqualValues(t, "mysql", spans[0].Attributes[AttrDBSystem])
+	assert.EqualValues(t, "user", spans[0].Attributes[AttrDBTable])
+	assert.EqualValues(t, "SELECT `id`, `name` FROM `user` WHERE `id` IN (?)", spans[0].Attributes[AttrDBStatement])
+	assert.True(t, spans[0].Ended)
+	assert.Nil(t, spans[0].Err)
+	assert.EqualValues(t, 1, spans[1].Attributes[AttrRowsAffected])
+	assert.EqualValues(t, "UPDATE", spans[1].Attributes[AttrDBOperation])
+	assert.EqualError(t, spans[2].Err, "duplicated")
+
+	assert.EqualValues(t, 3, exporter.Sum(MetricStatements))
+	assert.EqualValues(t, 1, exporter.Sum(MetricStatements, Attribute{Key: AttrDBOperation, Value: "SELECT"}))
+	assert.EqualValues(t, 1, exporter.Sum(MetricErrors))
+	assert.EqualValues(t, 1, exporter.Sum(MetricErrors, Attribute{Key: AttrDBOperation, Value: "INSERT"}))
+	durations := exporter.Values(MetricDuration)
+	assert.EqualValues(t, 3, len(durations))
+	assert.EqualValues(t, 0.01, durations[0].Value)
+
+	exporter.Reset()
+	assert.Empty(t, exporter.Spans())
+
+	// only the metrics
+	hook = NewHook(schemas.SQLITE, nil, exporter)
+	runHook(t, hook, "DELETE FROM `user`", nil, 2, nil)
+	assert.Empty(t, exporter.Spans())
+	assert.EqualValues(t, 1, exporter.Sum(MetricStatements, Attribute{Key: AttrDBTable, Value: "user"}))
+}
+
+func TestParseStatement(t *testing.T) {
+	kases := []struct {
+		sql       string
+		operation string
+		table     string
+	}{
+		{"SELECT * FROM `user` WHERE id=?", "SELECT", "user"},
+		{"select count(*) from \"public\".\"user\"", "SELECT", "public.user"},
+		{"SELECT * FROM (SELECT 1) t", "SELECT", ""},
+		{"INSERT INTO [user] ([name]) VALUES (?)", "INSERT", "user"},
+		{"UPDATE `user` SET `name`=?", "UPDATE", "user"},
+		{"DELETE FROM user", "DELETE", "user"},
+		{"CREATE TABLE IF NOT EXISTS `user` (`id` INTEGER)", "CREATE", "user"},
+		{"BEGIN TRANSACTION", "BEGIN", ""},
+		{"", "", ""},
+	}
+	for _, kase := range kases {
+		operation, table := parseStatement(kase.sql)
+		assert.EqualValues(t, kase.operation, operation, kase.sql)
+		assert.EqualValues(t, kase.table, table, kase.sql)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package observability
+
+import (
+	"strings"
+)
+
+// parseStatement returns the operation and the table of the SQL, the table
+// is the one after FROM, INTO, UPDATE or TABLE
+func parseStatement(sqlStr string) (string, string) {
+	fields := strings.Fields(sqlStr)
+	if len(fields) == 0 {
+		return "", ""
+	}
+
+	operation := strings.ToUpper(fields[0])
+	var tableKeyword string
+	switch operation {
+	case "SELECT", "DELETE":
+		tableKeyword = "FROM"
+	case "INSERT", "REPLACE", "MERGE":
+		tableKeyword = "INTO"
+	case "UPDATE":
+		if len(fields) > 1 {
+			return operation, unquoteTable(fields[1])
+		}
+		return operation, ""
+	case "CREATE", "DROP", "ALTER", "TRUNCATE":
+		tableKeyword = "TABLE"
+	default:
+		return operation, ""
+	}
+
+	for i := 1; i < len(fields)-1; i++ {
+		if !strings.EqualFold(fields[i], tableKeyword) {
+			continue
+		}
+		for _, next := range fields[i+1:] {
+			switch strings.ToUpper(next) {
+			case "IF", "NOT", "EXISTS", "ONLY":
+				continue
+			}
+			// a subquery
+			if strings.HasPrefix(next, "(") {
+				return operation, ""
+			}
+			return operation, unquoteTable(next)
+		}
+	}
+	return operation, ""
+}
+
+// unquoteTable removes the quotes and the parentheses of the table name
+func unquoteTable(name string) string {
+	if i := strings.IndexAny(name, "(,;"); i >= 0 {
+		name = name[:i]
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '`', '"', '[', ']':
+			return -1
+		}
+		return r
+	}, name)
+}
@@ -148,7 +148,7 @@ func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{
 	if err != nil {
 		return nil, err
 	}
-	rows, err := db.DB.QueryContext(ctx, query, args...)
+	rows, err := queryHook(ctx, hookCtx, db.DB.QueryContext)
 	hookCtx.End(ctx, nil, err)
 	if err := db.afterProcess(hookCtx); err != nil {
 		if rows != nil {
@@ -270,7 +270,7 @@ func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}
 	if err != nil {
 		return nil, err
 	}
-	res, err := db.DB.ExecContext(ctx, query, args...)
+	res, err := execHook(ctx, hookCtx, db.DB.ExecContext)
 	hookCtx.End(ctx, res, err)
 	if err := db.afterProcess(hookCtx); err != nil {
 		return nil, err
@@ -13,6 +13,10 @@ import (
 	"xorm.io/xorm/contexts"
 )
 
+// errStmtRewritten is returned when a hook rewrites the SQL of a prepared
+// statement, only the arguments could be rewritten
+var errStmtRewritten = errors.New("the SQL of a prepared statement can't be rewritten by the hooks")
+
 // Stmt reprents a stmt objects
 type Stmt struct {
 	*sql.Stmt
@@ -93,7 +97,12 @@ func (s *Stmt) ExecContext(ctx context.Context, args ...interface{}) (sql.Result
 	if err != nil {
 		return nil, err
 	}
-	res, err := s.Stmt.ExecContext(ctx, args...)
+	res, err := execHook(ctx, hookCtx, func(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+		if query != s.query {
+			return nil, errStmtRewritten
+		}
+		return s.Stmt.ExecContext(ctx, args...)
+	})
 	hookCtx.End(ctx, res, err)
 	if err := s.db.afterProcess(hookCtx); err != nil {
 		return nil, err
@@ -108,7 +117,12 @@ func (s *Stmt) QueryContext(ctx context.Context, args ...interface{}) (*Rows, er
 	if err != nil {
 		return nil, err
 	}
-	rows, err := s.Stmt.QueryContext(ctx, args...)
+	rows, err := queryHook(ctx, hookCtx, func(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+		if query != s.query {
+			return nil, errStmtRewritten
+		}
+		return s.Stmt.QueryContext(ctx, args...)
+	})
 	hookCtx.End(ctx, nil, err)
 	if err := s.db.afterProcess(hookCtx); err != nil {
 		return nil, err
@@ -0,0 +1,133 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+
+	"xorm.io/xorm/contexts"
+)
+
+// the rows of the short circuited queries are returned by an in process
+// driver since sql.Rows can only be created by a driver
+var (
+	syntheticDB     *sql.DB
+	syntheticDBOnce sync.Once
+)
+
+var errSyntheticUnsupported = errors.New("the synthetic driver only returns the rows")
+
+type syntheticDriver struct{}
+
+func (syntheticDriver) Open(string) (driver.Conn, error) {
+	return syntheticConn{}, nil
+}
+
+type syntheticConnector struct{}
+
+func (syntheticConnector) Connect(context.Context) (driver.Conn, error) {
+	return syntheticConn{}, nil
+}
+
+func (syntheticConnector) Driver() driver.Driver {
+	return syntheticDriver{}
+}
+
+type syntheticConn struct{}
+
+func (syntheticConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errSyntheticUnsupported
+}
+
+func (syntheticConn) Close() error {
+	return nil
+}
+
+func (syntheticConn) Begin() (driver.Tx, error) {
+	return nil, errSyntheticUnsupported
+}
+
+// CheckNamedValue accepts the rows as the argument
+func (syntheticConn) CheckNamedValue(*driver.NamedValue) error {
+	return nil
+}
+
+func (syntheticConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if len(args) != 1 {
+		return nil, errSyntheticUnsupported
+	}
+	rows, ok := args[0].Value.(*contexts.SyntheticRows)
+	if !ok {
+		return nil, errSyntheticUnsupported
+	}
+	return &syntheticRows{rows: rows}, nil
+}
+
+type syntheticRows struct {
+	rows *contexts.SyntheticRows
+	idx  int
+}
+
+func (r *syntheticRows) Columns() []string {
+	return r.rows.Columns
+}
+
+func (r *syntheticRows) Close() error {
+	return nil
+}
+
+func (r *syntheticRows) Next(dest []driver.Value) error {
+	if r.idx >= len(r.rows.Rows) {
+		return io.EOF
+	}
+	row := r.rows.Rows[r.idx]
+	r.idx++
+	for i := range dest {
+		if i >= len(row) {
+			dest[i] = nil
+			continue
+		}
+		v, err := driver.DefaultParameterConverter.ConvertValue(row[i])
+		if err != nil {
+			return err
+		}
+		dest[i] = v
+	}
+	return nil
+}
+
+// querySynthetic returns the rows of the short circuited hook context
+func querySynthetic(ctx context.Context, c *contexts.ContextHook) (*sql.Rows, error) {
+	syntheticDBOnce.Do(func() {
+		syntheticDB = sql.OpenDB(syntheticConnector{})
+	})
+	return syntheticDB.QueryContext(ctx, "", c.SyntheticRows())
+}
+
+// queryHook runs the query of the hook context which could be rewritten by
+// the hooks, or returns the synthetic rows if it's short circuited
+func queryHook(ctx context.Context, c *contexts.ContextHook, query func(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)) (*sql.Rows, error) {
+	if c.ShortCircuited() {
+		return querySynthetic(ctx, c)
+	}
+	return query(ctx, c.SQL, c.Args...)
+}
+
+// execHook executes the SQL of the hook context which could be rewritten by
+// the hooks, or returns the synthetic result if it's short circuited
+func execHook(ctx context.Context, c *contexts.ContextHook, exec func(ctx context.Context, query string, args ...interface{}) (sql.Result, error)) (sql.Result, error) {
+	if c.ShortCircuited() {
+		if c.Result != nil {
+			return c.Result, nil
+		}
+		return contexts.SyntheticResult{}, nil
+	}
+	return exec(ctx, c.SQL, c.Args...)
+}
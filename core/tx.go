@@ -134,7 +134,7 @@ func (tx *Tx) ExecContext(ctx context.Context, query string, args ...interface{}
 	if err != nil {
 		return nil, err
 	}
-	res, err := tx.Tx.ExecContext(ctx, query, args...)
+	res, err := execHook(ctx, hookCtx, tx.Tx.ExecContext)
 	hookCtx.End(ctx, res, err)
 	if err := tx.db.afterProcess(hookCtx); err != nil {
 		return nil, err
@@ -154,7 +154,7 @@ func (tx *Tx) QueryContext(ctx context.Context, query string, args ...interface{
 	if err != nil {
 		return nil, err
 	}
-	rows, err := tx.Tx.QueryContext(ctx, query, args...)
+	rows, err := queryHook(ctx, hookCtx, tx.Tx.QueryContext)
 	hookCtx.End(ctx, nil, err)
 	if err := tx.db.afterProcess(hookCtx); err != nil {
 		if rows != nil {
@@ -6,6 +6,7 @@ package dialects
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -46,7 +47,10 @@ const (
 
 // DialectFeatures represents a dialect parameters
 type DialectFeatures struct {
-	AutoincrMode int // 0 autoincrement column, 1 sequence
+	AutoincrMode     int  // 0 autoincrement column, 1 sequence
+	MaxBindParams    int  // the max number of parameters of one statement, 0 means no limit
+	MaxInListSize    int  // the max number of expressions of an IN list, 0 means no limit
+	TransactionalDDL bool // DDL could be rolled back within a transaction
 }
 
 // Dialect represents a kind of database
@@ -71,6 +75,10 @@ type Dialect interface {
 	CreateIndexSQL(tableName string, index *schemas.Index) string
 	DropIndexSQL(tableName string, index *schemas.Index) string
 
+	GetForeignKeys(queryer core.Queryer, ctx context.Context, tableName string) (map[string]*schemas.ForeignKey, error)
+	CreateForeignKeySQL(tableName string, fk *schemas.ForeignKey) string // empty if it's declared within CREATE TABLE only
+	DropForeignKeySQL(tableName string, fk *schemas.ForeignKey) string
+
 	GetTables(queryer core.Queryer, ctx context.Context) ([]*schemas.Table, error)
 	IsTableExist(queryer core.Queryer, ctx context.Context, tableName string) (bool, error)
 	CreateTableSQL(ctx context.Context, queryer core.Queryer, table *schemas.Table, tableName string) (string, bool, error)
@@ -80,15 +88,117 @@ type Dialect interface {
 	IsSequenceExist(ctx context.Context, queryer core.Queryer, seqName string) (bool, error)
 	DropSequenceSQL(seqName string) (string, error)
 
+	SavepointSQL(name string) (string, error)
+	ReleaseSavepointSQL(name string) (string, error)
+	RollbackToSavepointSQL(name string) (string, error)
+	IsRetryableError(err error) bool
+
 	GetColumns(queryer core.Queryer, ctx context.Context, tableName string) ([]string, map[string]*schemas.Column, error)
 	IsColumnExist(queryer core.Queryer, ctx context.Context, tableName string, colName string) (bool, error)
 	AddColumnSQL(tableName string, col *schemas.Column) string
 	ModifyColumnSQL(tableName string, col *schemas.Column) string
+	AlterColumnSQL(tableName string, col *schemas.Column, changes ColumnChange) []string // nil if any of the changes is not supported
+	DropColumnSQL(tableName, colName string) string
 
 	Filters() []Filter
 	SetParams(params map[string]string)
 }
 
+// ColumnChange represents the changes of a column to be altered
+type ColumnChange int
+
+// enumerate all the changes of a column
+const (
+	ColumnTypeChanged ColumnChange = 1 << iota
+	ColumnNullableChanged
+	ColumnDefaultChanged
+)
+
+// ColumnChanges returns the changes of the column of the struct from the one
+// of the database
+func ColumnChanges(dialect Dialect, col, oriCol *schemas.Column) ColumnChange {
+	var (
+		changes      ColumnChange
+		expectedType = dialect.SQLType(col)
+		curType      = dialect.SQLType(oriCol)
+	)
+	if expectedType != curType {
+		switch {
+		case strings.HasPrefix(curType, schemas.Varchar) && strings.HasPrefix(expectedType, schemas.Varchar),
+			expectedType == schemas.Text && strings.HasPrefix(curType, schemas.Varchar):
+			changes |= ColumnTypeChanged
+		case strings.HasPrefix(curType, expectedType) && curType[len(expectedType)] == '(':
+		case !strings.EqualFold(schemas.SQLTypeName(curType), dialect.Alias(schemas.SQLTypeName(expectedType))):
+			changes |= ColumnTypeChanged
+		}
+	} else if expectedType == schemas.Varchar && col.Length != oriCol.Length {
+		changes |= ColumnTypeChanged
+	}
+
+	def, quoted := normalizeDefault(col.Default)
+	oriDef, oriQuoted := normalizeDefault(oriCol.Default)
+	if def != oriDef && (quoted && oriQuoted || !strings.EqualFold(def, oriDef)) {
+		switch {
+		case col.IsAutoIncrement: // For autoincrement column, don't check default
+		case (col.SQLType.Name == schemas.Bool || col.SQLType.Name == schemas.Boolean) &&
+			((strings.EqualFold(def, "true") && oriDef == "1") ||
+				(strings.EqualFold(def, "false") && oriDef == "0")):
+		default:
+			changes |= ColumnDefaultChanged
+		}
+	}
+	if col.Nullable != oriCol.Nullable {
+		changes |= ColumnNullableChanged
+	}
+	return changes
+}
+
+// UnalterableColumnChanges returns the changes of a column which could not be
+// altered by AlterColumnSQL of the dialect, the default of MSSQL is a named
+// constraint of its own
+func UnalterableColumnChanges(dialect Dialect) ColumnChange {
+	if dialect.URI().DBType == schemas.MSSQL {
+		return ColumnDefaultChanged
+	}
+	return 0
+}
+
+// normalizeDefault returns the default of a column without the formatting of
+// the databases, i.e. the wrapping parentheses of MSSQL and the quotes which
+// MySQL drops, so that the defaults of the structs and the databases could be
+// compared. The unquoted defaults are compared case insensitively.
+func normalizeDefault(def string) (string, bool) {
+	def = strings.TrimSpace(def)
+	for len(def) >= 2 && def[0] == '(' && closingParen(def) == len(def)-1 {
+		def = strings.TrimSpace(def[1 : len(def)-1])
+	}
+	if len(def) >= 2 && def[0] == '\'' && def[len(def)-1] == '\'' {
+		return strings.ReplaceAll(def[1:len(def)-1], "''", "'"), true
+	}
+	if strings.EqualFold(def, "NULL") {
+		return "", false
+	}
+	return def, false
+}
+
+// closingParen returns the index of the parenthesis closing the one at the
+// start of s, -1 if it's not closed
+func closingParen(s string) int {
+	var depth int
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
 // Base represents a basic dialect and all real dialects could embed this struct
 type Base struct {
 	dialect Dialect
@@ -170,6 +280,28 @@ func (db *Base) DropSequenceSQL(seqName string) (string, error) {
 	return fmt.Sprintf("DROP SEQUENCE %s", seqName), nil
 }
 
+// SavepointSQL returns a SQL to create a savepoint in the current transaction
+func (db *Base) SavepointSQL(name string) (string, error) {
+	return "SAVEPOINT " + name, nil
+}
+
+// ReleaseSavepointSQL returns a SQL to release a savepoint, an empty SQL
+// means the database releases savepoints on commit only
+func (db *Base) ReleaseSavepointSQL(name string) (string, error) {
+	return "RELEASE SAVEPOINT " + name, nil
+}
+
+// RollbackToSavepointSQL returns a SQL to rollback to a savepoint
+func (db *Base) RollbackToSavepointSQL(name string) (string, error) {
+	return "ROLLBACK TO SAVEPOINT " + name, nil
+}
+
+// IsRetryableError returns true if the transaction failed because of a
+// serialization failure or a deadlock and could succeed if it is retried
+func (db *Base) IsRetryableError(err error) bool {
+	return false
+}
+
 // DropTableSQL returns drop table SQL
 func (db *Base) DropTableSQL(tableName string) (string, bool) {
 	quote := db.dialect.Quoter().Quote
@@ -237,16 +369,147 @@ func (db *Base) DropIndexSQL(tableName string, index *schemas.Index) string {
 	return fmt.Sprintf("DROP INDEX %v ON %s", quote(name), quote(tableName))
 }
 
+// GetForeignKeys returns no foreign keys by default
+func (db *Base) GetForeignKeys(queryer core.Queryer, ctx context.Context, tableName string) (map[string]*schemas.ForeignKey, error) {
+	return make(map[string]*schemas.ForeignKey), nil
+}
+
+// CreateForeignKeySQL returns a SQL to add a foreign key constraint
+func (db *Base) CreateForeignKeySQL(tableName string, fk *schemas.ForeignKey) string {
+	quoter := db.dialect.Quoter()
+	var b strings.Builder
+	fmt.Fprintf(&b, "ALTER TABLE %s ADD ", quoter.Quote(tableName))
+	writeForeignKey(&b, quoter, tableName, fk, true)
+	return b.String()
+}
+
+// writeForeignKey writes the constraint definition of the foreign key
+func writeForeignKey(b *strings.Builder, quoter schemas.Quoter, tableName string, fk *schemas.ForeignKey, withOnUpdate bool) {
+	fmt.Fprintf(b, "CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+		quoter.Quote(fk.XName(tableName)), quoter.Join(fk.Cols, ","),
+		quoter.Quote(fk.RefTable), quoter.Join(fk.RefCols, ","))
+	if fk.OnDelete != "" {
+		b.WriteString(" ON DELETE " + fk.OnDelete)
+	}
+	if fk.OnUpdate != "" && withOnUpdate {
+		b.WriteString(" ON UPDATE " + fk.OnUpdate)
+	}
+}
+
+// scanForeignKeys scans the rows of the constraint name, the column, the
+// referenced table, the referenced column, the delete rule and the update rule
+// into foreign keys
+func scanForeignKeys(rows *core.Rows, tableName string) (map[string]*schemas.ForeignKey, error) {
+	fks := make(map[string]*schemas.ForeignKey)
+	for rows.Next() {
+		var name, col, refTable, refCol, onDelete, onUpdate string
+		if err := rows.Scan(&name, &col, &refTable, &refCol, &onDelete, &onUpdate); err != nil {
+			return nil, err
+		}
+		addForeignKeyColumn(fks, tableName, name, col, refTable, refCol, onDelete, onUpdate)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return fks, nil
+}
+
+// addForeignKeyColumn adds a column of a foreign key read from the database
+func addForeignKeyColumn(fks map[string]*schemas.ForeignKey, tableName, name, col, refTable, refCol, onDelete, onUpdate string) {
+	if strings.HasPrefix(name, "FK_"+tableName+"_") {
+		name = name[4+len(tableName):]
+	}
+	fk, ok := fks[name]
+	if !ok {
+		fk = schemas.NewForeignKey(name, nil, refTable, nil)
+		fk.OnDelete = foreignKeyAction(onDelete)
+		fk.OnUpdate = foreignKeyAction(onUpdate)
+		fks[name] = fk
+	}
+	fk.Cols = append(fk.Cols, col)
+	fk.RefCols = append(fk.RefCols, refCol)
+}
+
+// foreignKeyAction returns the referential action reported by the database,
+// which is empty if it's the default one
+func foreignKeyAction(action string) string {
+	action, err := schemas.ReferentialAction(action)
+	if err != nil || action == schemas.NoAction {
+		return ""
+	}
+	return action
+}
+
+// DropForeignKeySQL returns a SQL to drop a foreign key constraint
+func (db *Base) DropForeignKeySQL(tableName string, fk *schemas.ForeignKey) string {
+	quote := db.dialect.Quoter().Quote
+	return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", quote(tableName), quote(fk.XName(tableName)))
+}
+
 // ModifyColumnSQL returns a SQL to modify SQL
 func (db *Base) ModifyColumnSQL(tableName string, col *schemas.Column) string {
 	s, _ := ColumnString(db.dialect, col, false, false)
 	return fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s", db.quoter.Quote(tableName), s)
 }
 
+// AlterColumnSQL returns the SQLs to change the type, the nullability and the
+// default of a column, the whole column is redefined by default
+func (db *Base) AlterColumnSQL(tableName string, col *schemas.Column, changes ColumnChange) []string {
+	return []string{db.dialect.ModifyColumnSQL(tableName, col)}
+}
+
+// DropColumnSQL returns a SQL to drop a column
+func (db *Base) DropColumnSQL(tableName, colName string) string {
+	quote := db.dialect.Quoter().Quote
+	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", quote(tableName), quote(colName))
+}
+
+// modifyColumnSQL returns a SQL of Oracle style to modify the changed parts of
+// a column only, since it's an error to set a column NOT NULL again
+func modifyColumnSQL(dialect Dialect, tableName string, col *schemas.Column, changes ColumnChange) string {
+	var b strings.Builder
+	quoter := dialect.Quoter()
+	b.WriteString("ALTER TABLE ")
+	_ = quoter.QuoteTo(&b, tableName)
+	b.WriteString(" MODIFY ")
+	_ = quoter.QuoteTo(&b, col.Name)
+	if changes&ColumnTypeChanged != 0 {
+		b.WriteString(" ")
+		b.WriteString(dialect.SQLType(col))
+	}
+	if changes&ColumnDefaultChanged != 0 {
+		b.WriteString(" DEFAULT ")
+		b.WriteString(columnDefault(col))
+	}
+	if changes&ColumnNullableChanged != 0 {
+		if col.Nullable {
+			b.WriteString(" NULL")
+		} else {
+			b.WriteString(" NOT NULL")
+		}
+	}
+	return b.String()
+}
+
+// columnDefault returns the default value of the column in SQL, NULL if the
+// column has no default
+func columnDefault(col *schemas.Column) string {
+	if col.DefaultIsEmpty {
+		return "NULL"
+	}
+	if col.Default == "" {
+		return "''"
+	}
+	return col.Default
+}
+
 // SetParams set params
 func (db *Base) SetParams(params map[string]string) {
 }
 
+// ErrSavepointNotSupported will be returned when the database has no savepoints
+var ErrSavepointNotSupported = errors.New("savepoint is not supported")
+
 var dialects = map[string]func() Dialect{}
 
 // RegisterDialect register database dialect
@@ -0,0 +1,68 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dialects
+
+import (
+	"errors"
+	"reflect"
+)
+
+// errorCodes walks the error chain and collects the vendor codes the drivers
+// attach to their errors, so that dialects can classify errors without
+// importing the drivers. A number is read from the SQLErrorNumber or Code
+// methods or from a Number or Code field, a SQL state from the SQLState method
+// or from a string Code field.
+func errorCodes(err error) (numbers []int64, states []string) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		switch e := err.(type) {
+		case interface{ SQLState() string }:
+			states = append(states, e.SQLState())
+		case interface{ SQLErrorNumber() int32 }:
+			numbers = append(numbers, int64(e.SQLErrorNumber()))
+		case interface{ Code() int }:
+			numbers = append(numbers, int64(e.Code()))
+		}
+
+		v := reflect.Indirect(reflect.ValueOf(err))
+		if v.Kind() != reflect.Struct {
+			continue
+		}
+		for _, name := range []string{"Number", "Code"} {
+			field := v.FieldByName(name)
+			if !field.IsValid() {
+				continue
+			}
+			switch field.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				numbers = append(numbers, field.Int())
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				numbers = append(numbers, int64(field.Uint()))
+			case reflect.String:
+				states = append(states, field.String())
+			}
+		}
+	}
+	return
+}
+
+// hasErrorCode returns true if the error carries one of the numbers or states
+func hasErrorCode(err error, numbers []int64, states []string) bool {
+	errNumbers, errStates := errorCodes(err)
+	for _, n := range errNumbers {
+		for _, number := range numbers {
+			if n == number {
+				return true
+			}
+		}
+	}
+	for _, s := range errStates {
+		for _, state := range states {
+			if s == state {
+				return true
+			}
+		}
+	}
+	return false
+}
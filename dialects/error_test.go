@@ -0,0 +1,65 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dialects
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"xorm.io/xorm/schemas"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type numberError struct {
+	Number uint16
+}
+
+func (e *numberError) Error() string {
+	return fmt.Sprintf("Error %d", e.Number)
+}
+
+type stateError struct {
+	Code string
+}
+
+func (e stateError) Error() string {
+	return "ERROR: " + e.Code
+}
+
+type sqliteError struct {
+	Code int
+}
+
+func (e sqliteError) Error() string {
+	return fmt.Sprintf("sqlite error %d", e.Code)
+}
+
+func TestIsRetryableError(t *testing.T) {
+	kases := []struct {
+		dbType    schemas.DBType
+		err       error
+		retryable bool
+	}{
+		{"mysql", &numberError{1213}, true},
+		{"mysql", fmt.Errorf("insert: %w", &numberError{1205}), true},
+		{"mysql", &numberError{1062}, false},
+		{"postgres", stateError{"40001"}, true},
+		{"postgres", stateError{"40P01"}, true},
+		{"postgres", stateError{"23505"}, false},
+		{"sqlite3", sqliteError{5}, true},
+		{"sqlite3", sqliteError{517}, true},
+		{"sqlite3", sqliteError{19}, false},
+		{"mssql", &numberError{1205}, true},
+		{"mssql", errors.New("deadlock"), false},
+		{"oracle", &numberError{1213}, false},
+	}
+
+	for _, kase := range kases {
+		dialect := QueryDialect(kase.dbType)
+		assert.EqualValues(t, kase.retryable, dialect.IsRetryableError(kase.err), "%s %v", kase.dbType, kase.err)
+	}
+}
@@ -16,6 +16,20 @@ type Filter interface {
 	Do(ctx context.Context, sql string) string
 }
 
+type keepPlaceholdersKey struct{}
+
+// KeepPlaceholders returns a context in which the filters keep the question
+// marks of the SQLs, e.g. for the SQL scripts which have no arguments
+func KeepPlaceholders(ctx context.Context) context.Context {
+	return context.WithValue(ctx, keepPlaceholdersKey{}, true)
+}
+
+// keepsPlaceholders returns true if the question marks should not be converted
+func keepsPlaceholders(ctx context.Context) bool {
+	keep, _ := ctx.Value(keepPlaceholdersKey{}).(bool)
+	return keep
+}
+
 // postgresSeqFilter filter SQL replace ?, ? ... to $1, $2 ...
 type postgresSeqFilter struct {
 	Prefix string
@@ -79,6 +93,9 @@ func postgresSeqFilterConvertQuestionMark(sql, prefix string, start int) string
 
 // Do implements Filter
 func (s *postgresSeqFilter) Do(ctx context.Context, sql string) string {
+	if keepsPlaceholders(ctx) {
+		return sql
+	}
 	return postgresSeqFilterConvertQuestionMark(sql, s.Prefix, s.Start)
 }
 
@@ -141,5 +158,8 @@ func oracleSeqFilterConvertQuestionMark(sql, prefix string, start int) string {
 
 // Do implements Filter
 func (s *oracleSeqFilter) Do(ctx context.Context, sql string) string {
+	if keepsPlaceholders(ctx) {
+		return sql
+	}
 	return oracleSeqFilterConvertQuestionMark(sql, s.Prefix, s.Start)
 }
@@ -1,6 +1,7 @@
 package dialects
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -87,3 +88,12 @@ func TestSeqFilterPostgresQuestions(t *testing.T) {
 		assert.EqualValues(t, result, postgresSeqFilterConvertQuestionMark(sql, "$", 1))
 	}
 }
+
+func TestSeqFilterKeepPlaceholders(t *testing.T) {
+	ctx := KeepPlaceholders(context.Background())
+	sql := "SELECT * FROM table1 WHERE a = ? AND b = ?"
+	assert.EqualValues(t, sql, (&postgresSeqFilter{Prefix: "$", Start: 1}).Do(ctx, sql))
+	assert.EqualValues(t, sql, (&oracleSeqFilter{Prefix: ":", Start: 1}).Do(ctx, sql))
+	assert.EqualValues(t, "SELECT * FROM table1 WHERE a = $1 AND b = $2",
+		(&postgresSeqFilter{Prefix: "$", Start: 1}).Do(context.Background(), sql))
+}
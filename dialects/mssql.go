@@ -294,7 +294,9 @@ func (db *mssql) Version(ctx context.Context, queryer core.Queryer) (*schemas.Ve
 
 func (db *mssql) Features() *DialectFeatures {
 	return &DialectFeatures{
-		AutoincrMode: IncrAutoincrMode,
+		AutoincrMode:     IncrAutoincrMode,
+		MaxBindParams:    2098, // sp_executesql takes 2 of the 2100 parameters
+		TransactionalDDL: true,
 	}
 }
 
@@ -427,6 +429,26 @@ func (db *mssql) AutoIncrStr() string {
 	return "IDENTITY"
 }
 
+// IsRetryableError returns true if the transaction was chosen as a deadlock victim
+func (db *mssql) IsRetryableError(err error) bool {
+	return hasErrorCode(err, []int64{1205}, nil)
+}
+
+// SavepointSQL returns a SQL to create a savepoint in the current transaction
+func (db *mssql) SavepointSQL(name string) (string, error) {
+	return "SAVE TRANSACTION " + name, nil
+}
+
+// ReleaseSavepointSQL returns an empty SQL, MSSQL cannot release savepoints
+func (db *mssql) ReleaseSavepointSQL(name string) (string, error) {
+	return "", nil
+}
+
+// RollbackToSavepointSQL returns a SQL to rollback to a savepoint
+func (db *mssql) RollbackToSavepointSQL(name string) (string, error) {
+	return "ROLLBACK TRANSACTION " + name, nil
+}
+
 func (db *mssql) DropTableSQL(tableName string) (string, bool) {
 	return fmt.Sprintf("IF EXISTS (SELECT * FROM sysobjects WHERE id = "+
 		"object_id(N'%s') and OBJECTPROPERTY(id, N'IsUserTable') = 1) "+
@@ -438,6 +460,21 @@ func (db *mssql) ModifyColumnSQL(tableName string, col *schemas.Column) string {
 	return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s", db.quoter.Quote(tableName), s)
 }
 
+// AlterColumnSQL returns a SQL to change the type and the nullability of a
+// column, the default is not changed since it's a named constraint, see
+// UnalterableColumnChanges
+func (db *mssql) AlterColumnSQL(tableName string, col *schemas.Column, changes ColumnChange) []string {
+	if changes&(ColumnTypeChanged|ColumnNullableChanged) == 0 {
+		return nil
+	}
+	nullable := " NOT NULL"
+	if col.Nullable {
+		nullable = " NULL"
+	}
+	return []string{fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s%s",
+		db.quoter.Quote(tableName), db.quoter.Quote(col.Name), db.SQLType(col), nullable)}
+}
+
 func (db *mssql) IndexCheckSQL(tableName, idxName string) (string, []interface{}) {
 	args := []interface{}{idxName}
 	sql := "select name from sysindexes where id=object_id('" + tableName + "') and name=?"
@@ -641,6 +678,27 @@ WHERE IXS.TYPE_DESC='NONCLUSTERED' and OBJECT_NAME(IXS.OBJECT_ID) =?
 	return indexes, nil
 }
 
+// GetForeignKeys returns the foreign keys of the table
+func (db *mssql) GetForeignKeys(queryer core.Queryer, ctx context.Context, tableName string) (map[string]*schemas.ForeignKey, error) {
+	args := []interface{}{tableName}
+	s := `SELECT FK.NAME, PC.NAME, RT.NAME, RC.NAME, FK.DELETE_REFERENTIAL_ACTION_DESC, FK.UPDATE_REFERENTIAL_ACTION_DESC
+FROM SYS.FOREIGN_KEYS FK
+INNER JOIN SYS.FOREIGN_KEY_COLUMNS FKC ON FKC.CONSTRAINT_OBJECT_ID = FK.OBJECT_ID
+INNER JOIN SYS.COLUMNS PC ON PC.OBJECT_ID = FKC.PARENT_OBJECT_ID AND PC.COLUMN_ID = FKC.PARENT_COLUMN_ID
+INNER JOIN SYS.TABLES RT ON RT.OBJECT_ID = FKC.REFERENCED_OBJECT_ID
+INNER JOIN SYS.COLUMNS RC ON RC.OBJECT_ID = FKC.REFERENCED_OBJECT_ID AND RC.COLUMN_ID = FKC.REFERENCED_COLUMN_ID
+WHERE OBJECT_NAME(FK.PARENT_OBJECT_ID) = ?
+ORDER BY FK.NAME, FKC.CONSTRAINT_COLUMN_ID`
+
+	rows, err := queryer.QueryContext(ctx, s, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanForeignKeys(rows, tableName)
+}
+
 func (db *mssql) CreateTableSQL(ctx context.Context, queryer core.Queryer, table *schemas.Table, tableName string) (string, bool, error) {
 	if tableName == "" {
 		tableName = table.Name
@@ -223,8 +223,9 @@ func (db *mysql) Version(ctx context.Context, queryer core.Queryer) (*schemas.Ve
 		}, nil
 	}
 
+	// 10.11.2-MariaDB or 10.11.2-MariaDB-1:10.11.2+maria~ubu2204
 	var edition string
-	if len(fields) == 2 {
+	if len(fields) >= 2 {
 		edition = fields[1]
 	}
 
@@ -236,7 +237,8 @@ func (db *mysql) Version(ctx context.Context, queryer core.Queryer) (*schemas.Ve
 
 func (db *mysql) Features() *DialectFeatures {
 	return &DialectFeatures{
-		AutoincrMode: IncrAutoincrMode,
+		AutoincrMode:  IncrAutoincrMode,
+		MaxBindParams: 65535,
 	}
 }
 
@@ -580,6 +582,11 @@ func (db *mysql) GetTables(queryer core.Queryer, ctx context.Context) ([]*schema
 	return tables, nil
 }
 
+// IsRetryableError returns true on deadlocks and lock wait timeouts
+func (db *mysql) IsRetryableError(err error) bool {
+	return hasErrorCode(err, []int64{1205, 1213}, []string{"40001"})
+}
+
 func (db *mysql) SetQuotePolicy(quotePolicy QuotePolicy) {
 	switch quotePolicy {
 	case QuotePolicyNone:
@@ -650,6 +657,30 @@ func (db *mysql) GetIndexes(queryer core.Queryer, ctx context.Context, tableName
 	return indexes, nil
 }
 
+// GetForeignKeys returns the foreign keys of the table
+func (db *mysql) GetForeignKeys(queryer core.Queryer, ctx context.Context, tableName string) (map[string]*schemas.ForeignKey, error) {
+	args := []interface{}{db.uri.DBName, tableName}
+	s := "SELECT k.`CONSTRAINT_NAME`, k.`COLUMN_NAME`, k.`REFERENCED_TABLE_NAME`, k.`REFERENCED_COLUMN_NAME`, r.`DELETE_RULE`, r.`UPDATE_RULE`" +
+		" FROM `INFORMATION_SCHEMA`.`KEY_COLUMN_USAGE` k JOIN `INFORMATION_SCHEMA`.`REFERENTIAL_CONSTRAINTS` r" +
+		" ON r.`CONSTRAINT_SCHEMA` = k.`CONSTRAINT_SCHEMA` AND r.`TABLE_NAME` = k.`TABLE_NAME` AND r.`CONSTRAINT_NAME` = k.`CONSTRAINT_NAME`" +
+		" WHERE k.`TABLE_SCHEMA` = ? AND k.`TABLE_NAME` = ? AND k.`REFERENCED_TABLE_NAME` IS NOT NULL" +
+		" ORDER BY k.`CONSTRAINT_NAME`, k.`ORDINAL_POSITION`"
+
+	rows, err := queryer.QueryContext(ctx, s, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanForeignKeys(rows, tableName)
+}
+
+// DropForeignKeySQL returns a SQL to drop a foreign key constraint
+func (db *mysql) DropForeignKeySQL(tableName string, fk *schemas.ForeignKey) string {
+	quote := db.dialect.Quoter().Quote
+	return fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s", quote(tableName), quote(fk.XName(tableName)))
+}
+
 func (db *mysql) CreateTableSQL(ctx context.Context, queryer core.Queryer, table *schemas.Table, tableName string) (string, bool, error) {
 	if tableName == "" {
 		tableName = table.Name
@@ -553,7 +553,9 @@ func (db *oracle) Version(ctx context.Context, queryer core.Queryer) (*schemas.V
 
 func (db *oracle) Features() *DialectFeatures {
 	return &DialectFeatures{
-		AutoincrMode: SequenceAutoincrMode,
+		AutoincrMode:  SequenceAutoincrMode,
+		MaxBindParams: 65535,
+		MaxInListSize: 1000,
 	}
 }
 
@@ -620,6 +622,11 @@ func (db *oracle) IsReserved(name string) bool {
 	return ok
 }
 
+// ReleaseSavepointSQL returns an empty SQL, Oracle cannot release savepoints
+func (db *oracle) ReleaseSavepointSQL(name string) (string, error) {
+	return "", nil
+}
+
 func (db *oracle) DropTableSQL(tableName string) (string, bool) {
 	return fmt.Sprintf("DROP TABLE \"%s\"", tableName), false
 }
@@ -821,6 +828,39 @@ func (db *oracle) GetTables(queryer core.Queryer, ctx context.Context) ([]*schem
 	return tables, nil
 }
 
+// GetForeignKeys returns the foreign keys of the table
+func (db *oracle) GetForeignKeys(queryer core.Queryer, ctx context.Context, tableName string) (map[string]*schemas.ForeignKey, error) {
+	args := []interface{}{tableName}
+	s := "SELECT c.constraint_name, cc.column_name, rc.table_name, rcc.column_name, c.delete_rule, 'NO ACTION' FROM user_constraints c " +
+		"JOIN user_cons_columns cc ON cc.constraint_name = c.constraint_name " +
+		"JOIN user_constraints rc ON rc.constraint_name = c.r_constraint_name " +
+		"JOIN user_cons_columns rcc ON rcc.constraint_name = rc.constraint_name AND rcc.position = cc.position " +
+		"WHERE c.constraint_type = 'R' AND c.table_name = :1 ORDER BY c.constraint_name, cc.position"
+
+	rows, err := queryer.QueryContext(ctx, s, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanForeignKeys(rows, tableName)
+}
+
+// AlterColumnSQL returns a SQL to modify the changed parts of a column
+func (db *oracle) AlterColumnSQL(tableName string, col *schemas.Column, changes ColumnChange) []string {
+	return []string{modifyColumnSQL(db, tableName, col, changes)}
+}
+
+// CreateForeignKeySQL returns a SQL to add a foreign key constraint, which
+// has no ON UPDATE
+func (db *oracle) CreateForeignKeySQL(tableName string, fk *schemas.ForeignKey) string {
+	quoter := db.dialect.Quoter()
+	var b strings.Builder
+	fmt.Fprintf(&b, "ALTER TABLE %s ADD ", quoter.Quote(tableName))
+	writeForeignKey(&b, quoter, tableName, fk, false)
+	return b.String()
+}
+
 func (db *oracle) GetIndexes(queryer core.Queryer, ctx context.Context, tableName string) (map[string]*schemas.Index, error) {
 	args := []interface{}{tableName}
 	s := "SELECT t.column_name,i.uniqueness,i.index_name FROM user_ind_columns t,user_indexes i " +
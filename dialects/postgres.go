@@ -886,6 +886,11 @@ func (db *postgres) needQuote(name string) bool {
 	return false
 }
 
+// IsRetryableError returns true on serialization failures and deadlocks
+func (db *postgres) IsRetryableError(err error) bool {
+	return hasErrorCode(err, nil, []string{"40001", "40P01"})
+}
+
 func (db *postgres) SetQuotePolicy(quotePolicy QuotePolicy) {
 	switch quotePolicy {
 	case QuotePolicyNone:
@@ -970,7 +975,9 @@ func (db *postgres) SQLType(c *schemas.Column) string {
 
 func (db *postgres) Features() *DialectFeatures {
 	return &DialectFeatures{
-		AutoincrMode: IncrAutoincrMode,
+		AutoincrMode:     IncrAutoincrMode,
+		MaxBindParams:    65535,
+		TransactionalDDL: true,
 	}
 }
 
@@ -1051,6 +1058,37 @@ func (db *postgres) ModifyColumnSQL(tableName string, col *schemas.Column) strin
 	return modifyColumnSQL + commentSQL
 }
 
+// AlterColumnSQL returns a SQL to change the type, the nullability and the
+// default of a column
+func (db *postgres) AlterColumnSQL(tableName string, col *schemas.Column, changes ColumnChange) []string {
+	quoter := db.dialect.Quoter()
+	if len(db.getSchema()) != 0 && !strings.Contains(tableName, ".") {
+		tableName = db.getSchema() + "." + tableName
+	}
+	colName := quoter.Quote(col.Name)
+
+	actions := make([]string, 0, 3)
+	if changes&ColumnTypeChanged != 0 {
+		sqlType := db.SQLType(col)
+		actions = append(actions, fmt.Sprintf("ALTER COLUMN %s TYPE %s USING %s::%s", colName, sqlType, colName, sqlType))
+	}
+	if changes&ColumnNullableChanged != 0 {
+		if col.Nullable {
+			actions = append(actions, fmt.Sprintf("ALTER COLUMN %s DROP NOT NULL", colName))
+		} else {
+			actions = append(actions, fmt.Sprintf("ALTER COLUMN %s SET NOT NULL", colName))
+		}
+	}
+	if changes&ColumnDefaultChanged != 0 {
+		if col.DefaultIsEmpty {
+			actions = append(actions, fmt.Sprintf("ALTER COLUMN %s DROP DEFAULT", colName))
+		} else {
+			actions = append(actions, fmt.Sprintf("ALTER COLUMN %s SET DEFAULT %s", colName, columnDefault(col)))
+		}
+	}
+	return []string{fmt.Sprintf("ALTER TABLE %s %s", quoter.Quote(tableName), strings.Join(actions, ", "))}
+}
+
 func (db *postgres) DropIndexSQL(tableName string, index *schemas.Index) string {
 	idxName := index.Name
 
@@ -1356,6 +1394,31 @@ func (db *postgres) GetIndexes(queryer core.Queryer, ctx context.Context, tableN
 	return indexes, nil
 }
 
+// GetForeignKeys returns the foreign keys of the table
+func (db *postgres) GetForeignKeys(queryer core.Queryer, ctx context.Context, tableName string) (map[string]*schemas.ForeignKey, error) {
+	args := []interface{}{tableName, db.getSchema()}
+	s := `SELECT con.conname, att.attname, ref.relname, ratt.attname,
+	CASE con.confdeltype WHEN 'r' THEN 'RESTRICT' WHEN 'c' THEN 'CASCADE' WHEN 'n' THEN 'SET NULL' WHEN 'd' THEN 'SET DEFAULT' ELSE 'NO ACTION' END,
+	CASE con.confupdtype WHEN 'r' THEN 'RESTRICT' WHEN 'c' THEN 'CASCADE' WHEN 'n' THEN 'SET NULL' WHEN 'd' THEN 'SET DEFAULT' ELSE 'NO ACTION' END
+FROM pg_constraint con
+JOIN pg_class cl ON cl.oid = con.conrelid
+JOIN pg_namespace ns ON ns.oid = cl.relnamespace
+JOIN pg_class ref ON ref.oid = con.confrelid
+CROSS JOIN LATERAL unnest(con.conkey, con.confkey) WITH ORDINALITY AS k(col, refcol, pos)
+JOIN pg_attribute att ON att.attrelid = con.conrelid AND att.attnum = k.col
+JOIN pg_attribute ratt ON ratt.attrelid = con.confrelid AND ratt.attnum = k.refcol
+WHERE con.contype = 'f' AND cl.relname = $1 AND ns.nspname = $2
+ORDER BY con.conname, k.pos`
+
+	rows, err := queryer.QueryContext(ctx, s, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanForeignKeys(rows, tableName)
+}
+
 func (db *postgres) CreateTableSQL(ctx context.Context, queryer core.Queryer, table *schemas.Table, tableName string) (string, bool, error) {
 	quoter := db.dialect.Quoter()
 	if len(db.getSchema()) != 0 && !strings.Contains(tableName, ".") {
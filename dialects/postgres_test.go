@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"xorm.io/xorm/schemas"
 )
 
 func TestParsePostgres(t *testing.T) {
@@ -116,3 +117,56 @@ func TestGetIndexColName(t *testing.T) {
 
 	t.Run("Indexes on Expressions", func(t *testing.T) {})
 }
+
+func TestAlterColumnSQL(t *testing.T) {
+	col := schemas.NewColumn("name", "Name", schemas.SQLType{Name: schemas.Varchar, DefaultLength: 20}, 20, 0, false)
+	col.Default = "'none'"
+	col.DefaultIsEmpty = false
+
+	postgres, err := OpenDialect("postgres", "postgres://postgres:@localhost:5432/test?sslmode=disable")
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{`ALTER TABLE "public"."user" ALTER COLUMN "name" TYPE VARCHAR(20) USING "name"::VARCHAR(20), ` +
+		`ALTER COLUMN "name" SET NOT NULL, ALTER COLUMN "name" SET DEFAULT 'none'`},
+		postgres.AlterColumnSQL("user", col, ColumnTypeChanged|ColumnNullableChanged|ColumnDefaultChanged))
+
+	mssql, err := OpenDialect("mssql", "server=localhost;user id=sa;password=yourStrong(!)Password;database=test")
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"ALTER TABLE [user] ALTER COLUMN [name] VARCHAR(20) NOT NULL"},
+		mssql.AlterColumnSQL("user", col, ColumnNullableChanged))
+	assert.EqualValues(t, []string{"ALTER TABLE [user] ALTER COLUMN [name] VARCHAR(20) NOT NULL"},
+		mssql.AlterColumnSQL("user", col, ColumnTypeChanged|ColumnDefaultChanged))
+	assert.Nil(t, mssql.AlterColumnSQL("user", col, ColumnDefaultChanged))
+	assert.EqualValues(t, ColumnDefaultChanged, UnalterableColumnChanges(mssql))
+	assert.EqualValues(t, 0, UnalterableColumnChanges(postgres))
+
+	sqlite, err := OpenDialect("sqlite3", "./test.db")
+	assert.NoError(t, err)
+	assert.Nil(t, sqlite.AlterColumnSQL("user", col, ColumnNullableChanged))
+	assert.EqualValues(t, "ALTER TABLE `user` DROP COLUMN `name`", sqlite.DropColumnSQL("user", "name"))
+}
+
+func TestColumnChangesDefault(t *testing.T) {
+	mssql, err := OpenDialect("mssql", "server=localhost;user id=sa;password=yourStrong(!)Password;database=test")
+	assert.NoError(t, err)
+
+	kases := []struct {
+		def, oriDef string
+		changed     bool
+	}{
+		{"'none'", "('none')", false},
+		{"'none'", "none", false},
+		{"0", "((0))", false},
+		{"current_timestamp", "(getdate())", true},
+		{"CURRENT_TIMESTAMP", "current_timestamp", false},
+		{"(1)+(2)", "1)+(2", true},
+		{"'None'", "'none'", true},
+		{"", "NULL", false},
+		{"1", "2", true},
+	}
+	for _, kase := range kases {
+		col := schemas.NewColumn("name", "Name", schemas.SQLType{Name: schemas.Varchar, DefaultLength: 20}, 20, 0, true)
+		oriCol := schemas.NewColumn("name", "Name", schemas.SQLType{Name: schemas.Varchar, DefaultLength: 20}, 20, 0, true)
+		col.Default, oriCol.Default = kase.def, kase.oriDef
+		assert.EqualValues(t, kase.changed, ColumnChanges(mssql, col, oriCol)&ColumnDefaultChanged != 0, "%s %s", kase.def, kase.oriDef)
+	}
+}
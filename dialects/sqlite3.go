@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	"xorm.io/xorm/core"
@@ -186,10 +187,24 @@ func (db *sqlite3) Version(ctx context.Context, queryer core.Queryer) (*schemas.
 
 func (db *sqlite3) Features() *DialectFeatures {
 	return &DialectFeatures{
-		AutoincrMode: IncrAutoincrMode,
+		AutoincrMode:     IncrAutoincrMode,
+		MaxBindParams:    999, // SQLITE_MAX_VARIABLE_NUMBER before 3.32.0
+		TransactionalDDL: true,
 	}
 }
 
+// IsRetryableError returns true if the database file was busy or locked
+func (db *sqlite3) IsRetryableError(err error) bool {
+	numbers, _ := errorCodes(err)
+	for _, n := range numbers {
+		// the low byte of an extended result code is the primary code
+		if code := n & 0xff; code == 5 || code == 6 { // SQLITE_BUSY, SQLITE_LOCKED
+			return true
+		}
+	}
+	return false
+}
+
 func (db *sqlite3) SetQuotePolicy(quotePolicy QuotePolicy) {
 	switch quotePolicy {
 	case QuotePolicyNone:
@@ -402,14 +417,16 @@ func (db *sqlite3) GetColumns(queryer core.Queryer, ctx context.Context, tableNa
 
 	nStart := strings.Index(name, "(")
 	nEnd := strings.LastIndex(name, ")")
-	reg := regexp.MustCompile(`[^\(,\)]*(\([^\(]*\))?`)
-	colCreates := reg.FindAllString(name[nStart+1:nEnd], -1)
+	colCreates := splitColumnDefinitions(name[nStart+1 : nEnd])
 	cols := make(map[string]*schemas.Column)
 	colSeq := make([]string, 0)
 
 	for _, colStr := range colCreates {
-		reg = regexp.MustCompile(`,\s`)
+		reg := regexp.MustCompile(`,\s`)
 		colStr = reg.ReplaceAllString(colStr, ",")
+		if isTableConstraint(colStr) {
+			continue
+		}
 		if strings.HasPrefix(strings.TrimSpace(colStr), "PRIMARY KEY") {
 			parts := strings.Split(strings.TrimSpace(colStr), "(")
 			if len(parts) == 2 {
@@ -434,6 +451,48 @@ func (db *sqlite3) GetColumns(queryer core.Queryer, ctx context.Context, tableNa
 	return colSeq, cols, nil
 }
 
+// splitColumnDefinitions splits the definitions of CREATE TABLE by the commas
+// out of the parentheses and the quotes
+func splitColumnDefinitions(s string) []string {
+	var (
+		defs  []string
+		depth int
+		quote byte
+		start int
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			defs = append(defs, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(defs, s[start:])
+}
+
+// isTableConstraint returns true if the definition of CREATE TABLE is a
+// table constraint but PRIMARY KEY, e.g. the foreign keys created with it
+func isTableConstraint(def string) bool {
+	def = strings.ToUpper(strings.TrimSpace(def))
+	for _, prefix := range []string{"CONSTRAINT ", "FOREIGN KEY", "UNIQUE ", "UNIQUE(", "CHECK ", "CHECK("} {
+		if strings.HasPrefix(def, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 func (db *sqlite3) GetTables(queryer core.Queryer, ctx context.Context) ([]*schemas.Table, error) {
 	args := []interface{}{}
 	s := "SELECT name FROM sqlite_master WHERE type='table'"
@@ -462,6 +521,103 @@ func (db *sqlite3) GetTables(queryer core.Queryer, ctx context.Context) ([]*sche
 	return tables, nil
 }
 
+// GetForeignKeys returns the foreign keys of the table, which are named by
+// the columns since SQLite keeps no names of them
+func (db *sqlite3) GetForeignKeys(queryer core.Queryer, ctx context.Context, tableName string) (map[string]*schemas.ForeignKey, error) {
+	s := fmt.Sprintf("PRAGMA foreign_key_list(%s)", db.Quoter().Quote(tableName))
+	rows, err := queryer.QueryContext(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type fkColumn struct {
+		col, refTable, refCol, onDelete, onUpdate string
+	}
+	var (
+		ids     []int
+		columns = make(map[int][]fkColumn)
+	)
+	for rows.Next() {
+		var (
+			id, seq int
+			refCol  sql.NullString
+			c       fkColumn
+			match   string
+		)
+		if err := rows.Scan(&id, &seq, &c.refTable, &c.col, &refCol, &c.onUpdate, &c.onDelete, &match); err != nil {
+			return nil, err
+		}
+		// the primary key is referenced if no column given
+		c.refCol = refCol.String
+		if _, ok := columns[id]; !ok {
+			ids = append(ids, id)
+		}
+		columns[id] = append(columns[id], c)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	fks := make(map[string]*schemas.ForeignKey)
+	for _, id := range ids {
+		cols := make([]string, 0, len(columns[id]))
+		for _, c := range columns[id] {
+			cols = append(cols, c.col)
+		}
+		name := strings.Join(cols, "_")
+		for _, c := range columns[id] {
+			addForeignKeyColumn(fks, tableName, name, c.col, c.refTable, c.refCol, c.onDelete, c.onUpdate)
+		}
+	}
+	return fks, nil
+}
+
+// CreateTableSQL returns the SQL to create the table with its foreign keys
+// since SQLite could not add them by ALTER TABLE
+func (db *sqlite3) CreateTableSQL(ctx context.Context, queryer core.Queryer, table *schemas.Table, tableName string) (string, bool, error) {
+	sql, ok, err := db.Base.CreateTableSQL(ctx, queryer, table, tableName)
+	if err != nil || len(table.ForeignKeys) == 0 {
+		return sql, ok, err
+	}
+	if tableName == "" {
+		tableName = table.Name
+	}
+
+	names := make([]string, 0, len(table.ForeignKeys))
+	for name := range table.ForeignKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(strings.TrimSuffix(sql, ")"))
+	for _, name := range names {
+		b.WriteString(", ")
+		writeForeignKey(&b, db.Quoter(), tableName, table.ForeignKeys[name], true)
+	}
+	b.WriteString(")")
+	return b.String(), ok, nil
+}
+
+// AlterColumnSQL returns no SQL since SQLite could not alter a column without
+// recreating the table
+func (db *sqlite3) AlterColumnSQL(tableName string, col *schemas.Column, changes ColumnChange) []string {
+	return nil
+}
+
+// CreateForeignKeySQL returns an empty SQL since the foreign keys are created
+// with the table
+func (db *sqlite3) CreateForeignKeySQL(tableName string, fk *schemas.ForeignKey) string {
+	return ""
+}
+
+// DropForeignKeySQL returns an empty SQL since SQLite could not drop a
+// foreign key without recreating the table
+func (db *sqlite3) DropForeignKeySQL(tableName string, fk *schemas.ForeignKey) string {
+	return ""
+}
+
 func (db *sqlite3) GetIndexes(queryer core.Queryer, ctx context.Context, tableName string) (map[string]*schemas.Index, error) {
 	args := []interface{}{tableName}
 	s := "SELECT sql FROM sqlite_master WHERE type='index' and tbl_name = ?"
@@ -5,9 +5,11 @@
 package dialects
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"xorm.io/xorm/schemas"
 )
 
 func TestSplitColStr(t *testing.T) {
@@ -33,3 +35,43 @@ func TestSplitColStr(t *testing.T) {
 		assert.EqualValues(t, kase.fields, splitColStr(kase.colStr))
 	}
 }
+
+func TestForeignKeySQL(t *testing.T) {
+	table := schemas.NewEmptyTable()
+	table.Name = "order"
+	col := schemas.NewColumn("user_id", "UserId", schemas.SQLType{Name: schemas.BigInt}, 0, 0, true)
+	table.AddColumn(col)
+	fk := schemas.NewForeignKey("user_id", []string{"user_id"}, "user", []string{"id"})
+	fk.OnDelete = schemas.Cascade
+	table.AddForeignKey(fk)
+
+	mysql, err := OpenDialect("mysql", "root:@tcp(localhost:3306)/test")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "ALTER TABLE `order` ADD CONSTRAINT `FK_order_user_id` FOREIGN KEY (`user_id`) REFERENCES `user` (`id`) ON DELETE CASCADE",
+		mysql.CreateForeignKeySQL("order", fk))
+	assert.EqualValues(t, "ALTER TABLE `order` DROP FOREIGN KEY `FK_order_user_id`", mysql.DropForeignKeySQL("order", fk))
+
+	postgres, err := OpenDialect("postgres", "postgres://postgres:@localhost:5432/test?sslmode=disable")
+	assert.NoError(t, err)
+	assert.EqualValues(t, `ALTER TABLE "order" DROP CONSTRAINT "FK_order_user_id"`, postgres.DropForeignKeySQL("order", fk))
+
+	// SQLite declares the foreign keys within CREATE TABLE
+	sqlite, err := OpenDialect("sqlite3", "./test.db")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "", sqlite.CreateForeignKeySQL("order", fk))
+	sql, _, err := sqlite.CreateTableSQL(context.Background(), nil, table, "")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "CREATE TABLE IF NOT EXISTS `order` (`user_id` INTEGER NULL, "+
+		"CONSTRAINT `FK_order_user_id` FOREIGN KEY (`user_id`) REFERENCES `user` (`id`) ON DELETE CASCADE)", sql)
+}
+
+func TestSplitColumnDefinitions(t *testing.T) {
+	defs := splitColumnDefinitions("`id` INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL, `amount` DECIMAL(10, 2) DEFAULT '1,2', " +
+		"CONSTRAINT `FK_order_user_id` FOREIGN KEY (`user_id`) REFERENCES `user` (`id`) ON DELETE CASCADE")
+	if assert.Len(t, defs, 3) {
+		assert.EqualValues(t, " `amount` DECIMAL(10, 2) DEFAULT '1,2'", defs[1])
+		assert.False(t, isTableConstraint(defs[0]))
+		assert.False(t, isTableConstraint(defs[1]))
+		assert.True(t, isTableConstraint(defs[2]))
+	}
+}
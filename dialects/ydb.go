@@ -0,0 +1,1018 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dialects
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"xorm.io/xorm/convert"
+	"xorm.io/xorm/core"
+	"xorm.io/xorm/schemas"
+)
+
+func init() {
+	RegisterDriver("ydb", &ydbDriver{})
+	RegisterDialect(schemas.YDB, func() Dialect {
+		return &ydb{}
+	})
+}
+
+var (
+	ydbReservedWords = map[string]bool{
+		"ABORT":         true,
+		"ACTION":        true,
+		"ADD":           true,
+		"AFTER":         true,
+		"ALL":           true,
+		"ALTER":         true,
+		"ANALYZE":       true,
+		"AND":           true,
+		"ANSI":          true,
+		"ANY":           true,
+		"ARRAY":         true,
+		"AS":            true,
+		"ASC":           true,
+		"ASSUME":        true,
+		"ASYNC":         true,
+		"ATTACH":        true,
+		"AUTOINCREMENT": true,
+		"BEFORE":        true,
+		"BEGIN":         true,
+		"BERNOULLI":     true,
+		"BETWEEN":       true,
+		"BITCAST":       true,
+		"BY":            true,
+		"CASCADE":       true,
+		"CASE":          true,
+		"CAST":          true,
+		"CHANGEFEED":    true,
+		"CHECK":         true,
+		"COLLATE":       true,
+		"COLUMN":        true,
+		"COLUMNS":       true,
+		"COMMIT":        true,
+		"COMPACT":       true,
+		"CONDITIONAL":   true,
+		"CONFLICT":      true,
+		"CONSTRAINT":    true,
+		"COVER":         true,
+		"CREATE":        true,
+		"CROSS":         true,
+		"CUBE":          true,
+		"CURRENT":       true,
+		"DATA":          true,
+		"DATABASE":      true,
+		"DECIMAL":       true,
+		"DECLARE":       true,
+		"DEFAULT":       true,
+		"DEFERRABLE":    true,
+		"DEFERRED":      true,
+		"DEFINE":        true,
+		"DELETE":        true,
+		"DESC":          true,
+		"DETACH":        true,
+		"DICT":          true,
+		"DISABLE":       true,
+		"DISCARD":       true,
+		"DISTINCT":      true,
+		"DO":            true,
+		"DROP":          true,
+		"EACH":          true,
+		"ELSE":          true,
+		"EMPTY":         true,
+		"EMPTY_ACTION":  true,
+		"ENCRYPTED":     true,
+		"END":           true,
+		"ENUM":          true,
+		"ERASE":         true,
+		"ERROR":         true,
+		"ESCAPE":        true,
+		"EVALUATE":      true,
+		"EXCEPT":        true,
+		"EXCLUDE":       true,
+		"EXCLUSION":     true,
+		"EXCLUSIVE":     true,
+		"EXISTS":        true,
+		"EXPLAIN":       true,
+		"EXPORT":        true,
+		"EXTERNAL":      true,
+		"FAIL":          true,
+		"FAMILY":        true,
+		"FILTER":        true,
+		"FLATTEN":       true,
+		"FLOW":          true,
+		"FOLLOWING":     true,
+		"FOR":           true,
+		"FOREIGN":       true,
+		"FROM":          true,
+		"FULL":          true,
+		"FUNCTION":      true,
+		"GLOB":          true,
+		"GLOBAL":        true,
+		"GROUP":         true,
+		"GROUPING":      true,
+		"GROUPS":        true,
+		"HASH":          true,
+		"HAVING":        true,
+		"HOP":           true,
+		"IF":            true,
+		"IGNORE":        true,
+		"ILIKE":         true,
+		"IMMEDIATE":     true,
+		"IMPORT":        true,
+		"IN":            true,
+		"INDEX":         true,
+		"INDEXED":       true,
+		"INHERITS":      true,
+		"INITIALLY":     true,
+		"INNER":         true,
+		"INSERT":        true,
+		"INSTEAD":       true,
+		"INTERSECT":     true,
+		"INTO":          true,
+		"IS":            true,
+		"ISNULL":        true,
+		"JOIN":          true,
+		"JSON_EXISTS":   true,
+		"JSON_QUERY":    true,
+		"JSON_VALUE":    true,
+		"KEY":           true,
+		"LEFT":          true,
+		"LIKE":          true,
+		"LIMIT":         true,
+		"LIST":          true,
+		"LOCAL":         true,
+		"MATCH":         true,
+		"NATURAL":       true,
+		"NO":            true,
+		"NOT":           true,
+		"NOTNULL":       true,
+		"NULL":          true,
+		"NULLS":         true,
+		"OBJECT":        true,
+		"OF":            true,
+		"OFFSET":        true,
+		"ON":            true,
+		"ONLY":          true,
+		"OPTIONAL":      true,
+		"OR":            true,
+		"ORDER":         true,
+		"OTHERS":        true,
+		"OUTER":         true,
+		"OVER":          true,
+		"PARTITION":     true,
+		"PASSING":       true,
+		"PASSWORD":      true,
+		"PLAN":          true,
+		"PRAGMA":        true,
+		"PRECEDING":     true,
+		"PRESORT":       true,
+		"PRIMARY":       true,
+		"PROCESS":       true,
+		"RAISE":         true,
+		"RANGE":         true,
+		"REDUCE":        true,
+		"REFERENCES":    true,
+		"REGEXP":        true,
+		"REINDEX":       true,
+		"RELEASE":       true,
+		"RENAME":        true,
+		"REPEATABLE":    true,
+		"REPLACE":       true,
+		"RESET":         true,
+		"RESOURCE":      true,
+		"RESPECT":       true,
+		"RESTRICT":      true,
+		"RESULT":        true,
+		"RETURN":        true,
+		"RETURNING":     true,
+		"REVERT":        true,
+		"RIGHT":         true,
+		"RLIKE":         true,
+		"ROLLBACK":      true,
+		"ROLLUP":        true,
+		"ROW":           true,
+		"ROWS":          true,
+		"SAMPLE":        true,
+		"SAVEPOINT":     true,
+		"SCHEMA":        true,
+		"SELECT":        true,
+		"SEMI":          true,
+		"SET":           true,
+		"SETS":          true,
+		"SOURCE":        true,
+		"STREAM":        true,
+		"STRUCT":        true,
+		"SUBQUERY":      true,
+		"SYMBOLS":       true,
+		"SYNC":          true,
+		"SYSTEM":        true,
+		"TABLE":         true,
+		"TABLESAMPLE":   true,
+		"TABLESTORE":    true,
+		"TAGGED":        true,
+		"TEMP":          true,
+		"TEMPORARY":     true,
+		"THEN":          true,
+		"TIES":          true,
+		"TO":            true,
+		"TRANSACTION":   true,
+		"TRIGGER":       true,
+		"TUPLE":         true,
+		"UNBOUNDED":     true,
+		"UNCONDITIONAL": true,
+		"UNION":         true,
+		"UNIQUE":        true,
+		"UNKNOWN":       true,
+		"UPDATE":        true,
+		"UPSERT":        true,
+		"USE":           true,
+		"USER":          true,
+		"USING":         true,
+		"VACUUM":        true,
+		"VALUES":        true,
+		"VARIANT":       true,
+		"VIEW":          true,
+		"VIRTUAL":       true,
+		"WHEN":          true,
+		"WHERE":         true,
+		"WINDOW":        true,
+		"WITH":          true,
+		"WITHOUT":       true,
+		"WRAPPER":       true,
+		"XOR":           true,
+	}
+
+	ydbQuoter = schemas.Quoter{
+		Prefix:     '`',
+		Suffix:     '`',
+		IsReserved: schemas.AlwaysReserve,
+	}
+)
+
+// enumerates all YQL primitive types used by the ydb dialect
+const (
+	ydbBool         = "Bool"
+	ydbInt8         = "Int8"
+	ydbInt16        = "Int16"
+	ydbInt32        = "Int32"
+	ydbInt64        = "Int64"
+	ydbUint8        = "Uint8"
+	ydbUint16       = "Uint16"
+	ydbUint32       = "Uint32"
+	ydbUint64       = "Uint64"
+	ydbFloat        = "Float"
+	ydbDouble       = "Double"
+	ydbDecimal      = "Decimal"
+	ydbString       = "String"
+	ydbUtf8         = "Utf8"
+	ydbJSON         = "Json"
+	ydbJSONDocument = "JsonDocument"
+	ydbYson         = "Yson"
+	ydbUUID         = "Uuid"
+	ydbDate         = "Date"
+	ydbDatetime     = "Datetime"
+	ydbTimestamp    = "Timestamp"
+	ydbInterval     = "Interval"
+	ydbSerial       = "Serial"
+	ydbBigSerial    = "BigSerial"
+)
+
+// ydbSchemeDescriber describes tables through the YDB scheme service. The
+// connections of the ydb-go-sdk database/sql driver implement it, so does
+// any queryer which wants to stand in for a real database.
+type ydbSchemeDescriber interface {
+	Version(ctx context.Context) (string, error)
+	GetTables(ctx context.Context, folder string, recursive bool, excludeSysDirs bool) ([]string, error)
+	IsTableExists(ctx context.Context, tableName string) (bool, error)
+	IsColumnExists(ctx context.Context, tableName, columnName string) (bool, error)
+	GetColumns(ctx context.Context, tableName string) ([]string, error)
+	GetColumnType(ctx context.Context, tableName, columnName string) (string, error)
+	GetPrimaryKeys(ctx context.Context, tableName string) ([]string, error)
+	GetIndexes(ctx context.Context, tableName string) ([]string, error)
+	GetIndexColumns(ctx context.Context, tableName, indexName string) ([]string, error)
+}
+
+// ydbDescribe calls f with the scheme describer behind the queryer. Schema
+// changes are not transactional on YDB, so a transaction cannot be described.
+func ydbDescribe(ctx context.Context, queryer core.Queryer, f func(ydbSchemeDescriber) error) error {
+	if describer, ok := queryer.(ydbSchemeDescriber); ok {
+		return f(describer)
+	}
+
+	db, ok := queryer.(interface {
+		Conn(ctx context.Context) (*sql.Conn, error)
+	})
+	if !ok {
+		return fmt.Errorf("ydb: cannot describe scheme via %T", queryer)
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn interface{}) error {
+		describer, ok := driverConn.(ydbSchemeDescriber)
+		if !ok {
+			return fmt.Errorf("ydb: driver connection %T cannot describe scheme", driverConn)
+		}
+		return f(describer)
+	})
+}
+
+type ydb struct {
+	Base
+}
+
+func (db *ydb) Init(uri *URI) error {
+	db.quoter = ydbQuoter
+	return db.Base.Init(db, uri)
+}
+
+func (db *ydb) Version(ctx context.Context, queryer core.Queryer) (*schemas.Version, error) {
+	var version string
+	if err := ydbDescribe(ctx, queryer, func(describer ydbSchemeDescriber) (err error) {
+		version, err = describer.Version(ctx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return &schemas.Version{
+		Number:  version,
+		Edition: "ydb",
+	}, nil
+}
+
+func (db *ydb) Features() *DialectFeatures {
+	return &DialectFeatures{
+		AutoincrMode: IncrAutoincrMode,
+	}
+}
+
+// Alias returns the underlying integer type of the serial types
+func (db *ydb) Alias(col string) string {
+	switch col {
+	case ydbSerial:
+		return ydbInt32
+	case ydbBigSerial:
+		return ydbInt64
+	}
+	return col
+}
+
+func (db *ydb) SetQuotePolicy(quotePolicy QuotePolicy) {
+	switch quotePolicy {
+	case QuotePolicyNone:
+		q := ydbQuoter
+		q.IsReserved = schemas.AlwaysNoReserve
+		db.quoter = q
+	case QuotePolicyReserved:
+		q := ydbQuoter
+		q.IsReserved = db.IsReserved
+		db.quoter = q
+	case QuotePolicyAlways:
+		fallthrough
+	default:
+		db.quoter = ydbQuoter
+	}
+}
+
+func (db *ydb) SQLType(c *schemas.Column) string {
+	if c.IsAutoIncrement {
+		switch c.SQLType.Name {
+		case schemas.BigInt, schemas.UnsignedBigInt, schemas.BigSerial:
+			return ydbBigSerial
+		default:
+			return ydbSerial
+		}
+	}
+
+	switch t := c.SQLType.Name; t {
+	case schemas.Bit, schemas.Bool, schemas.Boolean:
+		if c.Default == "1" {
+			c.Default = "true"
+		} else if c.Default == "0" {
+			c.Default = "false"
+		}
+		return ydbBool
+	case schemas.TinyInt:
+		return ydbInt8
+	case schemas.UnsignedTinyInt:
+		return ydbUint8
+	case schemas.SmallInt:
+		return ydbInt16
+	case schemas.UnsignedSmallInt:
+		return ydbUint16
+	case schemas.MediumInt, schemas.Int, schemas.Integer:
+		return ydbInt32
+	case schemas.UnsignedMediumInt, schemas.UnsignedInt:
+		return ydbUint32
+	case schemas.BigInt:
+		return ydbInt64
+	case schemas.UnsignedBit, schemas.UnsignedBigInt:
+		return ydbUint64
+	case schemas.Serial:
+		return ydbSerial
+	case schemas.BigSerial:
+		return ydbBigSerial
+	case schemas.Float, schemas.UnsignedFloat:
+		return ydbFloat
+	case schemas.Real, schemas.Double:
+		return ydbDouble
+	case schemas.Decimal, schemas.Numeric, schemas.Money, schemas.SmallMoney:
+		// YDB only accepts an explicit precision and scale for decimals
+		if c.Length > 0 {
+			return fmt.Sprintf("%s(%d,%d)", ydbDecimal, c.Length, c.Length2)
+		}
+		return ydbDecimal + "(22,9)"
+	case schemas.Char, schemas.NChar, schemas.Varchar, schemas.NVarchar, schemas.VARCHAR2,
+		schemas.TinyText, schemas.Text, schemas.NText, schemas.MediumText, schemas.LongText,
+		schemas.Clob, schemas.Enum, schemas.Set, schemas.SysName, schemas.XML:
+		return ydbUtf8
+	case schemas.Json:
+		return ydbJSON
+	case schemas.Jsonb:
+		return ydbJSONDocument
+	case schemas.Uuid:
+		return ydbUUID
+	case schemas.Binary, schemas.VarBinary, schemas.TinyBlob, schemas.Blob,
+		schemas.MediumBlob, schemas.LongBlob, schemas.Bytea, schemas.UniqueIdentifier:
+		return ydbString
+	case schemas.Date:
+		return ydbDate
+	case schemas.DateTime, schemas.SmallDateTime, schemas.TimeStamp, schemas.TimeStampz:
+		return ydbTimestamp
+	case schemas.Time:
+		return ydbInterval
+	default:
+		return t
+	}
+}
+
+func (db *ydb) ColumnTypeKind(t string) int {
+	switch ydbTypeName(t) {
+	case ydbBool:
+		return schemas.BOOL_TYPE
+	case ydbUtf8, ydbJSON, ydbJSONDocument, ydbUUID:
+		return schemas.TEXT_TYPE
+	case ydbString, ydbYson:
+		return schemas.BLOB_TYPE
+	case ydbDate, ydbDatetime, ydbTimestamp, ydbInterval:
+		return schemas.TIME_TYPE
+	case ydbInt8, ydbInt16, ydbInt32, ydbInt64, ydbUint8, ydbUint16, ydbUint32, ydbUint64,
+		ydbFloat, ydbDouble, ydbDecimal, ydbSerial, ydbBigSerial:
+		return schemas.NUMERIC_TYPE
+	default:
+		return schemas.UNKNOW_TYPE
+	}
+}
+
+func (db *ydb) IsReserved(name string) bool {
+	_, ok := ydbReservedWords[strings.ToUpper(name)]
+	return ok
+}
+
+// AutoIncrStr returns an empty string, YDB declares auto increment columns
+// via the Serial and BigSerial types
+func (db *ydb) AutoIncrStr() string {
+	return ""
+}
+
+// ydbColumnString generates a YQL column definition, which has the
+// nullability before the default value
+func ydbColumnString(dialect Dialect, col *schemas.Column) (string, error) {
+	var bd strings.Builder
+	if err := dialect.Quoter().QuoteTo(&bd, col.Name); err != nil {
+		return "", err
+	}
+	if err := bd.WriteByte(' '); err != nil {
+		return "", err
+	}
+	if _, err := bd.WriteString(dialect.SQLType(col)); err != nil {
+		return "", err
+	}
+	if !col.Nullable {
+		if _, err := bd.WriteString(" NOT NULL"); err != nil {
+			return "", err
+		}
+	}
+	if !col.DefaultIsEmpty && !col.IsAutoIncrement {
+		if _, err := bd.WriteString(" DEFAULT "); err != nil {
+			return "", err
+		}
+		if col.Default == "" {
+			if _, err := bd.WriteString(`""`); err != nil {
+				return "", err
+			}
+		} else {
+			if _, err := bd.WriteString(col.Default); err != nil {
+				return "", err
+			}
+		}
+	}
+	return bd.String(), nil
+}
+
+// ydbWriteIndex writes the inline definition of a secondary index
+func (db *ydb) writeIndex(b *strings.Builder, tableName string, index *schemas.Index) error {
+	quoter := db.Quoter()
+	if _, err := b.WriteString("INDEX "); err != nil {
+		return err
+	}
+	if err := quoter.QuoteTo(b, index.XName(path.Base(tableName))); err != nil {
+		return err
+	}
+	if index.Type == schemas.UniqueType {
+		if _, err := b.WriteString(" GLOBAL UNIQUE SYNC ON ("); err != nil {
+			return err
+		}
+	} else {
+		if _, err := b.WriteString(" GLOBAL ON ("); err != nil {
+			return err
+		}
+	}
+	if err := quoter.JoinWrite(b, index.Cols, ","); err != nil {
+		return err
+	}
+	_, err := b.WriteString(")")
+	return err
+}
+
+// CreateTableSQL generates the table creation SQL. YDB requires a primary key
+// on every table and declares the secondary indexes within the statement.
+func (db *ydb) CreateTableSQL(ctx context.Context, queryer core.Queryer, table *schemas.Table, tableName string) (string, bool, error) {
+	if tableName == "" {
+		tableName = table.Name
+	}
+	if len(table.PrimaryKeys) == 0 {
+		return "", false, fmt.Errorf("ydb: table %s must have a primary key", tableName)
+	}
+
+	quoter := db.Quoter()
+	var b strings.Builder
+	if _, err := b.WriteString("CREATE TABLE "); err != nil {
+		return "", false, err
+	}
+	if err := quoter.QuoteTo(&b, tableName); err != nil {
+		return "", false, err
+	}
+	if _, err := b.WriteString(" ("); err != nil {
+		return "", false, err
+	}
+
+	for _, colName := range table.ColumnsSeq() {
+		col := table.GetColumn(colName)
+		s, err := ydbColumnString(db, col)
+		if err != nil {
+			return "", false, err
+		}
+		if _, err := b.WriteString(s); err != nil {
+			return "", false, err
+		}
+		if _, err := b.WriteString(", "); err != nil {
+			return "", false, err
+		}
+	}
+
+	if _, err := b.WriteString("PRIMARY KEY ("); err != nil {
+		return "", false, err
+	}
+	if err := quoter.JoinWrite(&b, table.PrimaryKeys, ","); err != nil {
+		return "", false, err
+	}
+	if _, err := b.WriteString(")"); err != nil {
+		return "", false, err
+	}
+
+	// keep the generated SQL stable whatever the map order is
+	indexNames := make([]string, 0, len(table.Indexes))
+	for name := range table.Indexes {
+		indexNames = append(indexNames, name)
+	}
+	sort.Strings(indexNames)
+	for _, name := range indexNames {
+		if _, err := b.WriteString(", "); err != nil {
+			return "", false, err
+		}
+		if err := db.writeIndex(&b, tableName, table.Indexes[name]); err != nil {
+			return "", false, err
+		}
+	}
+
+	if _, err := b.WriteString(")"); err != nil {
+		return "", false, err
+	}
+
+	return b.String(), false, nil
+}
+
+// SavepointSQL returns ErrSavepointNotSupported, YDB has no savepoints
+func (db *ydb) SavepointSQL(name string) (string, error) {
+	return "", ErrSavepointNotSupported
+}
+
+// ReleaseSavepointSQL returns ErrSavepointNotSupported, YDB has no savepoints
+func (db *ydb) ReleaseSavepointSQL(name string) (string, error) {
+	return "", ErrSavepointNotSupported
+}
+
+// RollbackToSavepointSQL returns ErrSavepointNotSupported, YDB has no savepoints
+func (db *ydb) RollbackToSavepointSQL(name string) (string, error) {
+	return "", ErrSavepointNotSupported
+}
+
+func (db *ydb) DropTableSQL(tableName string) (string, bool) {
+	return fmt.Sprintf("DROP TABLE %s", db.Quoter().Quote(tableName)), false
+}
+
+func (db *ydb) IsTableExist(queryer core.Queryer, ctx context.Context, tableName string) (bool, error) {
+	var exist bool
+	err := ydbDescribe(ctx, queryer, func(describer ydbSchemeDescriber) (err error) {
+		exist, err = describer.IsTableExists(ctx, tableName)
+		return err
+	})
+	return exist, err
+}
+
+func (db *ydb) IsColumnExist(queryer core.Queryer, ctx context.Context, tableName, colName string) (bool, error) {
+	var exist bool
+	err := ydbDescribe(ctx, queryer, func(describer ydbSchemeDescriber) (err error) {
+		exist, err = describer.IsColumnExists(ctx, tableName, colName)
+		return err
+	})
+	return exist, err
+}
+
+// AddColumnSQL returns a SQL to add a column, YDB only adds nullable columns
+// without default values
+func (db *ydb) AddColumnSQL(tableName string, col *schemas.Column) string {
+	quoter := db.Quoter()
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s",
+		quoter.Quote(tableName), quoter.Quote(col.Name), db.SQLType(col))
+}
+
+// ModifyColumnSQL returns a SQL to modify the nullability of a column, YDB
+// cannot change the type of an existing column
+func (db *ydb) ModifyColumnSQL(tableName string, col *schemas.Column) string {
+	quoter := db.Quoter()
+	action := "SET NOT NULL"
+	if col.Nullable {
+		action = "DROP NOT NULL"
+	}
+	return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s",
+		quoter.Quote(tableName), quoter.Quote(col.Name), action)
+}
+
+// AlterColumnSQL returns a SQL to change the nullability of a column only
+func (db *ydb) AlterColumnSQL(tableName string, col *schemas.Column, changes ColumnChange) []string {
+	if changes != ColumnNullableChanged {
+		return nil
+	}
+	return []string{db.ModifyColumnSQL(tableName, col)}
+}
+
+func (db *ydb) IndexCheckSQL(tableName, idxName string) (string, []interface{}) {
+	return "", nil
+}
+
+// CreateIndexSQL returns a SQL to add a secondary index to an existing table
+func (db *ydb) CreateIndexSQL(tableName string, index *schemas.Index) string {
+	var b strings.Builder
+	b.WriteString("ALTER TABLE ")
+	_ = db.Quoter().QuoteTo(&b, tableName)
+	b.WriteString(" ADD ")
+	_ = db.writeIndex(&b, tableName, index)
+	return b.String()
+}
+
+// DropIndexSQL returns a SQL to drop a secondary index
+func (db *ydb) DropIndexSQL(tableName string, index *schemas.Index) string {
+	quoter := db.Quoter()
+	var name string
+	if index.IsRegular {
+		name = index.XName(path.Base(tableName))
+	} else {
+		name = index.Name
+	}
+	return fmt.Sprintf("ALTER TABLE %s DROP INDEX %s", quoter.Quote(tableName), quoter.Quote(name))
+}
+
+// CreateForeignKeySQL returns an empty SQL since YDB has no foreign keys
+func (db *ydb) CreateForeignKeySQL(tableName string, fk *schemas.ForeignKey) string {
+	return ""
+}
+
+// DropForeignKeySQL returns an empty SQL since YDB has no foreign keys
+func (db *ydb) DropForeignKeySQL(tableName string, fk *schemas.ForeignKey) string {
+	return ""
+}
+
+func (db *ydb) GetTables(queryer core.Queryer, ctx context.Context) ([]*schemas.Table, error) {
+	var names []string
+	if err := ydbDescribe(ctx, queryer, func(describer ydbSchemeDescriber) (err error) {
+		names, err = describer.GetTables(ctx, ".", true, true)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	tables := make([]*schemas.Table, 0, len(names))
+	for _, name := range names {
+		table := schemas.NewEmptyTable()
+		table.Name = name
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// ydbTypeName strips the optional wrapper and the parameters from a YQL type
+func ydbTypeName(tp string) string {
+	tp = strings.TrimSpace(tp)
+	if strings.HasPrefix(tp, "Optional<") && strings.HasSuffix(tp, ">") {
+		tp = tp[len("Optional<") : len(tp)-1]
+	}
+	tp = strings.TrimSuffix(tp, "?")
+	return schemas.SQLTypeName(tp)
+}
+
+// parseYdbColumnType parses a YQL type as returned by the scheme service,
+// i.e. Optional<Decimal(22,9)> or Int64?, into a column
+func parseYdbColumnType(col *schemas.Column, tp string) error {
+	tp = strings.TrimSpace(tp)
+	col.Nullable = false
+	if strings.HasPrefix(tp, "Optional<") && strings.HasSuffix(tp, ">") {
+		tp = tp[len("Optional<") : len(tp)-1]
+		col.Nullable = true
+	} else if strings.HasSuffix(tp, "?") {
+		tp = strings.TrimSuffix(tp, "?")
+		col.Nullable = true
+	}
+
+	var len1, len2 int64
+	name := tp
+	if i := strings.Index(tp, "("); i > 0 && strings.HasSuffix(tp, ")") {
+		name = tp[:i]
+		params := strings.Split(tp[i+1:len(tp)-1], ",")
+		var err error
+		len1, err = strconv.ParseInt(strings.TrimSpace(params[0]), 10, 64)
+		if err != nil {
+			return fmt.Errorf("ydb: unknown column type %s: %v", tp, err)
+		}
+		if len(params) > 1 {
+			len2, err = strconv.ParseInt(strings.TrimSpace(params[1]), 10, 64)
+			if err != nil {
+				return fmt.Errorf("ydb: unknown column type %s: %v", tp, err)
+			}
+		}
+	}
+
+	switch name {
+	case ydbBool:
+		name = schemas.Bool
+	case ydbInt8:
+		name = schemas.TinyInt
+	case ydbUint8:
+		name = schemas.UnsignedTinyInt
+	case ydbInt16:
+		name = schemas.SmallInt
+	case ydbUint16:
+		name = schemas.UnsignedSmallInt
+	case ydbInt32:
+		name = schemas.Int
+	case ydbUint32:
+		name = schemas.UnsignedInt
+	case ydbInt64:
+		name = schemas.BigInt
+	case ydbUint64:
+		name = schemas.UnsignedBigInt
+	case ydbFloat:
+		name = schemas.Float
+	case ydbDouble:
+		name = schemas.Double
+	case ydbDecimal:
+		name = schemas.Decimal
+	case ydbUtf8, "Text":
+		name = schemas.Varchar
+	case ydbString, "Bytes":
+		name = schemas.Blob
+	case ydbJSON:
+		name = schemas.Json
+	case ydbJSONDocument:
+		name = schemas.Jsonb
+	case ydbUUID:
+		name = schemas.Uuid
+	case ydbDate:
+		name = schemas.Date
+	case ydbTimestamp:
+		name = schemas.TimeStamp
+	}
+
+	col.SQLType = schemas.SQLType{Name: name, DefaultLength: len1, DefaultLength2: len2}
+	col.Length = len1
+	col.Length2 = len2
+	return nil
+}
+
+func (db *ydb) GetColumns(queryer core.Queryer, ctx context.Context, tableName string) ([]string, map[string]*schemas.Column, error) {
+	colSeq := make([]string, 0)
+	cols := make(map[string]*schemas.Column)
+
+	err := ydbDescribe(ctx, queryer, func(describer ydbSchemeDescriber) error {
+		names, err := describer.GetColumns(ctx, tableName)
+		if err != nil {
+			return err
+		}
+		pks, err := describer.GetPrimaryKeys(ctx, tableName)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			tp, err := describer.GetColumnType(ctx, tableName, name)
+			if err != nil {
+				return err
+			}
+
+			col := new(schemas.Column)
+			col.Name = name
+			col.Indexes = make(map[string]int)
+			col.DefaultIsEmpty = true
+			if err := parseYdbColumnType(col, tp); err != nil {
+				return err
+			}
+			for _, pk := range pks {
+				if pk == name {
+					col.IsPrimaryKey = true
+					break
+				}
+			}
+
+			cols[col.Name] = col
+			colSeq = append(colSeq, col.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return colSeq, cols, nil
+}
+
+func (db *ydb) GetIndexes(queryer core.Queryer, ctx context.Context, tableName string) (map[string]*schemas.Index, error) {
+	indexes := make(map[string]*schemas.Index)
+	baseName := path.Base(tableName)
+
+	err := ydbDescribe(ctx, queryer, func(describer ydbSchemeDescriber) error {
+		names, err := describer.GetIndexes(ctx, tableName)
+		if err != nil {
+			return err
+		}
+
+		for _, indexName := range names {
+			cols, err := describer.GetIndexColumns(ctx, tableName, indexName)
+			if err != nil {
+				return err
+			}
+
+			index := new(schemas.Index)
+			// the scheme service doesn't report uniqueness, rely on the naming
+			if strings.HasPrefix(indexName, "UQE_") {
+				index.Type = schemas.UniqueType
+			} else {
+				index.Type = schemas.IndexType
+			}
+			if strings.HasPrefix(indexName, "IDX_"+baseName) || strings.HasPrefix(indexName, "UQE_"+baseName) {
+				index.Name = indexName[5+len(baseName):]
+				index.IsRegular = true
+			} else {
+				index.Name = indexName
+			}
+			index.Cols = cols
+			indexes[index.Name] = index
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return indexes, nil
+}
+
+// ydbSeqFilter replaces ?, ? ... with $p0, $p1 ... which are the names
+// the ydb-go-sdk driver gives to positional arguments when it declares them.
+// YQL needs a DECLARE for every parameter and the filter can't know the types
+// of the arguments, so the driver has to declare them itself, see Parse.
+type ydbSeqFilter struct {
+	Prefix string
+	Start  int
+}
+
+// Do implements Filter
+func (s *ydbSeqFilter) Do(ctx context.Context, sql string) string {
+	return oracleSeqFilterConvertQuestionMark(sql, s.Prefix, s.Start)
+}
+
+func (db *ydb) Filters() []Filter {
+	return []Filter{&ydbSeqFilter{
+		Prefix: "$p",
+		Start:  0,
+	}}
+}
+
+type ydbDriver struct {
+	baseDriver
+}
+
+func (p *ydbDriver) Features() *DriverFeatures {
+	return &DriverFeatures{
+		SupportReturnInsertedID: false,
+	}
+}
+
+// Parse parses the datasource
+// grpc://localhost:2136/local or grpcs://ydb.example.com:2135/ru-central1/b1g/etn?param=value
+//
+// The parameters of the queries are not declared by xorm, the driver has to be
+// opened with go_query_bind=declare in the datasource, a datasource without it
+// is rejected.
+func (p *ydbDriver) Parse(driverName, dataSourceName string) (*URI, error) {
+	u, err := url.Parse(dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, errors.New("ydb: endpoint needed")
+	}
+	if !ydbDeclaresParams(u.Query()["go_query_bind"]) {
+		return nil, errors.New("ydb: go_query_bind has to contain declare since the parameters are not declared by xorm")
+	}
+
+	dbName := u.Path
+	if database := u.Query().Get("database"); database != "" {
+		dbName = database
+	}
+
+	uri := &URI{
+		DBType: schemas.YDB,
+		Proto:  u.Scheme,
+		Host:   u.Hostname(),
+		Port:   u.Port(),
+		DBName: dbName,
+	}
+	if u.User != nil {
+		uri.User = u.User.Username()
+		uri.Passwd, _ = u.User.Password()
+	}
+	return uri, nil
+}
+
+// ydbDeclaresParams returns true if the go_query_bind values of the datasource
+// make the driver declare the parameters
+func ydbDeclaresParams(binds []string) bool {
+	for _, bind := range binds {
+		for _, b := range strings.Split(bind, ",") {
+			if strings.EqualFold(strings.TrimSpace(b), "declare") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *ydbDriver) GenScanResult(colType string) (interface{}, error) {
+	switch ydbTypeName(colType) {
+	case ydbBool:
+		var s sql.NullBool
+		return &s, nil
+	case ydbInt8, ydbInt16, ydbInt32, ydbInt64, ydbUint8, ydbUint16, ydbUint32, ydbSerial, ydbBigSerial:
+		var s sql.NullInt64
+		return &s, nil
+	case ydbUint64:
+		var s convert.NullUint64
+		return &s, nil
+	case ydbFloat, ydbDouble:
+		var s sql.NullFloat64
+		return &s, nil
+	case ydbDate, ydbDatetime, ydbTimestamp:
+		var s sql.NullTime
+		return &s, nil
+	case ydbString, ydbYson:
+		var r sql.RawBytes
+		return &r, nil
+	default:
+		var s sql.NullString
+		return &s, nil
+	}
+}
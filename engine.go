@@ -15,6 +15,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"xorm.io/xorm/caches"
@@ -32,6 +33,7 @@ import (
 // Commonly, an application only need one engine
 type Engine struct {
 	cacherMgr      *caches.Manager
+	queryCache     *caches.QueryCache
 	defaultContext context.Context
 	dialect        dialects.Dialect
 	driver         dialects.Driver
@@ -47,6 +49,12 @@ type Engine struct {
 	DatabaseTZ *time.Location // The timezone of the database
 
 	logSessionID bool // create session id
+
+	cursorKey []byte // the key to sign the cursors of the keyset pagination
+
+	versionMutex sync.Mutex
+	version      *schemas.Version // the version of the database, queried once
+	versionErr   error            // the error of querying the version, not queried again
 }
 
 // NewEngine new a db manager according to the parameter. Currently support four
@@ -76,11 +84,13 @@ func newEngine(driverName, dataSourceName string, dialect dialects.Dialect, db *
 		TZLocation:     time.Local,
 		defaultContext: context.Background(),
 		cacherMgr:      cacherMgr,
+		queryCache:     caches.NewQueryCache(caches.DefaultQueryCacheBytes),
 		tagParser:      tagParser,
 		driverName:     driverName,
 		dataSourceName: dataSourceName,
 		db:             db,
 		logSessionID:   false,
+		cursorKey:      newCursorKey(),
 	}
 
 	if dialect.URI().DBType == schemas.SQLITE {
@@ -279,6 +289,25 @@ func (engine *Engine) GetDefaultCacher() caches.Cacher {
 	return engine.cacherMgr.GetDefaultCacher()
 }
 
+// SetQueryCache sets the cache of the results of the queries run with
+// CacheFor, it could be shared by the engines
+func (engine *Engine) SetQueryCache(cache *caches.QueryCache) {
+	engine.queryCache = cache
+}
+
+// QueryCache returns the cache of the results of the queries run with
+// CacheFor
+func (engine *Engine) QueryCache() *caches.QueryCache {
+	return engine.queryCache
+}
+
+// CacheFor caches the result of the next query for the ttl
+func (engine *Engine) CacheFor(ttl time.Duration, key ...string) *Session {
+	session := engine.NewSession()
+	session.isAutoClose = true
+	return session.CacheFor(ttl, key...)
+}
+
 // NoCache If you has set default cacher, and you want temporilly stop use cache,
 // you can use NoCache()
 func (engine *Engine) NoCache() *Session {
@@ -374,6 +403,12 @@ func (engine *Engine) loadTableInfo(ctx context.Context, table *schemas.Table) e
 	}
 	table.Indexes = indexes
 
+	fks, err := engine.dialect.GetForeignKeys(engine.db, ctx, table.Name)
+	if err != nil {
+		return err
+	}
+	table.ForeignKeys = fks
+
 	var seq int
 	for _, index := range indexes {
 		for _, name := range index.Cols {
@@ -497,7 +532,11 @@ func (engine *Engine) dumpTables(ctx context.Context, tables []*schemas.Table, w
 		}
 	}
 
-	for i, table := range tables {
+	// the referenced tables are dumped first, and the foreign keys are added
+	// after all the tables are dumped
+	var foreignKeys []pendingForeignKey
+	for n, i := range schemas.SortTablesByForeignKeys(tables) {
+		table := tables[i]
 		dstTable := table
 		if table.Type != nil {
 			dstTable, err = dstTableCache.Parse(reflect.New(table.Type).Elem())
@@ -518,7 +557,7 @@ func (engine *Engine) dumpTables(ctx context.Context, tables []*schemas.Table, w
 		if engine.dialect.URI().Schema != "" {
 			originalTableName = fmt.Sprintf("%s.%s", engine.dialect.URI().Schema, table.Name)
 		}
-		if i > 0 {
+		if n > 0 {
 			_, err = io.WriteString(w, "\n")
 			if err != nil {
 				return err
@@ -540,6 +579,9 @@ func (engine *Engine) dumpTables(ctx context.Context, tables []*schemas.Table, w
 		if err != nil {
 			return err
 		}
+		for _, fk := range sortedForeignKeys(dstTable) {
+			foreignKeys = append(foreignKeys, pendingForeignKey{dstTableName, fk, true})
+		}
 		_, err = io.WriteString(w, sqlstr+";\n")
 		if err != nil {
 			return err
@@ -824,6 +866,14 @@ func (engine *Engine) dumpTables(ctx context.Context, tables []*schemas.Table, w
 		rows.Close()
 		sess.Close()
 	}
+
+	for _, item := range foreignKeys {
+		if sqlstr := dstDialect.CreateForeignKeySQL(item.tableName, item.fk); sqlstr != "" {
+			if _, err := io.WriteString(w, sqlstr+";\n"); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
@@ -899,6 +949,28 @@ func (engine *Engine) Cols(columns ...string) *Session {
 	return session.Cols(columns...)
 }
 
+// Preload loads the relations of the paths for the results of Find or Get
+func (engine *Engine) Preload(paths ...string) *Session {
+	session := engine.NewSession()
+	session.isAutoClose = true
+	return session.Preload(paths...)
+}
+
+// PageAfter retrieves the records after the one of the values of the order
+// by columns
+func (engine *Engine) PageAfter(orderCols []string, lastValues []interface{}) *Session {
+	session := engine.NewSession()
+	session.isAutoClose = true
+	return session.PageAfter(orderCols, lastValues)
+}
+
+// Seek retrieves the records after the cursor returned by FindPage
+func (engine *Engine) Seek(cursor string) *Session {
+	session := engine.NewSession()
+	session.isAutoClose = true
+	return session.Seek(cursor)
+}
+
 // AllCols indicates that all columns should be use
 func (engine *Engine) AllCols() *Session {
 	session := engine.NewSession()
@@ -1052,6 +1124,20 @@ func (engine *Engine) DBVersion() (*schemas.Version, error) {
 	return engine.dialect.Version(engine.defaultContext, engine.db)
 }
 
+// serverVersion returns the version of the database, which is queried once.
+// It's nil if the version can't be queried.
+func (engine *Engine) serverVersion() *schemas.Version {
+	engine.versionMutex.Lock()
+	defer engine.versionMutex.Unlock()
+	if engine.version == nil && engine.versionErr == nil {
+		engine.version, engine.versionErr = engine.DBVersion()
+		if engine.versionErr != nil {
+			engine.logger.Warnf("query the version of the database failed: %v", engine.versionErr)
+		}
+	}
+	return engine.version
+}
+
 // TableInfo get table info according to bean's content
 func (engine *Engine) TableInfo(bean interface{}) (*schemas.Table, error) {
 	v := utils.ReflectValue(bean)
@@ -1202,6 +1288,49 @@ func (engine *Engine) Insert(beans ...interface{}) (int64, error) {
 	return session.Insert(beans...)
 }
 
+// UpdateMulti updates the beans of the slice by their primary keys in batches
+func (engine *Engine) UpdateMulti(rowsSlicePtr interface{}) (int64, error) {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.UpdateMulti(rowsSlicePtr)
+}
+
+// DeleteMulti deletes the beans of the slice by their primary keys in batches
+func (engine *Engine) DeleteMulti(rowsSlicePtr interface{}) (int64, error) {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.DeleteMulti(rowsSlicePtr)
+}
+
+// Upsert inserts the beans or updates the existing rows which conflict with them
+func (engine *Engine) Upsert(beans ...interface{}) (int64, error) {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.Upsert(beans...)
+}
+
+// OnConflict makes the insert update the existing row when it conflicts on
+// the unique key of the columns
+func (engine *Engine) OnConflict(cols ...string) *Session {
+	session := engine.NewSession()
+	session.isAutoClose = true
+	return session.OnConflict(cols...)
+}
+
+// Returning makes the changed rows returned into the beans
+func (engine *Engine) Returning(cols ...string) *Session {
+	session := engine.NewSession()
+	session.isAutoClose = true
+	return session.Returning(cols...)
+}
+
+// InsertMulti inserts the beans of the slice in batches
+func (engine *Engine) InsertMulti(rowsSlicePtr interface{}) (int64, error) {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.InsertMulti(rowsSlicePtr)
+}
+
 // InsertOne insert only one record
 func (engine *Engine) InsertOne(bean interface{}) (int64, error) {
 	session := engine.NewSession()
@@ -1253,6 +1382,20 @@ func (engine *Engine) Exist(bean ...interface{}) (bool, error) {
 	return session.Exist(bean...)
 }
 
+// Explain returns the plan of the query which Get would run for the bean
+func (engine *Engine) Explain(beans ...interface{}) (*QueryPlan, error) {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.Explain(beans...)
+}
+
+// ExplainFind returns the plan of the query which Find would run
+func (engine *Engine) ExplainFind(rowsSlicePtr interface{}, condiBean ...interface{}) (*QueryPlan, error) {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.ExplainFind(rowsSlicePtr, condiBean...)
+}
+
 // Find retrieve records from table, condiBeans's non-empty fields
 // are conditions. beans could be []Struct, []*Struct, map[int64]Struct
 // map[int64]*Struct
@@ -1262,6 +1405,14 @@ func (engine *Engine) Find(beans interface{}, condiBeans ...interface{}) error {
 	return session.Find(beans, condiBeans...)
 }
 
+// FindPage retrieves a page of the records and returns the cursor of the
+// next page
+func (engine *Engine) FindPage(rowsSlicePtr interface{}, condiBean ...interface{}) (string, error) {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.FindPage(rowsSlicePtr, condiBean...)
+}
+
 // FindAndCount find the results and also return the counts
 func (engine *Engine) FindAndCount(rowsSlicePtr interface{}, condiBean ...interface{}) (int64, error) {
 	session := engine.NewSession()
@@ -1333,6 +1484,21 @@ func (engine *Engine) Import(r io.Reader) ([]sql.Result, error) {
 	return session.Import(r)
 }
 
+// Export streams the records of the query into w by the format
+func (engine *Engine) Export(w io.Writer, format ExportFormat, sqlOrArgs ...interface{}) error {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.Export(w, format, sqlOrArgs...)
+}
+
+// BulkLoad loads the records of a slice of the structs, a *Rows or a
+// BulkSource into the table by the native bulk loading of the driver
+func (engine *Engine) BulkLoad(source interface{}) (int64, error) {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.BulkLoad(source)
+}
+
 // nowTime return current time
 func (engine *Engine) nowTime(col *schemas.Column) (interface{}, time.Time, error) {
 	t := time.Now()
@@ -1373,6 +1539,13 @@ func (engine *Engine) SetTZDatabase(tz *time.Location) {
 	engine.DatabaseTZ = tz
 }
 
+// SetCursorKey sets the key to sign the cursors of FindPage, the engines
+// which share the cursors should have the same key. A random key is used by
+// default, so the cursors are invalid after the engine is restarted.
+func (engine *Engine) SetCursorKey(key []byte) {
+	engine.cursorKey = key
+}
+
 // SetSchema sets the schema of database
 func (engine *Engine) SetSchema(schema string) {
 	engine.dialect.URI().SetSchema(schema)
@@ -46,6 +46,7 @@ func NewEngineGroup(args1 interface{}, args2 interface{}, policies ...GroupPolic
 
 		eg.Engine = engines[0]
 		eg.slaves = engines[1:]
+		eg.SetQueryCache(eg.Engine.QueryCache())
 		return &eg, nil
 	}
 
@@ -58,6 +59,7 @@ func NewEngineGroup(args1 interface{}, args2 interface{}, policies ...GroupPolic
 		}
 		eg.Engine = master
 		eg.slaves = slaves
+		eg.SetQueryCache(master.QueryCache())
 		return &eg, nil
 	}
 	return nil, ErrParamsType
@@ -136,6 +138,15 @@ func (eg *EngineGroup) SetDefaultCacher(cacher caches.Cacher) {
 	}
 }
 
+// SetQueryCache sets the cache of the results of the queries run with
+// CacheFor, it's shared by the master and the slaves
+func (eg *EngineGroup) SetQueryCache(cache *caches.QueryCache) {
+	eg.Engine.SetQueryCache(cache)
+	for i := 0; i < len(eg.slaves); i++ {
+		eg.slaves[i].SetQueryCache(cache)
+	}
+}
+
 // SetLogger set the new logger
 func (eg *EngineGroup) SetLogger(logger interface{}) {
 	eg.Engine.SetLogger(logger)
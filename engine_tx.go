@@ -0,0 +1,123 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// DefaultTxMaxRetries is how many times TransactionContext retries a
+// transaction by default
+const DefaultTxMaxRetries = 3
+
+type txOptions struct {
+	sql.TxOptions
+	maxRetries int
+	backoff    func(retry int) time.Duration
+}
+
+// TxOption represents an option of TransactionContext
+type TxOption func(*txOptions)
+
+// TxIsolationLevel sets the isolation level of the transaction
+func TxIsolationLevel(level sql.IsolationLevel) TxOption {
+	return func(opts *txOptions) {
+		opts.Isolation = level
+	}
+}
+
+// TxReadOnly makes the transaction read only
+func TxReadOnly() TxOption {
+	return func(opts *txOptions) {
+		opts.ReadOnly = true
+	}
+}
+
+// TxMaxRetries sets how many times the transaction will be retried when it
+// fails with a retryable error, zero disables the retries
+func TxMaxRetries(n int) TxOption {
+	return func(opts *txOptions) {
+		opts.maxRetries = n
+	}
+}
+
+// TxBackoff sets how long to wait before the retry, which starts from 1
+func TxBackoff(backoff func(retry int) time.Duration) TxOption {
+	return func(opts *txOptions) {
+		opts.backoff = backoff
+	}
+}
+
+// defaultTxBackoff doubles the wait from 10ms and caps it at one second
+func defaultTxBackoff(retry int) time.Duration {
+	d := 10 * time.Millisecond << uint(retry-1)
+	if d > time.Second || d <= 0 {
+		return time.Second
+	}
+	return d
+}
+
+// TransactionContext executes f in a transaction, which will be committed if
+// f returns nil and rolled back if f returns an error or panics. When the
+// dialect reports the error as retryable, i.e. a serialization failure or a
+// deadlock, the transaction will be retried with a backoff, so f should not
+// have side effects outside the transaction.
+func (engine *Engine) TransactionContext(ctx context.Context, f func(*Session) error, opts ...TxOption) error {
+	options := txOptions{
+		maxRetries: DefaultTxMaxRetries,
+		backoff:    defaultTxBackoff,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	for retry := 0; ; retry++ {
+		if retry > 0 {
+			timer := time.NewTimer(options.backoff(retry))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		err := engine.transactionOnce(ctx, f, &options.TxOptions)
+		if err == nil || retry >= options.maxRetries || !engine.dialect.IsRetryableError(err) {
+			return err
+		}
+		engine.logger.Warnf("transaction failed with a retryable error, retry %d: %v", retry+1, err)
+	}
+}
+
+func (engine *Engine) transactionOnce(ctx context.Context, f func(*Session) error, opts *sql.TxOptions) error {
+	session := engine.NewSession().Context(ctx)
+	defer session.Close()
+
+	if err := session.beginTx(opts); err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := session.Rollback(); rbErr != nil {
+				engine.logger.Errorf("rollback failed after panic: %v", rbErr)
+			}
+			panic(p)
+		}
+	}()
+
+	if err := f(session); err != nil {
+		if rbErr := session.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w, rollback failed: %v", err, rbErr)
+		}
+		return err
+	}
+
+	return session.Commit()
+}
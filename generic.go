@@ -0,0 +1,196 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"fmt"
+	"strings"
+
+	"xorm.io/xorm/schemas"
+)
+
+// FindT retrieves the records of the session as a slice of T
+func FindT[T any](session *Session) ([]T, error) {
+	var beans []T
+	if err := session.Find(&beans); err != nil {
+		return nil, err
+	}
+	return beans, nil
+}
+
+// GetT retrieves one record of the session as T, the bool is false if no
+// record is found
+func GetT[T any](session *Session) (*T, bool, error) {
+	bean := new(T)
+	has, err := session.Get(bean)
+	if err != nil || !has {
+		return nil, has, err
+	}
+	return bean, true, nil
+}
+
+// IterateT calls fun with the records of the session one by one
+func IterateT[T any](session *Session, fun func(idx int, bean *T) error) error {
+	if err := lastError(session); err != nil {
+		return err
+	}
+
+	rows, err := session.Rows(new(T))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for i := 0; rows.Next(); i++ {
+		bean := new(T)
+		if err := rows.Scan(bean); err != nil {
+			return err
+		}
+		if err := fun(i, bean); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// lastError returns the error of the statement and closes the session if it
+// should be closed, for the methods which do not check the error
+func lastError(session *Session) error {
+	err := session.statement.LastError
+	if err != nil && session.isAutoClose {
+		session.Close()
+	}
+	return err
+}
+
+// Query is a typed query of the table of the struct T, the column names
+// given to its methods are checked against the columns of T, the error of an
+// unknown column is returned by Find, Get, Iterate or Count.
+type Query[T any] struct {
+	session *Session
+	table   *schemas.Table
+}
+
+// NewQuery creates a typed query of T on the session
+func NewQuery[T any](session *Session) *Query[T] {
+	q := &Query[T]{session: session}
+	table, err := session.engine.TableInfo(new(T))
+	if err != nil {
+		q.setError(err)
+		return q
+	}
+	q.table = table
+	return q
+}
+
+// Session returns the session of the query to use the untyped methods
+func (q *Query[T]) Session() *Session {
+	return q.session
+}
+
+func (q *Query[T]) setError(err error) {
+	if q.session.statement.LastError == nil {
+		q.session.statement.LastError = err
+	}
+}
+
+// checkColumns returns false and records the error if any of the columns
+// does not exist in T
+func (q *Query[T]) checkColumns(columns ...string) bool {
+	if q.table == nil {
+		return false
+	}
+	for _, column := range columns {
+		if q.table.GetColumn(column) == nil {
+			q.setError(fmt.Errorf("%s has no column %s", q.table.Type.Name(), column))
+			return false
+		}
+	}
+	return true
+}
+
+// Cols selects or updates only the columns
+func (q *Query[T]) Cols(columns ...string) *Query[T] {
+	if q.checkColumns(columns...) {
+		q.session.Cols(columns...)
+	}
+	return q
+}
+
+// Omit excludes the columns
+func (q *Query[T]) Omit(columns ...string) *Query[T] {
+	if q.checkColumns(columns...) {
+		q.session.Omit(columns...)
+	}
+	return q
+}
+
+// Where adds the condition of the column compared with the arg by the
+// operator, which is one of =, <>, !=, <, <=, >, >=, LIKE and NOT LIKE
+func (q *Query[T]) Where(column, op string, arg interface{}) *Query[T] {
+	if !q.checkColumns(column) {
+		return q
+	}
+	switch op = strings.ToUpper(strings.TrimSpace(op)); op {
+	case "=", "<>", "!=", "<", "<=", ">", ">=", "LIKE", "NOT LIKE":
+		q.session.And(fmt.Sprintf("%s %s ?", q.session.engine.Quote(column), op), arg)
+	default:
+		q.setError(fmt.Errorf("unsupported operator %s", op))
+	}
+	return q
+}
+
+// In adds the condition of the column in the args
+func (q *Query[T]) In(column string, args ...interface{}) *Query[T] {
+	if q.checkColumns(column) {
+		q.session.In(column, args...)
+	}
+	return q
+}
+
+// Asc orders by the columns ascending
+func (q *Query[T]) Asc(columns ...string) *Query[T] {
+	if q.checkColumns(columns...) {
+		q.session.Asc(columns...)
+	}
+	return q
+}
+
+// Desc orders by the columns descending
+func (q *Query[T]) Desc(columns ...string) *Query[T] {
+	if q.checkColumns(columns...) {
+		q.session.Desc(columns...)
+	}
+	return q
+}
+
+// Limit limits the number of the records and the offset
+func (q *Query[T]) Limit(limit int, start ...int) *Query[T] {
+	q.session.Limit(limit, start...)
+	return q
+}
+
+// Find retrieves the records as a slice of T
+func (q *Query[T]) Find() ([]T, error) {
+	return FindT[T](q.session)
+}
+
+// Get retrieves one record as T
+func (q *Query[T]) Get() (*T, bool, error) {
+	return GetT[T](q.session)
+}
+
+// Iterate calls fun with the records one by one
+func (q *Query[T]) Iterate(fun func(idx int, bean *T) error) error {
+	return IterateT(q.session, fun)
+}
+
+// Count counts the records
+func (q *Query[T]) Count() (int64, error) {
+	if err := lastError(q.session); err != nil {
+		return 0, err
+	}
+	return q.session.Count(new(T))
+}
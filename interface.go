@@ -24,6 +24,8 @@ type Interface interface {
 	Alias(alias string) *Session
 	Asc(colNames ...string) *Session
 	BufferSize(size int) *Session
+	BulkLoad(source interface{}) (int64, error)
+	CacheFor(ttl time.Duration, key ...string) *Session
 	Cols(columns ...string) *Session
 	Count(...interface{}) (int64, error)
 	CreateIndexes(bean interface{}) error
@@ -31,19 +33,24 @@ type Interface interface {
 	Decr(column string, arg ...interface{}) *Session
 	Desc(...string) *Session
 	Delete(...interface{}) (int64, error)
+	DeleteMulti(rowsSlicePtr interface{}) (int64, error)
 	Truncate(...interface{}) (int64, error)
 	Distinct(columns ...string) *Session
 	DropIndexes(bean interface{}) error
 	Exec(sqlOrArgs ...interface{}) (sql.Result, error)
 	Exist(bean ...interface{}) (bool, error)
+	Explain(beans ...interface{}) (*QueryPlan, error)
+	ExplainFind(rowsSlicePtr interface{}, condiBean ...interface{}) (*QueryPlan, error)
 	Find(interface{}, ...interface{}) error
 	FindAndCount(interface{}, ...interface{}) (int64, error)
+	FindPage(interface{}, ...interface{}) (string, error)
 	Get(...interface{}) (bool, error)
 	GroupBy(keys string) *Session
 	ID(interface{}) *Session
 	In(string, ...interface{}) *Session
 	Incr(column string, arg ...interface{}) *Session
 	Insert(...interface{}) (int64, error)
+	InsertMulti(rowsSlicePtr interface{}) (int64, error)
 	InsertOne(interface{}) (int64, error)
 	IsTableEmpty(bean interface{}) (bool, error)
 	IsTableExist(beanOrTableName interface{}) (bool, error)
@@ -52,15 +59,20 @@ type Interface interface {
 	MustCols(columns ...string) *Session
 	NoAutoCondition(...bool) *Session
 	NotIn(string, ...interface{}) *Session
+	OnConflict(cols ...string) *Session
 	Nullable(...string) *Session
 	Join(joinOperator string, tablename interface{}, condition interface{}, args ...interface{}) *Session
 	Omit(columns ...string) *Session
 	OrderBy(order interface{}, args ...interface{}) *Session
+	PageAfter(orderCols []string, lastValues []interface{}) *Session
 	Ping() error
+	Preload(paths ...string) *Session
 	Query(sqlOrArgs ...interface{}) (resultsSlice []map[string][]byte, err error)
 	QueryInterface(sqlOrArgs ...interface{}) ([]map[string]interface{}, error)
 	QueryString(sqlOrArgs ...interface{}) ([]map[string]string, error)
+	Returning(cols ...string) *Session
 	Rows(bean interface{}) (*Rows, error)
+	Seek(cursor string) *Session
 	SetExpr(string, interface{}) *Session
 	Select(string) *Session
 	SQL(interface{}, ...interface{}) *Session
@@ -71,6 +83,8 @@ type Interface interface {
 	Table(tableNameOrBean interface{}) *Session
 	Unscoped() *Session
 	Update(bean interface{}, condiBeans ...interface{}) (int64, error)
+	UpdateMulti(rowsSlicePtr interface{}) (int64, error)
+	Upsert(...interface{}) (int64, error)
 	UseBool(...string) *Session
 	Where(interface{}, ...interface{}) *Session
 }
@@ -101,6 +115,7 @@ type EngineInterface interface {
 	NewSession() *Session
 	NoAutoTime() *Session
 	Prepare() *Session
+	QueryCache() *caches.QueryCache
 	Quote(string) string
 	SetCacher(string, caches.Cacher)
 	SetConnMaxLifetime(time.Duration)
@@ -112,6 +127,7 @@ type EngineInterface interface {
 	SetMapper(names.Mapper)
 	SetMaxOpenConns(int)
 	SetMaxIdleConns(int)
+	SetQueryCache(*caches.QueryCache)
 	SetQuotePolicy(dialects.QuotePolicy)
 	SetSchema(string)
 	SetTableMapper(names.Mapper)
@@ -0,0 +1,353 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statements
+
+import (
+	"fmt"
+	"strings"
+
+	"xorm.io/builder"
+	"xorm.io/xorm/schemas"
+)
+
+// BatchRow represents a row updated or deleted by a batch
+type BatchRow struct {
+	PK      schemas.PK
+	Version interface{}   // the expected version when the version is checked
+	Values  []interface{} // the values of the updated columns
+}
+
+// CheckBatchVersion returns true if the batch checks the version of the rows
+func (statement *Statement) CheckBatchVersion() bool {
+	return statement.RefTable.Version != "" && statement.CheckVersion
+}
+
+// batchConds returns the conditions shared by all the rows of the batch
+func (statement *Statement) batchConds() builder.Cond {
+	cond := statement.cond
+	if col := statement.RefTable.DeletedColumn(); col != nil && !statement.unscoped {
+		cond = cond.And(statement.CondDeleted(col))
+	}
+	return cond
+}
+
+// BatchFixedParams returns how many parameters of a batch are not from the rows
+func (statement *Statement) BatchFixedParams() (int, error) {
+	_, args, err := builder.ToSQL(statement.batchConds())
+	if err != nil {
+		return 0, err
+	}
+	return len(args), nil
+}
+
+// UpdateMultiRowParams returns how many parameters a row of the batch update
+// takes
+func (statement *Statement) UpdateMultiRowParams(numCols int) int {
+	numPKs := len(statement.RefTable.PrimaryKeys)
+	n := numPKs
+	if statement.CheckBatchVersion() {
+		n++
+	}
+	if statement.dialect.URI().DBType == schemas.POSTGRES {
+		return n + numCols
+	}
+	return n + numCols*(numPKs+1)
+}
+
+// DeleteMultiRowParams returns how many parameters a row of the batch delete
+// takes
+func (statement *Statement) DeleteMultiRowParams() int {
+	if statement.CheckBatchVersion() {
+		return len(statement.RefTable.PrimaryKeys) + 1
+	}
+	return len(statement.RefTable.PrimaryKeys)
+}
+
+// batchRowsCond returns the condition matching the rows by the primary key
+// and the version
+func (statement *Statement) batchRowsCond(rows []BatchRow) builder.Cond {
+	var (
+		table        = statement.RefTable
+		checkVersion = statement.CheckBatchVersion()
+	)
+	if len(table.PrimaryKeys) == 1 && !checkVersion {
+		ids := make([]interface{}, 0, len(rows))
+		for _, row := range rows {
+			ids = append(ids, row.PK[0])
+		}
+		return builder.In(statement.quote(table.PrimaryKeys[0]), ids...)
+	}
+
+	cond := builder.NewCond()
+	for _, row := range rows {
+		eq := builder.Eq{}
+		for i, pk := range table.PrimaryKeys {
+			eq[statement.quote(pk)] = row.PK[i]
+		}
+		if checkVersion {
+			eq[statement.quote(table.Version)] = row.Version
+		}
+		cond = cond.Or(eq)
+	}
+	return cond
+}
+
+// writeBatchWhere writes the WHERE clause of a batch
+func (statement *Statement) writeBatchWhere(w *builder.BytesWriter, rows []BatchRow) error {
+	if _, err := w.WriteString(" WHERE "); err != nil {
+		return err
+	}
+	cond := statement.batchRowsCond(rows)
+	if conds := statement.batchConds(); conds.IsValid() {
+		cond = builder.And(cond, conds)
+	}
+	return cond.WriteTo(w)
+}
+
+// writeBatchSets writes the assignments shared by all the rows
+func (statement *Statement) writeBatchSets(w *builder.BytesWriter, commonCols []string, commonArgs []interface{}, target string) error {
+	for i, col := range commonCols {
+		if _, err := fmt.Fprintf(w, ", %s = ?", statement.quote(col)); err != nil {
+			return err
+		}
+		w.Append(commonArgs[i])
+	}
+	if statement.CheckBatchVersion() {
+		version := statement.quote(statement.RefTable.Version)
+		if _, err := fmt.Fprintf(w, ", %s = %s%s + 1", version, target, version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GenUpdateMultiSQL generates the SQL updating the rows by their primary keys,
+// each row with its own values of the columns, and the common columns with the
+// same values. A CASE expression on the primary key picks the value of a row
+// except on PostgreSQL, which joins the table with the VALUES of the rows.
+func (statement *Statement) GenUpdateMultiSQL(colNames []string, commonCols []string, commonArgs []interface{}, rows []BatchRow) (string, []interface{}, error) {
+	if len(colNames) == 0 {
+		return "", nil, ErrNoColumnsTobeUpdated
+	}
+	if len(statement.RefTable.PrimaryKeys) == 0 {
+		return "", nil, fmt.Errorf("table %s has no primary key to update by", statement.TableName())
+	}
+
+	if statement.dialect.URI().DBType == schemas.POSTGRES {
+		return statement.genUpdateFromValuesSQL(colNames, commonCols, commonArgs, rows)
+	}
+
+	var (
+		buf   = builder.NewWriter()
+		table = statement.RefTable
+	)
+	if _, err := fmt.Fprintf(buf, "UPDATE %s SET ", statement.quote(statement.TableName())); err != nil {
+		return "", nil, err
+	}
+	for i, col := range colNames {
+		if i > 0 {
+			if _, err := buf.WriteString(", "); err != nil {
+				return "", nil, err
+			}
+		}
+		if _, err := fmt.Fprintf(buf, "%s = CASE", statement.quote(col)); err != nil {
+			return "", nil, err
+		}
+		if len(table.PrimaryKeys) == 1 {
+			if _, err := fmt.Fprintf(buf, " %s", statement.quote(table.PrimaryKeys[0])); err != nil {
+				return "", nil, err
+			}
+		}
+		for _, row := range rows {
+			if len(table.PrimaryKeys) == 1 {
+				if _, err := buf.WriteString(" WHEN ? THEN ?"); err != nil {
+					return "", nil, err
+				}
+				buf.Append(row.PK[0], row.Values[i])
+				continue
+			}
+
+			if _, err := buf.WriteString(" WHEN "); err != nil {
+				return "", nil, err
+			}
+			for j, pk := range table.PrimaryKeys {
+				if j > 0 {
+					if _, err := buf.WriteString(" AND "); err != nil {
+						return "", nil, err
+					}
+				}
+				if _, err := fmt.Fprintf(buf, "%s = ?", statement.quote(pk)); err != nil {
+					return "", nil, err
+				}
+				buf.Append(row.PK[j])
+			}
+			if _, err := buf.WriteString(" THEN ?"); err != nil {
+				return "", nil, err
+			}
+			buf.Append(row.Values[i])
+		}
+		if _, err := buf.WriteString(" END"); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if err := statement.writeBatchSets(buf, commonCols, commonArgs, ""); err != nil {
+		return "", nil, err
+	}
+	if err := statement.writeBatchWhere(buf, rows); err != nil {
+		return "", nil, err
+	}
+	return buf.String(), buf.Args(), nil
+}
+
+// genUpdateFromValuesSQL generates UPDATE ... FROM (VALUES ...) on PostgreSQL.
+// The columns of the values are renamed so that they will not be ambiguous
+// with the ones of the table in the conditions.
+func (statement *Statement) genUpdateFromValuesSQL(colNames []string, commonCols []string, commonArgs []interface{}, rows []BatchRow) (string, []interface{}, error) {
+	var (
+		buf          = builder.NewWriter()
+		table        = statement.RefTable
+		tableName    = statement.quote(statement.TableName())
+		checkVersion = statement.CheckBatchVersion()
+		valueCols    []*schemas.Column
+	)
+
+	if _, err := fmt.Fprintf(buf, "UPDATE %s SET ", tableName); err != nil {
+		return "", nil, err
+	}
+	for i, col := range colNames {
+		if i > 0 {
+			if _, err := buf.WriteString(", "); err != nil {
+				return "", nil, err
+			}
+		}
+		if _, err := fmt.Fprintf(buf, "%s = v.c%d", statement.quote(col), i); err != nil {
+			return "", nil, err
+		}
+	}
+	if err := statement.writeBatchSets(buf, commonCols, commonArgs, tableName+"."); err != nil {
+		return "", nil, err
+	}
+
+	for _, pk := range table.PrimaryKeys {
+		valueCols = append(valueCols, table.GetColumn(pk))
+	}
+	for _, col := range colNames {
+		valueCols = append(valueCols, table.GetColumn(col))
+	}
+	if checkVersion {
+		valueCols = append(valueCols, table.VersionColumn())
+	}
+
+	if _, err := buf.WriteString(" FROM (VALUES "); err != nil {
+		return "", nil, err
+	}
+	for i, row := range rows {
+		if i > 0 {
+			if _, err := buf.WriteString(", "); err != nil {
+				return "", nil, err
+			}
+		}
+		args := make([]interface{}, 0, len(valueCols))
+		args = append(args, row.PK...)
+		args = append(args, row.Values...)
+		if checkVersion {
+			args = append(args, row.Version)
+		}
+		if _, err := buf.WriteString("("); err != nil {
+			return "", nil, err
+		}
+		for j, col := range valueCols {
+			if j > 0 {
+				if _, err := buf.WriteString(","); err != nil {
+					return "", nil, err
+				}
+			}
+			// the parameters of VALUES are typed as text unless casted
+			if _, err := fmt.Fprintf(buf, "CAST(? AS %s)", statement.batchColumnType(col)); err != nil {
+				return "", nil, err
+			}
+			buf.Append(args[j])
+		}
+		if _, err := buf.WriteString(")"); err != nil {
+			return "", nil, err
+		}
+	}
+
+	aliases := make([]string, 0, len(valueCols))
+	for i := range table.PrimaryKeys {
+		aliases = append(aliases, fmt.Sprintf("k%d", i))
+	}
+	for i := range colNames {
+		aliases = append(aliases, fmt.Sprintf("c%d", i))
+	}
+	if checkVersion {
+		aliases = append(aliases, "ver")
+	}
+	if _, err := fmt.Fprintf(buf, ") AS v (%s) WHERE ", strings.Join(aliases, ",")); err != nil {
+		return "", nil, err
+	}
+
+	for i, pk := range table.PrimaryKeys {
+		if i > 0 {
+			if _, err := buf.WriteString(" AND "); err != nil {
+				return "", nil, err
+			}
+		}
+		if _, err := fmt.Fprintf(buf, "%s.%s = v.k%d", tableName, statement.quote(pk), i); err != nil {
+			return "", nil, err
+		}
+	}
+	if checkVersion {
+		if _, err := fmt.Fprintf(buf, " AND %s.%s = v.ver", tableName, statement.quote(table.Version)); err != nil {
+			return "", nil, err
+		}
+	}
+	if conds := statement.batchConds(); conds.IsValid() {
+		if _, err := buf.WriteString(" AND "); err != nil {
+			return "", nil, err
+		}
+		if err := conds.WriteTo(buf); err != nil {
+			return "", nil, err
+		}
+	}
+	return buf.String(), buf.Args(), nil
+}
+
+// batchColumnType returns the type to cast the parameters of the column to
+func (statement *Statement) batchColumnType(col *schemas.Column) string {
+	// serial types are only for the column definitions
+	c := *col
+	c.IsAutoIncrement = false
+	return statement.dialect.SQLType(&c)
+}
+
+// GenDeleteMultiSQL generates the SQL deleting the rows by their primary keys.
+// The rows are marked as deleted if the table has a deleted column, which will
+// be set to deletedArg.
+func (statement *Statement) GenDeleteMultiSQL(rows []BatchRow, deletedArg interface{}) (string, []interface{}, error) {
+	if len(statement.RefTable.PrimaryKeys) == 0 {
+		return "", nil, fmt.Errorf("table %s has no primary key to delete by", statement.TableName())
+	}
+
+	var (
+		buf       = builder.NewWriter()
+		tableName = statement.quote(statement.TableName())
+	)
+	if col := statement.RefTable.DeletedColumn(); col != nil && !statement.unscoped {
+		if _, err := fmt.Fprintf(buf, "UPDATE %s SET %s = ?", tableName, statement.quote(col.Name)); err != nil {
+			return "", nil, err
+		}
+		buf.Append(deletedArg)
+	} else {
+		if _, err := fmt.Fprintf(buf, "DELETE FROM %s", tableName); err != nil {
+			return "", nil, err
+		}
+	}
+	if err := statement.writeBatchWhere(buf, rows); err != nil {
+		return "", nil, err
+	}
+	return buf.String(), buf.Args(), nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statements
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"xorm.io/xorm/caches"
+	"xorm.io/xorm/dialects"
+	"xorm.io/xorm/names"
+	"xorm.io/xorm/tags"
+)
+
+type BatchUser struct {
+	Id      int64
+	Name    string
+	Version int `xorm:"version"`
+}
+
+func TestGenUpdateMultiSQL(t *testing.T) {
+	rows := []BatchRow{
+		{PK: []interface{}{int64(1)}, Version: 1, Values: []interface{}{"a"}},
+		{PK: []interface{}{int64(2)}, Version: 3, Values: []interface{}{"b"}},
+	}
+
+	kases := []struct {
+		driver   string
+		dsn      string
+		expected string
+		args     []interface{}
+	}{
+		{
+			"mysql", "root:@tcp(localhost:3306)/test",
+			"UPDATE `batch_user` SET `name` = CASE `id` WHEN ? THEN ? WHEN ? THEN ? END, `version` = `version` + 1 " +
+				"WHERE (`id`=? AND `version`=?) OR (`id`=? AND `version`=?)",
+			[]interface{}{int64(1), "a", int64(2), "b", int64(1), 1, int64(2), 3},
+		},
+		{
+			"postgres", "postgres://postgres:@localhost:5432/test?sslmode=disable",
+			`UPDATE "batch_user" SET "name" = v.c0, "version" = "batch_user"."version" + 1 ` +
+				`FROM (VALUES (CAST(? AS BIGINT),CAST(? AS VARCHAR(255)),CAST(? AS INTEGER)), ` +
+				`(CAST(? AS BIGINT),CAST(? AS VARCHAR(255)),CAST(? AS INTEGER))) AS v (k0,c0,ver) ` +
+				`WHERE "batch_user"."id" = v.k0 AND "batch_user"."version" = v.ver`,
+			[]interface{}{int64(1), "a", 1, int64(2), "b", 3},
+		},
+	}
+
+	for _, kase := range kases {
+		t.Run(kase.driver, func(t *testing.T) {
+			dialect, err := dialects.OpenDialect(kase.driver, kase.dsn)
+			assert.NoError(t, err)
+			parser := tags.NewParser("xorm", dialect, names.SnakeMapper{}, names.SnakeMapper{}, caches.NewManager())
+			statement := NewStatement(dialect, parser, time.Local)
+			assert.NoError(t, statement.SetRefBean(new(BatchUser)))
+
+			sql, args, err := statement.GenUpdateMultiSQL([]string{"name"}, nil, nil, rows)
+			assert.NoError(t, err)
+			assert.EqualValues(t, kase.expected, sql)
+			assert.EqualValues(t, kase.args, args)
+		})
+	}
+}
+
+func TestGenDeleteMultiSQL(t *testing.T) {
+	statement, err := createTestStatement()
+	assert.NoError(t, err)
+
+	sql, args, err := statement.GenDeleteMultiSQL([]BatchRow{
+		{PK: []interface{}{int64(1)}},
+		{PK: []interface{}{int64(2)}},
+	}, nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "DELETE FROM `TestTable` WHERE `ID` IN (?,?)", sql)
+	assert.EqualValues(t, []interface{}{int64(1), int64(2)}, args)
+}
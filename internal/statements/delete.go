@@ -88,6 +88,11 @@ func (statement *Statement) WriteDelete(realSQLWriter, deleteSQLWriter *builder.
 	tableNameNoQuote := statement.TableName()
 	tableName := statement.dialect.Quoter().Quote(tableNameNoQuote)
 	table := statement.RefTable
+	// a soft delete is an UPDATE
+	softDelete := !statement.GetUnscoped() && table != nil && table.DeletedColumn() != nil
+	if err := statement.checkReturning(softDelete); err != nil {
+		return err
+	}
 	if _, err := fmt.Fprint(deleteSQLWriter, "DELETE FROM ", tableName); err != nil {
 		return err
 	}
@@ -100,8 +105,24 @@ func (statement *Statement) WriteDelete(realSQLWriter, deleteSQLWriter *builder.
 		return err
 	}
 
-	if statement.GetUnscoped() || table == nil || table.DeletedColumn() == nil { // tag "deleted" is disabled
-		return utils.WriteBuilder(realSQLWriter, deleteSQLWriter, orderCondWriter)
+	if !softDelete { // tag "deleted" is disabled
+		if !statement.IsReturning() {
+			return utils.WriteBuilder(realSQLWriter, deleteSQLWriter, orderCondWriter)
+		}
+
+		if _, err := fmt.Fprint(realSQLWriter, "DELETE FROM ", tableName); err != nil {
+			return err
+		}
+		if err := statement.writeOutput(realSQLWriter.Builder, "deleted"); err != nil {
+			return err
+		}
+		if err := statement.writeWhere(realSQLWriter); err != nil {
+			return err
+		}
+		if err := utils.WriteBuilder(realSQLWriter, orderCondWriter); err != nil {
+			return err
+		}
+		return statement.writeReturning(realSQLWriter.Builder)
 	}
 
 	deletedColumn := table.DeletedColumn()
@@ -117,9 +138,15 @@ func (statement *Statement) WriteDelete(realSQLWriter, deleteSQLWriter *builder.
 	}
 	realSQLWriter.Append(val)
 
+	if err := statement.writeOutput(realSQLWriter.Builder, "inserted"); err != nil {
+		return err
+	}
 	if err := statement.writeWhere(realSQLWriter); err != nil {
 		return err
 	}
+	if err := utils.WriteBuilder(realSQLWriter, orderCondWriter); err != nil {
+		return err
+	}
 
-	return utils.WriteBuilder(realSQLWriter, orderCondWriter)
+	return statement.writeReturning(realSQLWriter.Builder)
 }
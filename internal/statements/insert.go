@@ -15,6 +15,9 @@ import (
 )
 
 func (statement *Statement) writeInsertOutput(buf *strings.Builder, table *schemas.Table) error {
+	if statement.IsReturning() {
+		return statement.writeOutput(buf, "inserted")
+	}
 	if statement.dialect.URI().DBType == schemas.MSSQL && len(table.AutoIncrement) > 0 {
 		if _, err := buf.WriteString(" OUTPUT Inserted."); err != nil {
 			return err
@@ -35,6 +38,10 @@ func (statement *Statement) GenInsertSQL(colNames []string, args []interface{})
 		tableName = statement.TableName()
 	)
 
+	if err := statement.checkReturning(false); err != nil {
+		return "", nil, err
+	}
+
 	if _, err := buf.WriteString("INSERT INTO "); err != nil {
 		return "", nil, err
 	}
@@ -164,7 +171,11 @@ func (statement *Statement) GenInsertSQL(colNames []string, args []interface{})
 		}
 	}
 
-	if len(table.AutoIncrement) > 0 && statement.dialect.URI().DBType == schemas.POSTGRES {
+	if statement.IsReturning() {
+		if err := statement.writeReturning(buf.Builder); err != nil {
+			return "", nil, err
+		}
+	} else if len(table.AutoIncrement) > 0 && statement.dialect.URI().DBType == schemas.POSTGRES {
 		if _, err := buf.WriteString(" RETURNING "); err != nil {
 			return "", nil, err
 		}
@@ -354,7 +365,41 @@ func (statement *Statement) oracleWriteInsertMultiple(w *builder.BytesWriter, ta
 	return nil
 }
 
+// ReturnsInOrder returns true if the rows returned by a multiple insert are
+// in the order of the inserted rows. The rows returned by SQLite and the
+// OUTPUT of MSSQL are in an arbitrary order.
+func (statement *Statement) ReturnsInOrder() bool {
+	switch statement.dialect.URI().DBType {
+	case schemas.POSTGRES, schemas.MYSQL:
+		return true
+	}
+	return false
+}
+
+// InsertMultipleReturnsID returns true if the multiple insert of the rows
+// returns the generated auto increment ids as rows in the order of the rows,
+// so the ids of SQLite and MSSQL are only returned for one row.
+func (statement *Statement) InsertMultipleReturnsID(colNames []string, rows int) bool {
+	table := statement.RefTable
+	if table == nil || len(table.AutoIncrement) == 0 || containsCol(colNames, table.AutoIncrement) {
+		return false
+	}
+	if rows > 1 && !statement.ReturnsInOrder() {
+		return false
+	}
+	switch statement.dialect.URI().DBType {
+	case schemas.POSTGRES, schemas.MSSQL:
+		return true
+	case schemas.SQLITE:
+		return statement.versionAtLeast("3.35.0")
+	}
+	return false
+}
+
 func (statement *Statement) WriteInsertMultiple(w *builder.BytesWriter, tableName string, colNames []string, colMultiPlaces []string) error {
+	if err := statement.checkReturning(false); err != nil {
+		return err
+	}
 	if statement.dialect.URI().DBType == schemas.ORACLE {
 		return statement.oracleWriteInsertMultiple(w, tableName, colNames, colMultiPlaces)
 	}
@@ -374,7 +419,16 @@ func (statement *Statement) plainWriteInsertMultiple(w *builder.BytesWriter, tab
 	if err := statement.writeColumns(w, colNames); err != nil {
 		return err
 	}
-	if _, err := fmt.Fprint(w, ") VALUES ("); err != nil {
+	if _, err := fmt.Fprint(w, ")"); err != nil {
+		return err
+	}
+	returnsID := statement.InsertMultipleReturnsID(colNames, len(colMultiPlaces))
+	if returnsID || statement.IsReturning() {
+		if err := statement.writeInsertOutput(w.Builder, statement.RefTable); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprint(w, " VALUES ("); err != nil {
 		return err
 	}
 	for i, cols := range colMultiPlaces {
@@ -387,5 +441,16 @@ func (statement *Statement) plainWriteInsertMultiple(w *builder.BytesWriter, tab
 			}
 		}
 	}
+	if statement.IsReturning() {
+		return statement.writeReturning(w.Builder)
+	}
+	if returnsID && statement.dialect.URI().DBType != schemas.MSSQL {
+		if _, err := fmt.Fprint(w, " RETURNING "); err != nil {
+			return err
+		}
+		if err := statement.dialect.Quoter().QuoteTo(w.Builder, statement.RefTable.AutoIncrement); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -0,0 +1,153 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statements
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"xorm.io/builder"
+	"xorm.io/xorm/schemas"
+)
+
+// ErrKeysetOrderBy is returned when the order by list could not be used for
+// keyset pagination
+var ErrKeysetOrderBy = errors.New("keyset pagination needs the order by columns without expressions")
+
+var unquoteReplacer = strings.NewReplacer("`", "", `"`, "", "[", "", "]", "")
+
+// isKeysetColumn returns true if the unquoted name is a column which may be
+// prefixed by the table
+func isKeysetColumn(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if c != '_' && c != '.' && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// KeysetColumns returns the columns of the order by list and whether they are
+// descending, the raw order by strings are parsed like "a DESC, b"
+func (statement *Statement) KeysetColumns() ([]string, []bool, error) {
+	var (
+		cols  []string
+		descs []bool
+	)
+	for _, ob := range statement.orderBy {
+		str, ok := ob.orderStr.(string)
+		if !ok || len(ob.orderArgs) > 0 {
+			return nil, nil, ErrKeysetOrderBy
+		}
+		if ob.direction != "" {
+			cols = append(cols, unquoteReplacer.Replace(str))
+			descs = append(descs, ob.direction == "DESC")
+			continue
+		}
+
+		for _, part := range strings.Split(str, ",") {
+			fields := strings.Fields(part)
+			switch {
+			case len(fields) == 1:
+				descs = append(descs, false)
+			case len(fields) == 2 && strings.EqualFold(fields[1], "ASC"):
+				descs = append(descs, false)
+			case len(fields) == 2 && strings.EqualFold(fields[1], "DESC"):
+				descs = append(descs, true)
+			default:
+				return nil, nil, ErrKeysetOrderBy
+			}
+			col := unquoteReplacer.Replace(fields[0])
+			if !isKeysetColumn(col) {
+				return nil, nil, ErrKeysetOrderBy
+			}
+			cols = append(cols, col)
+		}
+	}
+	return cols, descs, nil
+}
+
+// PageAfter adds the condition to retrieve the records after the one of the
+// values in the order of the columns. The columns are ordered ascending if
+// there is no order by, otherwise they should be the same as the order by
+// columns. The columns should not be null.
+func (statement *Statement) PageAfter(orderCols []string, values []interface{}) *Statement {
+	if len(orderCols) == 0 || len(orderCols) != len(values) {
+		statement.LastError = fmt.Errorf("keyset pagination needs %d values of the columns but got %d", len(orderCols), len(values))
+		return statement
+	}
+	if !statement.HasOrderBy() {
+		statement.Asc(orderCols...)
+	}
+
+	cols, descs, err := statement.KeysetColumns()
+	if err != nil {
+		statement.LastError = err
+		return statement
+	}
+	if len(cols) != len(orderCols) {
+		statement.LastError = fmt.Errorf("keyset pagination columns %v are not the order by columns %v", orderCols, cols)
+		return statement
+	}
+	for i, col := range cols {
+		if !strings.EqualFold(col, unquoteReplacer.Replace(orderCols[i])) {
+			statement.LastError = fmt.Errorf("keyset pagination columns %v are not the order by columns %v", orderCols, cols)
+			return statement
+		}
+	}
+
+	statement.cond = statement.cond.And(statement.keysetCond(cols, descs, values))
+	return statement
+}
+
+// supportRowValues returns true if the database could compare the row values
+// like (a, b) > (?, ?)
+func (statement *Statement) supportRowValues() bool {
+	switch statement.dialect.URI().DBType {
+	case schemas.MYSQL, schemas.POSTGRES, schemas.SQLITE:
+		return true
+	}
+	return false
+}
+
+// keysetCond returns the row values condition if all the columns are in the
+// same direction, otherwise the expanded one (a > ? OR (a = ? AND b > ?))
+func (statement *Statement) keysetCond(cols []string, descs []bool, values []interface{}) builder.Cond {
+	quoter := statement.dialect.Quoter()
+	op := func(desc bool) string {
+		if desc {
+			return "<"
+		}
+		return ">"
+	}
+
+	if len(cols) == 1 {
+		return builder.Expr(fmt.Sprintf("%s %s ?", quoter.Quote(cols[0]), op(descs[0])), values[0])
+	}
+
+	sameDirection := true
+	for _, desc := range descs {
+		sameDirection = sameDirection && desc == descs[0]
+	}
+	if sameDirection && statement.supportRowValues() {
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(cols)), ",")
+		return builder.Expr(fmt.Sprintf("(%s) %s (%s)", quoter.Join(cols, ","), op(descs[0]), placeholders), values...)
+	}
+
+	var cond builder.Cond = builder.NewCond()
+	for i := range cols {
+		and := builder.Expr(fmt.Sprintf("%s %s ?", quoter.Quote(cols[i]), op(descs[i])), values[i])
+		for j := i - 1; j >= 0; j-- {
+			and = builder.And(builder.Expr(fmt.Sprintf("%s = ?", quoter.Quote(cols[j])), values[j]), and)
+		}
+		cond = cond.Or(and)
+	}
+	return cond
+}
@@ -0,0 +1,99 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statements
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"xorm.io/builder"
+	"xorm.io/xorm/caches"
+	"xorm.io/xorm/dialects"
+	"xorm.io/xorm/names"
+	"xorm.io/xorm/tags"
+)
+
+func TestKeysetColumns(t *testing.T) {
+	statement := NewStatement(dialect, tagParser, time.Local)
+	statement.Desc("created").OrderBy("`name`, id desc").Asc("user.code")
+	cols, descs, err := statement.KeysetColumns()
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"created", "name", "id", "user.code"}, cols)
+	assert.EqualValues(t, []bool{true, false, true, false}, descs)
+
+	statement.OrderBy("LENGTH(name) DESC")
+	_, _, err = statement.KeysetColumns()
+	assert.ErrorIs(t, err, ErrKeysetOrderBy)
+
+	statement.ResetOrderBy()
+	statement.OrderBy(builder.Expr("id"))
+	_, _, err = statement.KeysetColumns()
+	assert.ErrorIs(t, err, ErrKeysetOrderBy)
+}
+
+func TestPageAfter(t *testing.T) {
+	kases := []struct {
+		driver   string
+		dsn      string
+		order    func(*Statement)
+		expected string
+	}{
+		{
+			"sqlite3", "./test.db",
+			func(statement *Statement) {},
+			"(`id` > ?)",
+		},
+		{
+			"sqlite3", "./test.db",
+			func(statement *Statement) { statement.Desc("created", "id") },
+			"((`created`,`id`) < (?,?))",
+		},
+		{
+			"sqlite3", "./test.db",
+			func(statement *Statement) { statement.OrderBy("created DESC, id") },
+			"((`created` < ?) OR ((`created` = ?) AND (`id` > ?)))",
+		},
+		{
+			"mssql", "server=localhost;user id=sa;password=yourStrong(!)Password;database=test",
+			func(statement *Statement) { statement.Asc("created", "id") },
+			"(([created] > ?) OR (([created] = ?) AND ([id] > ?)))",
+		},
+	}
+
+	for _, kase := range kases {
+		t.Run(kase.driver, func(t *testing.T) {
+			dialect, err := dialects.OpenDialect(kase.driver, kase.dsn)
+			assert.NoError(t, err)
+			parser := tags.NewParser("xorm", dialect, names.SnakeMapper{}, names.SnakeMapper{}, caches.NewManager())
+			statement := NewStatement(dialect, parser, time.Local)
+			kase.order(statement)
+
+			var cols []string
+			var values []interface{}
+			if statement.HasOrderBy() {
+				cols, values = []string{"created", "id"}, []interface{}{"2023-01-01", 1}
+			} else {
+				cols, values = []string{"id"}, []interface{}{1}
+			}
+			statement.PageAfter(cols, values)
+			assert.NoError(t, statement.LastError)
+
+			sql, args, err := builder.ToSQL(statement.cond)
+			assert.NoError(t, err)
+			assert.EqualValues(t, kase.expected, sql)
+			assert.NotEmpty(t, args)
+		})
+	}
+
+	statement := NewStatement(dialect, tagParser, time.Local)
+	statement.Asc("id")
+	statement.PageAfter([]string{"name"}, []interface{}{"a"})
+	assert.Error(t, statement.LastError)
+
+	statement = NewStatement(dialect, tagParser, time.Local)
+	statement.PageAfter([]string{"id", "name"}, []interface{}{1})
+	assert.Error(t, statement.LastError)
+}
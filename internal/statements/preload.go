@@ -0,0 +1,17 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statements
+
+// Preload adds the paths of the relations to be loaded, the fields of a path
+// are separated by dots like Orders.Items
+func (statement *Statement) Preload(paths ...string) *Statement {
+	statement.preloads = append(statement.preloads, paths...)
+	return statement
+}
+
+// Preloads returns the paths of the relations to be loaded
+func (statement *Statement) Preloads() []string {
+	return statement.preloads
+}
@@ -0,0 +1,116 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statements
+
+import (
+	"errors"
+	"strings"
+
+	"xorm.io/xorm/schemas"
+)
+
+// ErrReturningNotSupported represents an error the database could not return
+// the rows of the statement
+var ErrReturningNotSupported = errors.New("returning the changed rows is not supported by the database")
+
+// Returning makes INSERT, UPDATE and DELETE return the columns of the changed
+// rows. All the columns of the table are returned if no column given.
+func (statement *Statement) Returning(cols ...string) *Statement {
+	statement.returning = append(make([]string, 0, len(cols)), cols...)
+	return statement
+}
+
+// IsReturning returns true if the statement returns the changed rows
+func (statement *Statement) IsReturning() bool {
+	return statement.returning != nil
+}
+
+// ReturningColumns returns the columns returned by the statement
+func (statement *Statement) ReturningColumns() []string {
+	if len(statement.returning) > 0 || statement.RefTable == nil {
+		return statement.returning
+	}
+	cols := make([]string, 0, len(statement.RefTable.ColumnsSeq()))
+	for _, col := range statement.RefTable.Columns() {
+		if col.MapType == schemas.ONLYTODB {
+			continue
+		}
+		cols = append(cols, col.Name)
+	}
+	return cols
+}
+
+// IsReturningColumn returns true if the column is returned by the statement
+func (statement *Statement) IsReturningColumn(colName string) bool {
+	return statement.IsReturning() && containsCol(statement.ReturningColumns(), colName)
+}
+
+// checkReturning returns an error if the database could not return the rows
+// changed by the statement. MySQL has no RETURNING but MariaDB 10.5+ supports
+// it on INSERT and DELETE, and SQLite supports it since 3.35.0, the version of
+// the database is checked for them.
+func (statement *Statement) checkReturning(isUpdate bool) error {
+	if !statement.IsReturning() {
+		return nil
+	}
+	if statement.RefTable == nil && len(statement.returning) == 0 {
+		return errors.New("no columns to be returned")
+	}
+	switch statement.dialect.URI().DBType {
+	case schemas.POSTGRES, schemas.MSSQL:
+		return nil
+	case schemas.SQLITE:
+		if statement.versionAtLeast("3.35.0") {
+			return nil
+		}
+	case schemas.MYSQL:
+		if !isUpdate && statement.isMariaDB() && statement.versionAtLeast("10.5") {
+			return nil
+		}
+	}
+	return ErrReturningNotSupported
+}
+
+// isMariaDB returns true if the MySQL server is known to be MariaDB
+func (statement *Statement) isMariaDB() bool {
+	if statement.serverVersion == nil {
+		return false
+	}
+	version := statement.serverVersion()
+	return version != nil && strings.EqualFold(version.Edition, "MariaDB")
+}
+
+// writeReturning writes the RETURNING clause at the end of the statement
+func (statement *Statement) writeReturning(buf *strings.Builder) error {
+	if !statement.IsReturning() || statement.dialect.URI().DBType == schemas.MSSQL {
+		return nil
+	}
+	if _, err := buf.WriteString(" RETURNING "); err != nil {
+		return err
+	}
+	return statement.dialect.Quoter().JoinWrite(buf, statement.ReturningColumns(), ",")
+}
+
+// writeOutput writes the OUTPUT clause of MSSQL, the source is inserted or
+// deleted
+func (statement *Statement) writeOutput(buf *strings.Builder, source string) error {
+	if !statement.IsReturning() || statement.dialect.URI().DBType != schemas.MSSQL {
+		return nil
+	}
+	if _, err := buf.WriteString(" OUTPUT "); err != nil {
+		return err
+	}
+	for i, col := range statement.ReturningColumns() {
+		if i > 0 {
+			if _, err := buf.WriteString(","); err != nil {
+				return err
+			}
+		}
+		if _, err := buf.WriteString(source + "." + statement.quote(col)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statements
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"xorm.io/builder"
+	"xorm.io/xorm/caches"
+	"xorm.io/xorm/dialects"
+	"xorm.io/xorm/names"
+	"xorm.io/xorm/schemas"
+	"xorm.io/xorm/tags"
+)
+
+type ReturningUser struct {
+	Id      int64
+	Name    string
+	Created time.Time `xorm:"created"`
+}
+
+func newReturningStatement(t *testing.T, driver, dsn string) *Statement {
+	dialect, err := dialects.OpenDialect(driver, dsn)
+	assert.NoError(t, err)
+	parser := tags.NewParser("xorm", dialect, names.SnakeMapper{}, names.SnakeMapper{}, caches.NewManager())
+	statement := NewStatement(dialect, parser, time.Local)
+	assert.NoError(t, statement.SetRefBean(new(ReturningUser)))
+	return statement
+}
+
+func setServerVersion(statement *Statement, number, edition string) {
+	statement.SetServerVersion(func() *schemas.Version {
+		return &schemas.Version{Number: number, Edition: edition}
+	})
+}
+
+func TestGenInsertSQLReturning(t *testing.T) {
+	kases := []struct {
+		driver   string
+		dsn      string
+		expected string
+	}{
+		{
+			"postgres", "postgres://postgres:@localhost:5432/test?sslmode=disable",
+			`INSERT INTO "returning_user" ("name") VALUES (?) RETURNING "id","name","created"`,
+		},
+		{
+			"mssql", "server=localhost;user id=sa;password=yourStrong(!)Password;database=test",
+			"INSERT INTO [returning_user] ([name]) OUTPUT inserted.[id],inserted.[name],inserted.[created] VALUES (?)",
+		},
+		{
+			"mysql", "root:@tcp(localhost:3306)/test",
+			"INSERT INTO `returning_user` (`name`) VALUES (?) RETURNING `id`,`name`,`created`",
+		},
+	}
+
+	for _, kase := range kases {
+		t.Run(kase.driver, func(t *testing.T) {
+			statement := newReturningStatement(t, kase.driver, kase.dsn)
+			setServerVersion(statement, "10.11.2", "MariaDB")
+			statement.Returning()
+
+			sql, _, err := statement.GenInsertSQL([]string{"name"}, []interface{}{"lunny"})
+			assert.NoError(t, err)
+			assert.EqualValues(t, kase.expected, sql)
+		})
+	}
+}
+
+func TestInsertMultipleReturnsID(t *testing.T) {
+	statement := newReturningStatement(t, "sqlite3", "./test.db")
+	assert.False(t, statement.InsertMultipleReturnsID([]string{"name"}, 1))
+
+	setServerVersion(statement, "3.34.1", "sqlite")
+	assert.False(t, statement.InsertMultipleReturnsID([]string{"name"}, 1))
+
+	setServerVersion(statement, "3.40.0", "sqlite")
+	assert.True(t, statement.InsertMultipleReturnsID([]string{"name"}, 1))
+	assert.False(t, statement.InsertMultipleReturnsID([]string{"id", "name"}, 1))
+	// the order of the rows returned by SQLite is arbitrary
+	assert.False(t, statement.InsertMultipleReturnsID([]string{"name"}, 2))
+
+	w := builder.NewWriter()
+	assert.NoError(t, statement.WriteInsertMultiple(w, "returning_user", []string{"name"}, []string{"?"}))
+	assert.EqualValues(t, "INSERT INTO `returning_user` (`name`) VALUES (?) RETURNING `id`", w.String())
+	w = builder.NewWriter()
+	assert.NoError(t, statement.WriteInsertMultiple(w, "returning_user", []string{"name"}, []string{"?", "?"}))
+	assert.EqualValues(t, "INSERT INTO `returning_user` (`name`) VALUES (?),(?)", w.String())
+
+	statement = newReturningStatement(t, "postgres", "postgres://postgres:@localhost:5432/test?sslmode=disable")
+	assert.True(t, statement.InsertMultipleReturnsID([]string{"name"}, 2))
+}
+
+func TestReturnsInOrder(t *testing.T) {
+	kases := []struct {
+		driver   string
+		dsn      string
+		expected bool
+	}{
+		{"postgres", "postgres://postgres:@localhost:5432/test?sslmode=disable", true},
+		{"mysql", "root:@tcp(localhost:3306)/test", true},
+		{"sqlite3", "./test.db", false},
+		{"mssql", "server=localhost;user id=sa;password=yourStrong(!)Password;database=test", false},
+	}
+
+	for _, kase := range kases {
+		t.Run(kase.driver, func(t *testing.T) {
+			statement := newReturningStatement(t, kase.driver, kase.dsn)
+			assert.EqualValues(t, kase.expected, statement.ReturnsInOrder())
+		})
+	}
+}
+
+func TestWriteUpdateReturning(t *testing.T) {
+	statement := newReturningStatement(t, "postgres", "postgres://postgres:@localhost:5432/test?sslmode=disable")
+	statement.Returning("id", "created")
+
+	w := builder.NewWriter()
+	err := statement.WriteUpdate(w, builder.Eq{"id": 1}, reflect.ValueOf(ReturningUser{}), []string{`"name" = ?`}, []interface{}{"lunny"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, `UPDATE "returning_user" SET "name" = ? WHERE id=? RETURNING "id","created"`, w.String())
+
+	statement = newReturningStatement(t, "mssql", "server=localhost;user id=sa;password=yourStrong(!)Password;database=test")
+	statement.Returning("id")
+
+	w = builder.NewWriter()
+	err = statement.WriteUpdate(w, builder.Eq{"id": 1}, reflect.ValueOf(ReturningUser{}), []string{"[name] = ?"}, []interface{}{"lunny"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, "UPDATE [returning_user] SET [name] = ? OUTPUT inserted.[id] WHERE id=?", w.String())
+
+	// MariaDB has no UPDATE ... RETURNING
+	statement = newReturningStatement(t, "mysql", "root:@tcp(localhost:3306)/test")
+	setServerVersion(statement, "10.11.2", "MariaDB")
+	statement.Returning()
+	err = statement.WriteUpdate(builder.NewWriter(), builder.Eq{"id": 1}, reflect.ValueOf(ReturningUser{}), []string{"`name` = ?"}, []interface{}{"lunny"})
+	assert.ErrorIs(t, err, ErrReturningNotSupported)
+}
+
+func TestWriteDeleteReturning(t *testing.T) {
+	nowTime := func(*schemas.Column) (interface{}, time.Time, error) {
+		return nil, time.Time{}, nil
+	}
+
+	statement := newReturningStatement(t, "postgres", "postgres://postgres:@localhost:5432/test?sslmode=disable")
+	statement.Returning("name")
+	statement.And(builder.Eq{"id": 1})
+
+	w := builder.NewWriter()
+	assert.NoError(t, statement.WriteDelete(w, builder.NewWriter(), nowTime))
+	assert.EqualValues(t, `DELETE FROM "returning_user" WHERE id=? RETURNING "name"`, w.String())
+
+	statement = newReturningStatement(t, "mssql", "server=localhost;user id=sa;password=yourStrong(!)Password;database=test")
+	statement.Returning("name")
+	statement.And(builder.Eq{"id": 1})
+
+	w = builder.NewWriter()
+	assert.NoError(t, statement.WriteDelete(w, builder.NewWriter(), nowTime))
+	assert.EqualValues(t, "DELETE FROM [returning_user] OUTPUT deleted.[name] WHERE id=?", w.String())
+}
+
+func TestCheckReturning(t *testing.T) {
+	kases := []struct {
+		driver    string
+		dsn       string
+		number    string
+		edition   string
+		isUpdate  bool
+		supported bool
+	}{
+		{"mysql", "root:@tcp(localhost:3306)/test", "8.0.33", "", false, false},
+		{"mysql", "root:@tcp(localhost:3306)/test", "10.4.28", "MariaDB", false, false},
+		{"mysql", "root:@tcp(localhost:3306)/test", "10.5.0", "MariaDB", false, true},
+		{"mysql", "root:@tcp(localhost:3306)/test", "10.11.2", "MariaDB", true, false},
+		{"sqlite3", "./test.db", "3.34.1", "sqlite", false, false},
+		{"sqlite3", "./test.db", "3.35.0", "sqlite", true, true},
+	}
+	for _, kase := range kases {
+		statement := newReturningStatement(t, kase.driver, kase.dsn)
+		setServerVersion(statement, kase.number, kase.edition)
+		statement.Returning()
+		err := statement.checkReturning(kase.isUpdate)
+		if kase.supported {
+			assert.NoError(t, err, kase.number)
+		} else {
+			assert.ErrorIs(t, err, ErrReturningNotSupported, kase.number)
+		}
+	}
+
+	// a soft delete is an UPDATE
+	type SoftDeleteUser struct {
+		Id      int64
+		Deleted time.Time `xorm:"deleted"`
+	}
+	statement := newReturningStatement(t, "mysql", "root:@tcp(localhost:3306)/test")
+	assert.NoError(t, statement.SetRefBean(new(SoftDeleteUser)))
+	setServerVersion(statement, "10.11.2", "MariaDB")
+	statement.Returning()
+	err := statement.WriteDelete(builder.NewWriter(), builder.NewWriter(), func(*schemas.Column) (interface{}, time.Time, error) {
+		return nil, time.Time{}, nil
+	})
+	assert.ErrorIs(t, err, ErrReturningNotSupported)
+}
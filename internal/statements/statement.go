@@ -45,6 +45,7 @@ type join struct {
 type Statement struct {
 	RefTable        *schemas.Table
 	dialect         dialects.Dialect
+	serverVersion   func() *schemas.Version
 	defaultTimeZone *time.Location
 	tagParser       *tags.Parser
 	Start           int
@@ -81,11 +82,21 @@ type Statement struct {
 	DecrColumns     exprParams
 	ExprColumns     exprParams
 	cond            builder.Cond
+	upsert          *upsert
+	returning       []string // nil if nothing to return, empty for all the columns
+	preloads        []string
 	BufferSize      int
 	Context         contexts.ContextCache
+	CacheFor        *CacheFor
 	LastError       error
 }
 
+// CacheFor represents the options to cache the result of the query
+type CacheFor struct {
+	TTL time.Duration
+	Key string // generated by the query if it's empty
+}
+
 // NewStatement creates a new statement
 func NewStatement(dialect dialects.Dialect, tagParser *tags.Parser, defaultTimeZone *time.Location) *Statement {
 	statement := &Statement{
@@ -97,6 +108,58 @@ func NewStatement(dialect dialects.Dialect, tagParser *tags.Parser, defaultTimeZ
 	return statement
 }
 
+// SetServerVersion sets the function returning the version of the database,
+// which returns nil if the version is unknown
+func (statement *Statement) SetServerVersion(serverVersion func() *schemas.Version) {
+	statement.serverVersion = serverVersion
+}
+
+// versionAtLeast returns true if the version of the database is known and it's
+// not lower than min, the numbers are compared part by part
+func (statement *Statement) versionAtLeast(min string) bool {
+	if statement.serverVersion == nil {
+		return false
+	}
+	version := statement.serverVersion()
+	if version == nil {
+		return false
+	}
+	return compareVersion(version.Number, min) >= 0
+}
+
+// compareVersion compares the numbers like 3.35.0 part by part, the non digit
+// suffix of a part is ignored
+func compareVersion(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x = leadingInt(as[i])
+		}
+		if i < len(bs) {
+			y = leadingInt(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func leadingInt(s string) int {
+	var n int
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			break
+		}
+		n = n*10 + int(c-'0')
+	}
+	return n
+}
+
 // SetTableName set table name
 func (statement *Statement) SetTableName(tableName string) {
 	statement.tableName = tableName
@@ -121,6 +184,11 @@ func (statement *Statement) SetContextCache(ctxCache contexts.ContextCache) {
 	statement.Context = ctxCache
 }
 
+// SetCacheFor caches the result of the query for the ttl by the key
+func (statement *Statement) SetCacheFor(ttl time.Duration, key string) {
+	statement.CacheFor = &CacheFor{TTL: ttl, Key: key}
+}
+
 // Reset reset all the statement's fields
 func (statement *Statement) Reset() {
 	statement.RefTable = nil
@@ -155,8 +223,12 @@ func (statement *Statement) Reset() {
 	statement.DecrColumns = exprParams{}
 	statement.ExprColumns = exprParams{}
 	statement.cond = builder.NewCond()
+	statement.upsert = nil
+	statement.returning = nil
+	statement.preloads = nil
 	statement.BufferSize = 0
 	statement.Context = nil
+	statement.CacheFor = nil
 	statement.LastError = nil
 }
 
@@ -79,6 +79,14 @@ func TestColumnsStringGeneration(t *testing.T) {
 	}
 }
 
+func TestCompareVersion(t *testing.T) {
+	assert.EqualValues(t, 0, compareVersion("3.35.0", "3.35"))
+	assert.EqualValues(t, 1, compareVersion("3.40.1", "3.35.0"))
+	assert.EqualValues(t, -1, compareVersion("3.9.2", "3.35.0"))
+	assert.EqualValues(t, 1, compareVersion("10.11.2-MariaDB", "10.5"))
+	assert.EqualValues(t, -1, compareVersion("10.4.28", "10.5"))
+}
+
 func TestConvertSQLOrArgs(t *testing.T) {
 	statement, err := createTestStatement()
 	assert.NoError(t, err)
@@ -579,6 +579,10 @@ func (statement *Statement) writeUpdateSets(w *builder.BytesWriter, v reflect.Va
 var ErrNoColumnsTobeUpdated = errors.New("no columns found to be updated")
 
 func (statement *Statement) WriteUpdate(updateWriter *builder.BytesWriter, cond builder.Cond, v reflect.Value, colNames []string, args []interface{}) error {
+	if err := statement.checkReturning(true); err != nil {
+		return err
+	}
+
 	if _, err := fmt.Fprintf(updateWriter, "UPDATE"); err != nil {
 		return err
 	}
@@ -606,6 +610,10 @@ func (statement *Statement) WriteUpdate(updateWriter *builder.BytesWriter, cond
 		return ErrNoColumnsTobeUpdated
 	}
 
+	if err := statement.writeOutput(updateWriter.Builder, "inserted"); err != nil {
+		return err
+	}
+
 	// write from
 	if err := statement.writeUpdateFrom(updateWriter); err != nil {
 		return err
@@ -633,5 +641,9 @@ func (statement *Statement) WriteUpdate(updateWriter *builder.BytesWriter, cond
 		}
 	}
 
-	return statement.writeUpdateLimit(updateWriter, cond)
+	if err := statement.writeUpdateLimit(updateWriter, cond); err != nil {
+		return err
+	}
+
+	return statement.writeReturning(updateWriter.Builder)
 }
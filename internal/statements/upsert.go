@@ -0,0 +1,599 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statements
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"xorm.io/builder"
+	"xorm.io/xorm/internal/utils"
+	"xorm.io/xorm/schemas"
+)
+
+// ErrNoConflictKey represents an error there is no unique key the upsert
+// could conflict on
+var ErrNoConflictKey = errors.New("no unique key to detect the conflict of upsert, please use OnConflict")
+
+// upsert describes how an insert resolves the conflict on a unique key
+type upsert struct {
+	conflictCols []string // the unique key the insert conflicts on
+	updateCols   []string // the columns updated on conflict, all by default
+	doNothing    bool
+}
+
+func (statement *Statement) getUpsert() *upsert {
+	if statement.upsert == nil {
+		statement.upsert = &upsert{}
+	}
+	return statement.upsert
+}
+
+// OnConflict makes the insert an upsert which conflicts on the columns. The
+// primary key and the unique indexes of the table are used if no column given.
+func (statement *Statement) OnConflict(cols ...string) *Statement {
+	u := statement.getUpsert()
+	if len(cols) > 0 {
+		u.conflictCols = cols
+	}
+	return statement
+}
+
+// DoUpdate sets the columns updated when the upsert conflicts. All the inserted
+// columns except the conflict key and the created time are updated if no
+// column given.
+func (statement *Statement) DoUpdate(cols ...string) *Statement {
+	u := statement.getUpsert()
+	u.updateCols = cols
+	u.doNothing = false
+	return statement
+}
+
+// DoNothing makes the upsert keep the existing row when it conflicts
+func (statement *Statement) DoNothing() *Statement {
+	statement.getUpsert().doNothing = true
+	return statement
+}
+
+// IsUpsert returns true if the insert should resolve the conflicts
+func (statement *Statement) IsUpsert() bool {
+	return statement.upsert != nil
+}
+
+// UpsertReturning returns the columns the upsert SQL returns as a row, which
+// are the auto increment and the version columns on the databases support
+// that unless the returned columns are given. Nothing is returned if the upsert
+// does nothing on conflict. SQLite returns the columns since 3.35.0.
+func (statement *Statement) UpsertReturning() []string {
+	switch statement.dialect.URI().DBType {
+	case schemas.POSTGRES, schemas.SQLITE, schemas.MSSQL:
+	default:
+		return nil
+	}
+	if statement.IsReturning() {
+		return statement.ReturningColumns()
+	}
+	if statement.dialect.URI().DBType == schemas.SQLITE && !statement.versionAtLeast("3.35.0") {
+		return nil
+	}
+
+	var cols []string
+	table := statement.RefTable
+	if len(table.AutoIncrement) > 0 {
+		cols = append(cols, table.AutoIncrement)
+	}
+	if len(table.Version) > 0 && statement.CheckVersion {
+		cols = append(cols, table.Version)
+	}
+	return cols
+}
+
+func containsCol(cols []string, col string) bool {
+	for _, c := range cols {
+		if strings.EqualFold(schemas.CommonQuoter.Trim(c), schemas.CommonQuoter.Trim(col)) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsCols(cols []string, subCols []string) bool {
+	for _, col := range subCols {
+		if !containsCol(cols, col) {
+			return false
+		}
+	}
+	return true
+}
+
+// conflictKeys returns the unique keys the inserted columns may conflict on
+func (statement *Statement) conflictKeys(colNames []string) [][]string {
+	if len(statement.upsert.conflictCols) > 0 {
+		return [][]string{statement.upsert.conflictCols}
+	}
+
+	var (
+		table = statement.RefTable
+		keys  [][]string
+	)
+	if len(table.PrimaryKeys) > 0 && containsCols(colNames, table.PrimaryKeys) {
+		keys = append(keys, table.PrimaryKeys)
+	}
+
+	names := make([]string, 0, len(table.Indexes))
+	for name, index := range table.Indexes {
+		if index.Type == schemas.UniqueType {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if cols := table.Indexes[name].Cols; containsCols(colNames, cols) {
+			keys = append(keys, cols)
+		}
+	}
+	return keys
+}
+
+// upsertUpdateCols returns the columns updated from the inserted values
+func (statement *Statement) upsertUpdateCols(srcCols []string, keys [][]string) ([]string, error) {
+	var (
+		table = statement.RefTable
+		cols  []string
+	)
+	if len(statement.upsert.updateCols) > 0 {
+		for _, col := range statement.upsert.updateCols {
+			if !containsCol(srcCols, col) {
+				return nil, fmt.Errorf("column %s updated by upsert is not inserted", col)
+			}
+			// the version is increased rather than overwritten
+			if column := table.GetColumn(col); column != nil && column.IsVersion && statement.CheckVersion {
+				continue
+			}
+			cols = append(cols, schemas.CommonQuoter.Trim(col))
+		}
+		// the updated time is always refreshed
+		for _, col := range srcCols {
+			if column := table.GetColumn(col); column != nil && column.IsUpdated &&
+				statement.UseAutoTime && !containsCol(cols, col) {
+				cols = append(cols, col)
+			}
+		}
+		return cols, nil
+	}
+
+	for _, col := range srcCols {
+		isKey := false
+		for _, key := range keys {
+			if containsCol(key, col) {
+				isKey = true
+				break
+			}
+		}
+		if isKey || statement.IncrColumns.IsColExist(col) || statement.DecrColumns.IsColExist(col) {
+			continue
+		}
+		if column := table.GetColumn(col); column != nil && (column.IsCreated ||
+			column.IsDeleted || column.IsAutoIncrement || column.IsVersion && statement.CheckVersion) {
+			continue
+		}
+		cols = append(cols, col)
+	}
+	return cols, nil
+}
+
+// writeUpsertSets writes the assignments of the conflicting row, source refers
+// to the inserted value of a column and target to the existing one. It returns
+// false if there is nothing to update.
+func (statement *Statement) writeUpsertSets(w *builder.BytesWriter, srcCols []string, keys [][]string,
+	source, target func(col string) string) (bool, error) {
+	updateCols, err := statement.upsertUpdateCols(srcCols, keys)
+	if err != nil {
+		return false, err
+	}
+
+	var sets int
+	writeSet := func(col string) error {
+		if sets > 0 {
+			if _, err := w.WriteString(", "); err != nil {
+				return err
+			}
+		}
+		sets++
+		_, err := fmt.Fprintf(w, "%s = ", statement.quote(col))
+		return err
+	}
+
+	for _, col := range updateCols {
+		if err := writeSet(col); err != nil {
+			return false, err
+		}
+		if _, err := w.WriteString(source(col)); err != nil {
+			return false, err
+		}
+	}
+
+	table := statement.RefTable
+	if len(table.Version) > 0 && statement.CheckVersion {
+		if err := writeSet(table.Version); err != nil {
+			return false, err
+		}
+		if _, err := fmt.Fprintf(w, "%s + 1", target(table.Version)); err != nil {
+			return false, err
+		}
+	}
+
+	for _, exprs := range []struct {
+		params exprParams
+		op     string
+	}{
+		{statement.IncrColumns, " + "},
+		{statement.DecrColumns, " - "},
+	} {
+		for _, expr := range exprs.params {
+			if err := writeSet(expr.ColName); err != nil {
+				return false, err
+			}
+			if _, err := w.WriteString(target(expr.ColName) + exprs.op); err != nil {
+				return false, err
+			}
+			if err := expr.WriteArgs(w); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	return sets > 0, nil
+}
+
+// writeInsertValues writes INSERT INTO table (columns) VALUES (args)
+func (statement *Statement) writeInsertValues(w *builder.BytesWriter, colNames []string, args []interface{}) error {
+	if _, err := fmt.Fprintf(w, "INSERT INTO %s (", statement.quote(statement.TableName())); err != nil {
+		return err
+	}
+	if err := statement.dialect.Quoter().JoinWrite(w.Builder, append(colNames, statement.ExprColumns.ColNames()...), ","); err != nil {
+		return err
+	}
+	if _, err := w.WriteString(") VALUES ("); err != nil {
+		return err
+	}
+	if err := statement.WriteArgs(w, args); err != nil {
+		return err
+	}
+	if len(statement.ExprColumns) > 0 {
+		if len(args) > 0 {
+			if _, err := w.WriteString(","); err != nil {
+				return err
+			}
+		}
+		if err := statement.ExprColumns.WriteArgs(w); err != nil {
+			return err
+		}
+	}
+	_, err := w.WriteString(")")
+	return err
+}
+
+// GenUpsertSQL generates the SQL inserting the bean or resolving the conflict
+// on a unique key, which is ON DUPLICATE KEY UPDATE on MySQL, ON CONFLICT on
+// PostgreSQL and SQLite, and MERGE on MSSQL, Oracle and Dameng.
+func (statement *Statement) GenUpsertSQL(colNames []string, args []interface{}) (string, []interface{}, error) {
+	if len(colNames)+len(statement.ExprColumns) == 0 {
+		return "", nil, errors.New("no column to upsert")
+	}
+	// the upsert of MariaDB could not return the updated row
+	if statement.IsReturning() && statement.dialect.URI().DBType == schemas.MYSQL {
+		return "", nil, ErrReturningNotSupported
+	}
+	if err := statement.checkReturning(false); err != nil {
+		return "", nil, err
+	}
+
+	switch statement.dialect.URI().DBType {
+	case schemas.MYSQL:
+		return statement.genOnDuplicateKeySQL(colNames, args)
+	case schemas.POSTGRES, schemas.SQLITE:
+		return statement.genOnConflictSQL(colNames, args)
+	case schemas.MSSQL, schemas.ORACLE, schemas.DAMENG:
+		return statement.genMergeSQL(colNames, args)
+	default:
+		return "", nil, fmt.Errorf("upsert is not supported on %s", statement.dialect.URI().DBType)
+	}
+}
+
+func (statement *Statement) genOnDuplicateKeySQL(colNames []string, args []interface{}) (string, []interface{}, error) {
+	var (
+		buf     = builder.NewWriter()
+		table   = statement.RefTable
+		srcCols = append(colNames, statement.ExprColumns.ColNames()...)
+	)
+	if err := statement.writeInsertValues(buf, colNames, args); err != nil {
+		return "", nil, err
+	}
+	if _, err := buf.WriteString(" ON DUPLICATE KEY UPDATE "); err != nil {
+		return "", nil, err
+	}
+
+	hasSets := false
+	if !statement.upsert.doNothing {
+		var err error
+		hasSets, err = statement.writeUpsertSets(buf, srcCols, statement.conflictKeys(colNames), func(col string) string {
+			return "VALUES(" + statement.quote(col) + ")"
+		}, statement.quote)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	// LAST_INSERT_ID(expr) makes the id of the updated row returned as well
+	if len(table.AutoIncrement) > 0 && hasSets {
+		if _, err := fmt.Fprintf(buf, ", %s = LAST_INSERT_ID(%s)", statement.quote(table.AutoIncrement),
+			statement.quote(table.AutoIncrement)); err != nil {
+			return "", nil, err
+		}
+	} else if !hasSets {
+		// assigning a column to itself keeps the existing row
+		col := srcCols[0]
+		if len(table.AutoIncrement) > 0 {
+			col = table.AutoIncrement
+		}
+		if _, err := fmt.Fprintf(buf, "%s = %s", statement.quote(col), statement.quote(col)); err != nil {
+			return "", nil, err
+		}
+	}
+
+	return buf.String(), buf.Args(), nil
+}
+
+func (statement *Statement) genOnConflictSQL(colNames []string, args []interface{}) (string, []interface{}, error) {
+	var (
+		buf       = builder.NewWriter()
+		tableName = statement.quote(statement.TableName())
+		srcCols   = append(colNames, statement.ExprColumns.ColNames()...)
+		keys      = statement.conflictKeys(colNames)
+	)
+	if err := statement.writeInsertValues(buf, colNames, args); err != nil {
+		return "", nil, err
+	}
+
+	if !statement.upsert.doNothing || len(statement.upsert.conflictCols) > 0 {
+		switch len(keys) {
+		case 0:
+			return "", nil, ErrNoConflictKey
+		case 1:
+		default:
+			return "", nil, fmt.Errorf("upsert into %s may conflict on more than one unique key, please use OnConflict", statement.TableName())
+		}
+	}
+
+	if _, err := buf.WriteString(" ON CONFLICT "); err != nil {
+		return "", nil, err
+	}
+	if len(keys) == 1 {
+		if _, err := buf.WriteString("("); err != nil {
+			return "", nil, err
+		}
+		if err := statement.dialect.Quoter().JoinWrite(buf.Builder, keys[0], ","); err != nil {
+			return "", nil, err
+		}
+		if _, err := buf.WriteString(") "); err != nil {
+			return "", nil, err
+		}
+	}
+
+	hasSets := false
+	if !statement.upsert.doNothing {
+		sets := builder.NewWriter()
+		var err error
+		hasSets, err = statement.writeUpsertSets(sets, srcCols, keys, func(col string) string {
+			return "excluded." + statement.quote(col)
+		}, func(col string) string {
+			return tableName + "." + statement.quote(col)
+		})
+		if err != nil {
+			return "", nil, err
+		}
+		if hasSets {
+			if _, err := fmt.Fprintf(buf, "DO UPDATE SET %s", sets.String()); err != nil {
+				return "", nil, err
+			}
+			buf.Append(sets.Args()...)
+		}
+	}
+	if !hasSets {
+		if _, err := buf.WriteString("DO NOTHING"); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if cols := statement.UpsertReturning(); len(cols) > 0 {
+		if _, err := buf.WriteString(" RETURNING "); err != nil {
+			return "", nil, err
+		}
+		if err := statement.dialect.Quoter().JoinWrite(buf.Builder, cols, ","); err != nil {
+			return "", nil, err
+		}
+	}
+
+	return buf.String(), buf.Args(), nil
+}
+
+func (statement *Statement) genMergeSQL(colNames []string, args []interface{}) (string, []interface{}, error) {
+	var (
+		buf       = builder.NewWriter()
+		dbType    = statement.dialect.URI().DBType
+		table     = statement.RefTable
+		tableName = statement.TableName()
+		srcCols   = append(colNames, statement.ExprColumns.ColNames()...)
+		keys      = statement.conflictKeys(colNames)
+		source    = func(col string) string { return "s." + statement.quote(col) }
+		target    = func(col string) string { return "t." + statement.quote(col) }
+	)
+	if len(keys) == 0 {
+		return "", nil, ErrNoConflictKey
+	}
+	for _, key := range keys {
+		if !containsCols(srcCols, key) {
+			return "", nil, fmt.Errorf("conflict key %v of upsert is not inserted", key)
+		}
+	}
+
+	if _, err := fmt.Fprintf(buf, "MERGE INTO %s ", statement.quote(tableName)); err != nil {
+		return "", nil, err
+	}
+
+	// the source is one row of the inserted values
+	if dbType == schemas.MSSQL {
+		if _, err := buf.WriteString("WITH (HOLDLOCK) AS t USING (VALUES ("); err != nil {
+			return "", nil, err
+		}
+		if err := statement.WriteArgs(buf, args); err != nil {
+			return "", nil, err
+		}
+		if len(statement.ExprColumns) > 0 {
+			if len(args) > 0 {
+				if _, err := buf.WriteString(","); err != nil {
+					return "", nil, err
+				}
+			}
+			if err := statement.ExprColumns.WriteArgs(buf); err != nil {
+				return "", nil, err
+			}
+		}
+		if _, err := buf.WriteString(")) AS s ("); err != nil {
+			return "", nil, err
+		}
+		if err := statement.dialect.Quoter().JoinWrite(buf.Builder, srcCols, ","); err != nil {
+			return "", nil, err
+		}
+		if _, err := buf.WriteString(")"); err != nil {
+			return "", nil, err
+		}
+	} else {
+		if _, err := buf.WriteString("t USING (SELECT "); err != nil {
+			return "", nil, err
+		}
+		for i, arg := range args {
+			if i > 0 {
+				if _, err := buf.WriteString(","); err != nil {
+					return "", nil, err
+				}
+			}
+			if err := statement.WriteArg(buf, arg); err != nil {
+				return "", nil, err
+			}
+			if _, err := fmt.Fprintf(buf, " %s", statement.quote(colNames[i])); err != nil {
+				return "", nil, err
+			}
+		}
+		for i, expr := range statement.ExprColumns {
+			if i > 0 || len(args) > 0 {
+				if _, err := buf.WriteString(","); err != nil {
+					return "", nil, err
+				}
+			}
+			if err := expr.WriteArgs(buf); err != nil {
+				return "", nil, err
+			}
+			if _, err := fmt.Fprintf(buf, " %s", statement.quote(expr.ColName)); err != nil {
+				return "", nil, err
+			}
+		}
+		if _, err := buf.WriteString(" FROM DUAL) s"); err != nil {
+			return "", nil, err
+		}
+	}
+
+	// the row matches if it conflicts on any of the keys
+	if _, err := buf.WriteString(" ON ("); err != nil {
+		return "", nil, err
+	}
+	for i, key := range keys {
+		if i > 0 {
+			if _, err := buf.WriteString(" OR "); err != nil {
+				return "", nil, err
+			}
+		}
+		if _, err := buf.WriteString("("); err != nil {
+			return "", nil, err
+		}
+		for j, col := range key {
+			if j > 0 {
+				if _, err := buf.WriteString(" AND "); err != nil {
+					return "", nil, err
+				}
+			}
+			if _, err := fmt.Fprintf(buf, "%s = %s", target(col), source(col)); err != nil {
+				return "", nil, err
+			}
+		}
+		if _, err := buf.WriteString(")"); err != nil {
+			return "", nil, err
+		}
+	}
+	if _, err := buf.WriteString(")"); err != nil {
+		return "", nil, err
+	}
+
+	if !statement.upsert.doNothing {
+		sets := builder.NewWriter()
+		hasSets, err := statement.writeUpsertSets(sets, srcCols, keys, source, target)
+		if err != nil {
+			return "", nil, err
+		}
+		if hasSets {
+			if _, err := fmt.Fprintf(buf, " WHEN MATCHED THEN UPDATE SET %s", sets.String()); err != nil {
+				return "", nil, err
+			}
+			buf.Append(sets.Args()...)
+		}
+	}
+
+	insertCols := srcCols
+	insertValues := make([]string, 0, len(srcCols)+1)
+	for _, col := range srcCols {
+		insertValues = append(insertValues, source(col))
+	}
+	if len(table.AutoIncrement) > 0 && (dbType == schemas.ORACLE || dbType == schemas.DAMENG) &&
+		!containsCol(srcCols, table.AutoIncrement) {
+		insertCols = append(insertCols[:len(insertCols):len(insertCols)], table.AutoIncrement)
+		insertValues = append(insertValues, utils.SeqName(tableName)+".nextval")
+	}
+	if _, err := buf.WriteString(" WHEN NOT MATCHED THEN INSERT ("); err != nil {
+		return "", nil, err
+	}
+	if err := statement.dialect.Quoter().JoinWrite(buf.Builder, insertCols, ","); err != nil {
+		return "", nil, err
+	}
+	if _, err := fmt.Fprintf(buf, ") VALUES (%s)", strings.Join(insertValues, ",")); err != nil {
+		return "", nil, err
+	}
+
+	if dbType == schemas.MSSQL {
+		if cols := statement.UpsertReturning(); len(cols) > 0 {
+			if _, err := buf.WriteString(" OUTPUT "); err != nil {
+				return "", nil, err
+			}
+			for i, col := range cols {
+				if i > 0 {
+					if _, err := buf.WriteString(","); err != nil {
+						return "", nil, err
+					}
+				}
+				if _, err := buf.WriteString("inserted." + statement.quote(col)); err != nil {
+					return "", nil, err
+				}
+			}
+		}
+		// MERGE must be terminated by a semicolon on MSSQL
+		if _, err := buf.WriteString(";"); err != nil {
+			return "", nil, err
+		}
+	}
+
+	return buf.String(), buf.Args(), nil
+}
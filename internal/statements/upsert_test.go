@@ -0,0 +1,102 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statements
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"xorm.io/xorm/caches"
+	"xorm.io/xorm/dialects"
+	"xorm.io/xorm/names"
+	"xorm.io/xorm/tags"
+)
+
+type UpsertUser struct {
+	Id      int64
+	Name    string `xorm:"unique"`
+	Email   string
+	Created time.Time `xorm:"created"`
+	Version int       `xorm:"version"`
+}
+
+func TestGenUpsertSQL(t *testing.T) {
+	kases := []struct {
+		driver   string
+		dsn      string
+		expected string
+	}{
+		{
+			"mysql", "root:@tcp(localhost:3306)/test",
+			"INSERT INTO `upsert_user` (`name`,`email`,`created`,`version`) VALUES (?,?,?,?) ON DUPLICATE KEY UPDATE " +
+				"`email` = VALUES(`email`), `version` = `version` + 1, `id` = LAST_INSERT_ID(`id`)",
+		},
+		{
+			"postgres", "postgres://postgres:@localhost:5432/test?sslmode=disable",
+			`INSERT INTO "upsert_user" ("name","email","created","version") VALUES (?,?,?,?) ON CONFLICT ("name") DO UPDATE SET ` +
+				`"email" = excluded."email", "version" = "upsert_user"."version" + 1 RETURNING "id","version"`,
+		},
+		{
+			"mssql", "server=localhost;user id=sa;password=yourStrong(!)Password;database=test",
+			"MERGE INTO [upsert_user] WITH (HOLDLOCK) AS t USING (VALUES (?,?,?,?)) AS s ([name],[email],[created],[version]) " +
+				"ON ((t.[name] = s.[name])) WHEN MATCHED THEN UPDATE SET [email] = s.[email], [version] = t.[version] + 1 " +
+				"WHEN NOT MATCHED THEN INSERT ([name],[email],[created],[version]) VALUES (s.[name],s.[email],s.[created],s.[version]) " +
+				"OUTPUT inserted.[id],inserted.[version];",
+		},
+	}
+
+	for _, kase := range kases {
+		t.Run(kase.driver, func(t *testing.T) {
+			dialect, err := dialects.OpenDialect(kase.driver, kase.dsn)
+			assert.NoError(t, err)
+			parser := tags.NewParser("xorm", dialect, names.SnakeMapper{}, names.SnakeMapper{}, caches.NewManager())
+			statement := NewStatement(dialect, parser, time.Local)
+			assert.NoError(t, statement.SetRefBean(new(UpsertUser)))
+			statement.OnConflict()
+
+			now := time.Now()
+			sql, args, err := statement.GenUpsertSQL([]string{"name", "email", "created", "version"},
+				[]interface{}{"lunny", "lunny@a.com", now, 1})
+			assert.NoError(t, err)
+			assert.EqualValues(t, kase.expected, sql)
+			assert.EqualValues(t, []interface{}{"lunny", "lunny@a.com", now, 1}, args)
+		})
+	}
+}
+
+func TestGenUpsertSQLDoNothing(t *testing.T) {
+	statement, err := createTestStatement()
+	assert.NoError(t, err)
+	statement.DoNothing()
+
+	sql, _, err := statement.GenUpsertSQL([]string{"Caption"}, []interface{}{"a"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, "INSERT INTO `TestTable` (`Caption`) VALUES (?) ON CONFLICT DO NOTHING", sql)
+
+	statement.OnConflict("Caption").DoUpdate("Code1")
+	_, _, err = statement.GenUpsertSQL([]string{"Caption"}, []interface{}{"a"})
+	assert.Error(t, err)
+}
+
+func TestUpsertReturningSQLiteVersion(t *testing.T) {
+	dialect, err := dialects.OpenDialect("sqlite3", "./test.db")
+	assert.NoError(t, err)
+	parser := tags.NewParser("xorm", dialect, names.SnakeMapper{}, names.SnakeMapper{}, caches.NewManager())
+
+	for _, kase := range []struct {
+		version   string
+		returning []string
+	}{
+		{"3.34.1", nil},
+		{"3.35.0", []string{"id", "version"}},
+	} {
+		statement := NewStatement(dialect, parser, time.Local)
+		setServerVersion(statement, kase.version, "")
+		assert.NoError(t, statement.SetRefBean(new(UpsertUser)))
+		statement.OnConflict()
+		assert.EqualValues(t, kase.returning, statement.UpsertReturning(), kase.version)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeepCopy(t *testing.T) {
+	type Inner struct {
+		Name string
+	}
+	type Bean struct {
+		Id      int64
+		Inner   *Inner
+		Tags    []string
+		Data    []byte
+		Attrs   map[string]interface{}
+		Created time.Time
+		private []int
+	}
+
+	bean := &Bean{
+		Id:      1,
+		Inner:   &Inner{Name: "a"},
+		Tags:    []string{"x"},
+		Data:    []byte("data"),
+		Attrs:   map[string]interface{}{"k": []byte("v")},
+		Created: time.Now(),
+		private: []int{1},
+	}
+	c := DeepCopy(reflect.ValueOf(bean)).Interface().(*Bean)
+	assert.EqualValues(t, bean, c)
+	assert.True(t, bean != c)
+
+	c.Inner.Name = "b"
+	c.Tags[0] = "y"
+	c.Data[0] = 'D'
+	c.Attrs["k"].([]byte)[0] = 'V'
+	assert.EqualValues(t, "a", bean.Inner.Name)
+	assert.EqualValues(t, "x", bean.Tags[0])
+	assert.EqualValues(t, "data", string(bean.Data))
+	assert.EqualValues(t, "v", string(bean.Attrs["k"].([]byte)))
+
+	// the unexported fields are shared
+	c.private[0] = 2
+	assert.EqualValues(t, 2, bean.private[0])
+
+	// the cycles are kept
+	type Node struct {
+		Next *Node
+	}
+	node := &Node{}
+	node.Next = node
+	cn := DeepCopy(reflect.ValueOf(node)).Interface().(*Node)
+	assert.True(t, cn != node)
+	assert.True(t, cn.Next == cn)
+
+	var rows []map[string]interface{}
+	assert.Nil(t, DeepCopy(reflect.ValueOf(rows)).Interface())
+	assert.False(t, DeepCopy(reflect.ValueOf(nil)).IsValid())
+}
@@ -5,7 +5,10 @@
 package utils
 
 import (
+	"bufio"
+	"io"
 	"strings"
+	"unicode"
 )
 
 // IsSubQuery returns true if it contains a sub query
@@ -18,3 +21,276 @@ func IsSubQuery(tbName string) bool {
 	return strings.EqualFold(tbName[:len(selStr)], selStr) ||
 		strings.EqualFold(tbName[:len(selStr)+1], "("+selStr)
 }
+
+// NewSQLScanner returns a scanner which splits the SQL statements of the
+// reader by the semicolons out of the quotes and the line comments
+func NewSQLScanner(r io.Reader) *bufio.Scanner {
+	var (
+		inSingleQuote bool
+		startComment  bool
+	)
+
+	scanner := bufio.NewScanner(r)
+	semiColSpliter := func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+		oriInSingleQuote := inSingleQuote
+		for i, b := range data {
+			if startComment {
+				if b == '\n' {
+					startComment = false
+				}
+			} else {
+				if !inSingleQuote && i > 0 && data[i-1] == '-' && data[i] == '-' {
+					startComment = true
+					continue
+				}
+
+				if b == '\'' {
+					inSingleQuote = !inSingleQuote
+				}
+				if !inSingleQuote && b == ';' {
+					return i + 1, data[0:i], nil
+				}
+			}
+		}
+		// If we're at EOF, we have a final, non-terminated line. Return it.
+		if atEOF {
+			return len(data), data, nil
+		}
+		inSingleQuote = oriInSingleQuote
+		// Request more data.
+		return 0, nil, nil
+	}
+
+	scanner.Split(semiColSpliter)
+	return scanner
+}
+
+// sqlToken represents a word, a quoted identifier or a punctuation of a SQL
+type sqlToken struct {
+	text   string
+	quoted bool
+}
+
+// tokenizeSQL splits the SQL into the words and the punctuations, the string
+// literals and the comments are skipped, the qualified names are one word
+func tokenizeSQL(sql string) []sqlToken {
+	var (
+		tokens []sqlToken
+		word   strings.Builder
+		quoted bool
+	)
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, sqlToken{text: word.String(), quoted: quoted})
+			word.Reset()
+			quoted = false
+		}
+	}
+	isWordChar := func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '$' || r == '.'
+	}
+
+	rs := []rune(sql)
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		switch {
+		case r == '\'':
+			flush()
+			for i++; i < len(rs); i++ {
+				if rs[i] == '\'' {
+					if i+1 < len(rs) && rs[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+		case r == '-' && i+1 < len(rs) && rs[i+1] == '-':
+			flush()
+			for i < len(rs) && rs[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(rs) && rs[i+1] == '*':
+			flush()
+			for i += 2; i+1 < len(rs) && !(rs[i] == '*' && rs[i+1] == '/'); i++ {
+			}
+			i++
+		case r == '`' || r == '"' || r == '[':
+			end := r
+			if r == '[' {
+				end = ']'
+			}
+			for i++; i < len(rs) && rs[i] != end; i++ {
+				word.WriteRune(rs[i])
+			}
+			quoted = true
+		case isWordChar(r):
+			word.WriteRune(r)
+		default:
+			flush()
+			if !unicode.IsSpace(r) {
+				tokens = append(tokens, sqlToken{text: string(r)})
+			}
+		}
+	}
+	flush()
+	return tokens
+}
+
+// the keywords followed by a table name
+var tableKeywords = map[string]bool{
+	"FROM":   true,
+	"JOIN":   true,
+	"INTO":   true,
+	"UPDATE": true,
+	"TABLE":  true,
+	"USING":  true,
+}
+
+// the words between a table keyword and the table name
+var tableModifiers = map[string]bool{
+	"IF":      true,
+	"NOT":     true,
+	"EXISTS":  true,
+	"ONLY":    true,
+	"LATERAL": true,
+	"SET":     true,
+}
+
+// the keywords ending a list of tables after FROM
+var fromListEnds = map[string]bool{
+	"WHERE":  true,
+	"GROUP":  true,
+	"ORDER":  true,
+	"HAVING": true,
+	"LIMIT":  true,
+	"ON":     true,
+	"UNION":  true,
+	"SET":    true,
+	"VALUES": true,
+	"SELECT": true,
+	"FOR":    true,
+}
+
+// ReferencedTables returns the lower case names of the tables referenced by
+// the SQL, including the joined tables and the tables of the sub queries. The
+// schemas are removed from the names. It may return more words than the
+// tables but it shouldn't miss a table.
+func ReferencedTables(sql string) []string {
+	var (
+		tables      []string
+		seen        = make(map[string]bool)
+		expectTable bool
+		inFromList  bool
+	)
+	for _, token := range tokenizeSQL(sql) {
+		upper := strings.ToUpper(token.text)
+		isWord := token.quoted || isWordToken(token.text)
+		if !token.quoted && tableKeywords[upper] {
+			expectTable = true
+			// the tables of MySQL are listed after UPDATE like after FROM
+			inFromList = upper == "FROM" || upper == "UPDATE"
+			continue
+		}
+		if expectTable {
+			if !token.quoted && tableModifiers[upper] {
+				continue
+			}
+			expectTable = false
+			if isWord {
+				name := strings.ToLower(token.text)
+				if idx := strings.LastIndexByte(name, '.'); idx >= 0 {
+					name = name[idx+1:]
+				}
+				if name != "" && !seen[name] {
+					seen[name] = true
+					tables = append(tables, name)
+				}
+			}
+			continue
+		}
+		if inFromList {
+			if token.text == "," && !token.quoted {
+				expectTable = true
+			} else if !token.quoted && fromListEnds[upper] {
+				inFromList = false
+			}
+		}
+	}
+	return tables
+}
+
+func isWordToken(s string) bool {
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '$' && r != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// the keywords of the statements changing the data, which are written in a
+// WITH of PostgreSQL like WITH d AS (DELETE ... RETURNING *) SELECT ...
+var writeKeywords = map[string]bool{
+	"INSERT": true,
+	"UPDATE": true,
+	"DELETE": true,
+	"MERGE":  true,
+}
+
+// IsWriteSQL returns true if the SQL could change the data or the schema, it
+// is checked by the first keyword and the keywords of the data changes in the
+// SQL, except the row locks like FOR UPDATE and FOR NO KEY UPDATE
+func IsWriteSQL(sql string) bool {
+	if isWriteKeywordFirst(sql) {
+		return true
+	}
+	var last string
+	for _, token := range tokenizeSQL(sql) {
+		if token.quoted {
+			last = ""
+			continue
+		}
+		upper := strings.ToUpper(token.text)
+		if writeKeywords[upper] && !(upper == "UPDATE" && (last == "FOR" || last == "KEY")) {
+			return true
+		}
+		last = upper
+	}
+	return false
+}
+
+// isWriteKeywordFirst returns true if the first keyword of the SQL changes the
+// data or the schema
+func isWriteKeywordFirst(sql string) bool {
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '(':
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return false
+			}
+			i += end + 3
+		default:
+			j := i
+			for j < len(sql) && (sql[j] >= 'a' && sql[j] <= 'z' || sql[j] >= 'A' && sql[j] <= 'Z') {
+				j++
+			}
+			switch strings.ToUpper(sql[i:j]) {
+			case "INSERT", "UPDATE", "DELETE", "REPLACE", "MERGE", "UPSERT",
+				"TRUNCATE", "DROP", "ALTER", "CREATE", "RENAME":
+				return true
+			}
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferencedTables(t *testing.T) {
+	kases := []struct {
+		sql    string
+		tables []string
+	}{
+		{"SELECT * FROM `user` WHERE `id`=?", []string{"user"}},
+		{"SELECT * FROM \"public\".\"User\" u LEFT JOIN \"public\".\"order\" o ON u.id = o.user_id", []string{"user", "order"}},
+		{"SELECT TOP 1 * FROM [dbo].[user] WHERE [name] = 'from x'", []string{"user"}},
+		{"SELECT a.id FROM a, b AS bb, c WHERE a.id = bb.id", []string{"a", "b", "c"}},
+		{"SELECT * FROM a WHERE id IN (SELECT a_id FROM b WHERE x = 'join y') -- FROM z", []string{"a", "b"}},
+		{"SELECT * FROM (SELECT * FROM a) t INNER JOIN b ON t.id=b.id", []string{"a", "b"}},
+		{"SELECT count(*) FROM a /* FROM z */ WHERE 1=1", []string{"a"}},
+		{"INSERT INTO `user` (`name`) VALUES (?)", []string{"user"}},
+		{"INSERT INTO archive SELECT * FROM `user`", []string{"archive", "user"}},
+		{"UPDATE `user` SET `name`=? WHERE `id`=?", []string{"user"}},
+		{"UPDATE ONLY users SET name = 'a'", []string{"users"}},
+		{"UPDATE a, `b` AS bb SET a.x = bb.x WHERE a.id = bb.id", []string{"a", "b"}},
+		{"WITH d AS (DELETE FROM a RETURNING *) INSERT INTO b SELECT * FROM d", []string{"a", "b", "d"}},
+		{"DELETE FROM user WHERE id IN (SELECT id FROM banned)", []string{"user", "banned"}},
+		{"TRUNCATE TABLE user", []string{"user"}},
+		{"DROP TABLE IF EXISTS `user`", []string{"user"}},
+		{"CREATE TABLE IF NOT EXISTS user (id INTEGER)", []string{"user"}},
+		{"SELECT 1", nil},
+	}
+	for _, kase := range kases {
+		assert.EqualValues(t, kase.tables, ReferencedTables(kase.sql), kase.sql)
+	}
+}
+
+func TestIsWriteSQL(t *testing.T) {
+	assert.True(t, IsWriteSQL("INSERT INTO user (id) VALUES (1)"))
+	assert.True(t, IsWriteSQL("  update user set id = 1"))
+	assert.True(t, IsWriteSQL("/* comment */ DELETE FROM user"))
+	assert.True(t, IsWriteSQL("TRUNCATE TABLE user"))
+	assert.False(t, IsWriteSQL("SELECT * FROM user"))
+	assert.False(t, IsWriteSQL("(SELECT 1) UNION (SELECT 2)"))
+	assert.False(t, IsWriteSQL("WITH t AS (SELECT 1) SELECT * FROM t"))
+	assert.True(t, IsWriteSQL("WITH d AS (DELETE FROM t RETURNING *) SELECT * FROM d"))
+	assert.True(t, IsWriteSQL("WITH u AS (UPDATE t SET x = 1 RETURNING id) SELECT count(*) FROM u"))
+	assert.False(t, IsWriteSQL("SELECT * FROM t WHERE name = 'delete' FOR UPDATE"))
+	assert.False(t, IsWriteSQL("SELECT * FROM t FOR NO KEY UPDATE"))
+	assert.False(t, IsWriteSQL("SELECT `update` FROM t -- DELETE"))
+	assert.False(t, IsWriteSQL(""))
+}
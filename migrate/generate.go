@@ -0,0 +1,343 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"xorm.io/xorm"
+	"xorm.io/xorm/dialects"
+	"xorm.io/xorm/internal/utils"
+	"xorm.io/xorm/schemas"
+)
+
+// Diff represents the SQLs to migrate the database to the structs and back
+type Diff struct {
+	Up   []string
+	Down []string // in the order of execution, so the last change is undone first
+	// Warnings are the differences which could not be migrated by SQLs, e.g.
+	// the changed columns of SQLite, whose tables have to be recreated
+	Warnings []string
+}
+
+// IsEmpty returns true if there is no SQL to migrate, the database matches the
+// structs unless there are warnings
+func (diff *Diff) IsEmpty() bool {
+	return len(diff.Up) == 0
+}
+
+// add adds the SQL to migrate and the SQL to undo it
+func (diff *Diff) add(up, down string) {
+	if down == "" {
+		diff.addAll([]string{up}, nil)
+	} else {
+		diff.addAll([]string{up}, []string{down})
+	}
+}
+
+// addAll adds the SQLs to migrate and the SQLs to undo them
+func (diff *Diff) addAll(ups, downs []string) {
+	diff.Up = append(diff.Up, ups...)
+	diff.Down = append(append([]string{}, downs...), diff.Down...)
+}
+
+// GenerateDiff compares the tables of the structs parsed by the engine with
+// the ones of the database, and returns the SQLs of the dialect of the engine
+// to create the missing tables, to add, alter or drop the columns, the
+// indexes and the foreign keys, and to undo them. The columns and the foreign
+// keys are compared as Sync does, and the changes which could not be migrated
+// by the dialect are reported as the warnings instead.
+func GenerateDiff(engine *xorm.Engine, beans ...interface{}) (*Diff, error) {
+	var (
+		ctx         = context.Background()
+		dialect     = engine.Dialect()
+		diff        Diff
+		beanTables  = make([]*schemas.Table, 0, len(beans))
+		foreignKeys []pendingForeignKey
+	)
+	tables, err := engine.DBMetas()
+	if err != nil {
+		return nil, err
+	}
+	for _, bean := range beans {
+		table, err := engine.TableInfo(bean)
+		if err != nil {
+			return nil, err
+		}
+		beanTables = append(beanTables, table)
+	}
+
+	// the referenced tables are created first
+	for _, i := range schemas.SortTablesByForeignKeys(beanTables) {
+		bean, table := beans[i], beanTables[i]
+		tbName := engine.TableName(bean)
+		tableName := dialects.TableNameWithSchema(dialect, tbName)
+
+		var oriTable *schemas.Table
+		for _, tb := range tables {
+			if strings.EqualFold(tb.Name, tbName) {
+				oriTable = tb
+				break
+			}
+		}
+
+		if oriTable == nil {
+			if err := diffNewTable(ctx, &diff, engine, table, tableName); err != nil {
+				return nil, err
+			}
+			if dialect.URI().DBType != schemas.YDB {
+				for _, fk := range sortedForeignKeys(table) {
+					foreignKeys = append(foreignKeys, pendingForeignKey{tbName, tableName, fk, true})
+				}
+			}
+			continue
+		}
+
+		// the foreign keys are dropped before the columns and the indexes of
+		// them, and the changed ones are re-created after all the tables
+		for _, fk := range sortedForeignKeys(table) {
+			var found, changed *schemas.ForeignKey
+			for _, oriFK := range oriTable.ForeignKeys {
+				if fk.Equal(oriFK) {
+					found = oriFK
+					break
+				}
+				if strings.EqualFold(oriFK.XName(tbName), fk.XName(tbName)) {
+					changed = oriFK
+				}
+			}
+			if found != nil {
+				continue
+			}
+			if changed != nil {
+				drop, create := dialect.DropForeignKeySQL(tableName, changed), dialect.CreateForeignKeySQL(tableName, changed)
+				if drop == "" || create == "" {
+					diff.Warnings = append(diff.Warnings, fmt.Sprintf("Table %s foreign key %s has been changed but could not be dropped", tbName, fk.XName(tbName)))
+					continue
+				}
+				diff.add(drop, create)
+			}
+			foreignKeys = append(foreignKeys, pendingForeignKey{tbName, tableName, fk, false})
+		}
+		for _, name := range sortedForeignKeyNames(oriTable) {
+			oriFK := oriTable.ForeignKeys[name]
+			if hasForeignKey(table, tbName, oriFK) {
+				continue
+			}
+			drop, create := dialect.DropForeignKeySQL(tableName, oriFK), dialect.CreateForeignKeySQL(tableName, oriFK)
+			if drop == "" || create == "" {
+				diff.Warnings = append(diff.Warnings, fmt.Sprintf("Table %s foreign key %s has been removed but could not be dropped", tbName, oriFK.XName(tbName)))
+				continue
+			}
+			diff.add(drop, create)
+		}
+
+		// the indexes are dropped before the columns in them
+		for _, name := range sortedNames(oriTable.Indexes) {
+			index := oriTable.Indexes[name]
+			if _, ok := table.FindIndex(index); ok || oriTable.IsForeignKeyIndex(name) {
+				continue
+			}
+			diff.add(dialect.DropIndexSQL(tableName, index), dialect.CreateIndexSQL(tableName, index))
+		}
+
+		for _, col := range table.Columns() {
+			oriCol := oriTable.GetColumn(col.Name)
+			if oriCol == nil {
+				diff.add(dialect.AddColumnSQL(tableName, col), dialect.DropColumnSQL(tableName, col.Name))
+				continue
+			}
+			changes := dialects.ColumnChanges(dialect, col, oriCol)
+			if unalterable := changes & dialects.UnalterableColumnChanges(dialect); unalterable&dialects.ColumnDefaultChanged != 0 {
+				diff.Warnings = append(diff.Warnings, fmt.Sprintf("Table %s Column %s default has been changed but the default constraint could not be altered", tbName, col.Name))
+				changes &^= unalterable
+			}
+			if changes == 0 {
+				continue
+			}
+			ups := dialect.AlterColumnSQL(tableName, col, changes)
+			downs := dialect.AlterColumnSQL(tableName, oriCol, changes)
+			if len(ups) == 0 || len(downs) == 0 {
+				diff.Warnings = append(diff.Warnings, fmt.Sprintf("Table %s Column %s has been changed but could not be altered", tbName, col.Name))
+				continue
+			}
+			diff.addAll(ups, downs)
+		}
+		for _, name := range oriTable.ColumnsSeq() {
+			if table.GetColumn(name) == nil {
+				diff.add(dialect.DropColumnSQL(tableName, name), dialect.AddColumnSQL(tableName, oriTable.GetColumn(name)))
+			}
+		}
+
+		for _, name := range sortedNames(table.Indexes) {
+			index := table.Indexes[name]
+			if _, ok := oriTable.FindIndex(index); !ok {
+				diff.add(dialect.CreateIndexSQL(tableName, index), dialect.DropIndexSQL(tableName, index))
+			}
+		}
+	}
+
+	// the foreign keys are added after all the tables are created since the
+	// tables may reference each other
+	for _, item := range foreignKeys {
+		create := dialect.CreateForeignKeySQL(item.tableName, item.fk)
+		if create == "" {
+			// the foreign keys of a new table may be created with it
+			if !item.isNewTable {
+				diff.Warnings = append(diff.Warnings, fmt.Sprintf("Table %s foreign key %s could not be added to the existing table", item.tbName, item.fk.XName(item.tbName)))
+			}
+			continue
+		}
+		drop := dialect.DropForeignKeySQL(item.tableName, item.fk)
+		if drop == "" && !item.isNewTable {
+			diff.Warnings = append(diff.Warnings, fmt.Sprintf("Table %s foreign key %s could not be dropped by the rollback", item.tbName, item.fk.XName(item.tbName)))
+		}
+		diff.add(create, drop)
+	}
+	return &diff, nil
+}
+
+// pendingForeignKey represents a foreign key to be added after the tables
+type pendingForeignKey struct {
+	tbName     string
+	tableName  string // with the schema
+	fk         *schemas.ForeignKey
+	isNewTable bool // the foreign keys of a new table may be created with it
+}
+
+// hasForeignKey returns true if the table has the foreign key of the database
+// or the one of the same name
+func hasForeignKey(table *schemas.Table, tbName string, oriFK *schemas.ForeignKey) bool {
+	for _, fk := range table.ForeignKeys {
+		if fk.Equal(oriFK) || strings.EqualFold(oriFK.XName(tbName), fk.XName(tbName)) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedForeignKeys returns the foreign keys of the table sorted by name
+func sortedForeignKeys(table *schemas.Table) []*schemas.ForeignKey {
+	names := sortedForeignKeyNames(table)
+	fks := make([]*schemas.ForeignKey, 0, len(names))
+	for _, name := range names {
+		fks = append(fks, table.ForeignKeys[name])
+	}
+	return fks
+}
+
+func sortedForeignKeyNames(table *schemas.Table) []string {
+	names := make([]string, 0, len(table.ForeignKeys))
+	for name := range table.ForeignKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// diffNewTable adds the SQLs to create the table with its indexes
+func diffNewTable(ctx context.Context, diff *Diff, engine *xorm.Engine, table *schemas.Table, tableName string) error {
+	dialect := engine.Dialect()
+	if table.AutoIncrement != "" && dialect.Features().AutoincrMode == dialects.SequenceAutoincrMode {
+		createSeq, err := dialect.CreateSequenceSQL(ctx, engine.DB(), utils.SeqName(tableName))
+		if err != nil {
+			return err
+		}
+		dropSeq, err := dialect.DropSequenceSQL(utils.SeqName(tableName))
+		if err != nil {
+			return err
+		}
+		diff.add(createSeq, dropSeq)
+	}
+
+	createTable, _, err := dialect.CreateTableSQL(ctx, engine.DB(), table, tableName)
+	if err != nil {
+		return err
+	}
+	dropTable, _ := dialect.DropTableSQL(tableName)
+	diff.add(createTable, dropTable)
+
+	// YDB declares the secondary indexes within CREATE TABLE
+	if dialect.URI().DBType == schemas.YDB {
+		return nil
+	}
+	for _, name := range sortedNames(table.Indexes) {
+		// the indexes are dropped with the table
+		diff.add(dialect.CreateIndexSQL(tableName, table.Indexes[name]), "")
+	}
+	return nil
+}
+
+func sortedNames(indexes map[string]*schemas.Index) []string {
+	names := make([]string, 0, len(indexes))
+	for name := range indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WriteSQL writes the up and the down SQLs as the SQL migration files
+func (diff *Diff) WriteSQL(up, down io.Writer) error {
+	if err := writeStatements(up, diff.Up); err != nil {
+		return err
+	}
+	return writeStatements(down, diff.Down)
+}
+
+func writeStatements(w io.Writer, sqls []string) error {
+	for _, sql := range sqls {
+		if _, err := fmt.Fprintf(w, "%s;\n", sql); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveSQLFiles writes the SQL migration files named id.up.sql and
+// id.down.sql into the directory, which could be loaded by LoadSQLMigrations
+func (diff *Diff) SaveSQLFiles(dir, id string) error {
+	var up, down bytes.Buffer
+	if err := diff.WriteSQL(&up, &down); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, id+".up.sql"), up.Bytes(), 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, id+".down.sql"), down.Bytes(), 0o644)
+}
+
+// WriteGo writes a Go file of the package which declares the migration of
+// the id as the variable
+func (diff *Diff) WriteGo(w io.Writer, pkg, varName, id string) error {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// The migration is generated from the differences between the structs and the database.\n\npackage %s\n\n", pkg)
+	b.WriteString("import (\n\t\"xorm.io/xorm\"\n\t\"xorm.io/xorm/migrate\"\n)\n\n")
+	fmt.Fprintf(&b, "var %s = &migrate.Migration{\n\tID: %s,\n", varName, strconv.Quote(id))
+	writeGoFunc(&b, "Migrate", diff.Up)
+	writeGoFunc(&b, "Rollback", diff.Down)
+	b.WriteString("}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+func writeGoFunc(b *bytes.Buffer, field string, sqls []string) {
+	fmt.Fprintf(b, "%s: func(engine *xorm.Engine) error {\n", field)
+	b.WriteString("for _, sql := range []string{\n")
+	for _, sql := range sqls {
+		fmt.Fprintf(b, "%s,\n", strconv.Quote(sql))
+	}
+	b.WriteString("} {\nif _, err := engine.Exec(sql); err != nil {\nreturn err\n}\n}\nreturn nil\n},\n")
+}
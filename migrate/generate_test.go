@@ -0,0 +1,168 @@
+package migrate
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type GenerateUser struct {
+	ID       int64
+	Name     string `xorm:"varchar(50) index"`
+	Obsolete string `xorm:"varchar(20)"`
+}
+
+func (GenerateUser) TableName() string {
+	return "generate_user"
+}
+
+type GenerateUser2 struct {
+	ID    int64
+	Name  string `xorm:"varchar(50)"`
+	Email string `xorm:"varchar(100) unique"`
+}
+
+func (GenerateUser2) TableName() string {
+	return "generate_user"
+}
+
+type GenerateUser3 struct {
+	ID       int64
+	Name     string `xorm:"varchar(50) index notnull"`
+	Obsolete string `xorm:"varchar(20)"`
+}
+
+func (GenerateUser3) TableName() string {
+	return "generate_user"
+}
+
+type GeneratePet struct {
+	ID   int64
+	Name string `xorm:"varchar(50) index"`
+}
+
+func TestGenerateDiff(t *testing.T) {
+	db := newTestEngine(t)
+	defer db.Close()
+	assert.NoError(t, db.Sync(new(GenerateUser)))
+
+	diff, err := GenerateDiff(db, new(GenerateUser2), new(GeneratePet))
+	assert.NoError(t, err)
+	assert.False(t, diff.IsEmpty())
+	up := strings.Join(diff.Up, "\n")
+	assert.Contains(t, up, "DROP INDEX `IDX_generate_user_name`")
+	assert.Contains(t, up, "ADD `email`")
+	assert.Contains(t, up, "DROP COLUMN `obsolete`")
+	assert.Contains(t, up, "CREATE UNIQUE INDEX `UQE_generate_user_email`")
+	assert.Contains(t, up, "CREATE TABLE IF NOT EXISTS `generate_pet`")
+
+	var goSrc bytes.Buffer
+	assert.NoError(t, diff.WriteGo(&goSrc, "migrations", "migration0002", "0002_generated"))
+	assert.Contains(t, goSrc.String(), "package migrations")
+	assert.Contains(t, goSrc.String(), `ID: "0002_generated",`)
+
+	dir := t.TempDir()
+	assert.NoError(t, diff.SaveSQLFiles(dir, "0002_generated"))
+	migrations, err := LoadSQLMigrations(os.DirFS(dir))
+	assert.NoError(t, err)
+	if !assert.Len(t, migrations, 1) {
+		return
+	}
+
+	m := New(db, DefaultOptions, migrations)
+	assert.NoError(t, m.Migrate())
+	diff, err = GenerateDiff(db, new(GenerateUser2), new(GeneratePet))
+	assert.NoError(t, err)
+	assert.True(t, diff.IsEmpty(), strings.Join(diff.Up, "\n"))
+
+	// the down migration undoes the changes
+	assert.NoError(t, m.RollbackLast())
+	diff, err = GenerateDiff(db, new(GenerateUser))
+	assert.NoError(t, err)
+	assert.True(t, diff.IsEmpty(), strings.Join(diff.Up, "\n"))
+	exists, err := db.IsTableExist(new(GeneratePet))
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestGenerateDiffChangedColumn(t *testing.T) {
+	db := newTestEngine(t)
+	defer db.Close()
+	assert.NoError(t, db.Sync(new(GenerateUser)))
+
+	// SQLite could not alter a column without recreating the table
+	diff, err := GenerateDiff(db, new(GenerateUser3))
+	assert.NoError(t, err)
+	assert.True(t, diff.IsEmpty(), strings.Join(diff.Up, "\n"))
+	assert.Empty(t, diff.Down)
+	if assert.Len(t, diff.Warnings, 1) {
+		assert.Contains(t, diff.Warnings[0], "generate_user Column name")
+	}
+}
+
+type GenerateOwner struct {
+	ID   int64
+	Name string `xorm:"varchar(50)"`
+}
+
+type GenerateCar struct {
+	ID      int64
+	OwnerID int64 `xorm:"'owner_id' fk(generate_owner.id)"`
+}
+
+func (GenerateCar) TableName() string {
+	return "generate_car"
+}
+
+type GenerateCar2 struct {
+	ID      int64
+	OwnerID int64 `xorm:"'owner_id'"`
+}
+
+func (GenerateCar2) TableName() string {
+	return "generate_car"
+}
+
+func TestGenerateDiffForeignKeys(t *testing.T) {
+	db := newTestEngine(t)
+	defer db.Close()
+
+	// the referenced table is created first and SQLite declares the foreign
+	// keys within CREATE TABLE
+	diff, err := GenerateDiff(db, new(GenerateCar), new(GenerateOwner))
+	assert.NoError(t, err)
+	assert.Empty(t, diff.Warnings)
+	if assert.Len(t, diff.Up, 2) {
+		assert.Contains(t, diff.Up[0], "CREATE TABLE IF NOT EXISTS `generate_owner`")
+		assert.Contains(t, diff.Up[1], "REFERENCES `generate_owner`")
+	}
+	for _, sql := range diff.Up {
+		_, err := db.Exec(sql)
+		assert.NoError(t, err)
+	}
+	diff, err = GenerateDiff(db, new(GenerateCar), new(GenerateOwner))
+	assert.NoError(t, err)
+	assert.True(t, diff.IsEmpty(), strings.Join(diff.Up, "\n"))
+	assert.Empty(t, diff.Warnings)
+
+	// SQLite could not drop a foreign key without recreating the table
+	diff, err = GenerateDiff(db, new(GenerateCar2), new(GenerateOwner))
+	assert.NoError(t, err)
+	assert.True(t, diff.IsEmpty(), strings.Join(diff.Up, "\n"))
+	if assert.Len(t, diff.Warnings, 1) {
+		assert.Contains(t, diff.Warnings[0], "generate_car foreign key")
+	}
+
+	// nor add one to an existing table
+	assert.NoError(t, db.DropTables(new(GenerateCar)))
+	assert.NoError(t, db.Sync(new(GenerateCar2)))
+	diff, err = GenerateDiff(db, new(GenerateCar), new(GenerateOwner))
+	assert.NoError(t, err)
+	assert.True(t, diff.IsEmpty(), strings.Join(diff.Up, "\n"))
+	if assert.Len(t, diff.Warnings, 1) {
+		assert.Contains(t, diff.Warnings[0], "could not be added to the existing table")
+	}
+}
@@ -0,0 +1,163 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"xorm.io/xorm/schemas"
+)
+
+// DefaultLockTimeout is the default time to wait for the lock of the migrations
+const DefaultLockTimeout = 10 * time.Minute
+
+// ErrLockTimeout is returned when the migrations are locked by another process
+// longer than the lock timeout
+var ErrLockTimeout = errors.New("Timeout to wait for the lock of migrations")
+
+// ErrLockConnection is returned when the advisory lock could not hold a
+// connection of its own, since the migrations would wait for it forever
+var ErrLockConnection = errors.New("The lock of migrations needs a connection besides the migrations, MaxOpenConns should be at least 2")
+
+// lockRetryInterval is the interval to try to get the lock again
+var lockRetryInterval = time.Second
+
+// withLock runs f while holding the lock of the migrations, MySQL and
+// PostgreSQL use the advisory locks and the other databases insert a row into
+// the lock table. A dry run takes no lock.
+func (m *Migrate) withLock(f func() error) error {
+	if m.options.DryRun {
+		return f()
+	}
+
+	var (
+		unlock func() error
+		err    error
+	)
+	switch m.db.Dialect().URI().DBType {
+	case schemas.MYSQL:
+		unlock, err = m.advisoryLock("SELECT GET_LOCK(?, 0)", "SELECT RELEASE_LOCK(?)", "xorm_migrate_"+m.options.TableName)
+	case schemas.POSTGRES:
+		unlock, err = m.advisoryLock("SELECT pg_try_advisory_lock($1)", "SELECT pg_advisory_unlock($1)", m.lockKey())
+	default:
+		unlock, err = m.rowLock()
+	}
+	if err != nil {
+		return err
+	}
+
+	err = f()
+	if unlockErr := unlock(); err == nil {
+		err = unlockErr
+	}
+	return err
+}
+
+// lockKey returns the key of the advisory lock of PostgreSQL
+func (m *Migrate) lockKey() int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("xorm_migrate_" + m.options.TableName))
+	return int64(h.Sum64())
+}
+
+// waitLock tries to get the lock until the lock timeout
+func (m *Migrate) waitLock(tryLock func() (bool, error)) error {
+	deadline := time.Now().Add(m.options.LockTimeout)
+	for {
+		locked, err := tryLock()
+		if err != nil || locked {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return ErrLockTimeout
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// advisoryLock gets a session level lock, so the lock and the unlock have to
+// be executed on the same connection which is held until unlocked. The
+// migrations run on the other connections of the engine, so it fails fast if
+// there is only one.
+func (m *Migrate) advisoryLock(lockSQL, unlockSQL string, key interface{}) (func() error, error) {
+	if m.db.DB().Stats().MaxOpenConnections == 1 {
+		return nil, ErrLockConnection
+	}
+
+	ctx := context.Background()
+	conn, err := m.db.DB().Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.waitLock(func() (bool, error) {
+		var locked sql.NullBool
+		if err := conn.QueryRowContext(ctx, lockSQL, key).Scan(&locked); err != nil {
+			return false, err
+		}
+		return locked.Valid && locked.Bool, nil
+	}); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return func() error {
+		defer conn.Close()
+		_, err := conn.ExecContext(ctx, unlockSQL, key)
+		return err
+	}, nil
+}
+
+// rowLock gets the lock by inserting the row into the lock table. The row is
+// left if the process is killed while migrating, it's removed when it's older
+// than the lock expiration or it should be deleted manually.
+func (m *Migrate) rowLock() (func() error, error) {
+	tableName := m.options.TableName + "_lock"
+	idCol := schemas.NewColumn("id", "", schemas.SQLType{Name: schemas.Int}, 0, 0, false)
+	idCol.IsPrimaryKey = true
+	lockedAtCol := schemas.NewColumn("locked_at", "", schemas.SQLType{Name: schemas.DateTime}, 0, 0, true)
+	if err := m.createTableIfNotExists(tableName, idCol, lockedAtCol); err != nil {
+		return nil, err
+	}
+
+	quote := m.db.Quote
+	insertLock := func() error {
+		_, err := m.db.Exec(fmt.Sprintf("INSERT INTO %s (%s, %s) VALUES (?, ?)", quote(tableName), quote("id"), quote("locked_at")), 1, time.Now())
+		return err
+	}
+	if err := m.waitLock(func() (bool, error) {
+		err := insertLock()
+		if err == nil {
+			return true, nil
+		}
+		// the lock is held by another process if the row exists
+		locked, existErr := m.db.SQL(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s = ?", quote(tableName), quote("id")), 1).Count()
+		if existErr != nil || locked == 0 {
+			return false, err
+		}
+		if m.options.LockExpiration <= 0 {
+			return false, nil
+		}
+
+		// the process holding the expired lock is considered crashed
+		res, err := m.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s = ? AND %s < ?", quote(tableName), quote("id"), quote("locked_at")),
+			1, time.Now().Add(-m.options.LockExpiration))
+		if err != nil {
+			return false, err
+		}
+		if expired, err := res.RowsAffected(); err != nil || expired == 0 {
+			return false, err
+		}
+		return insertLock() == nil, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		_, err := m.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s = ?", quote(tableName), quote("id")), 1)
+		return err
+	}, nil
+}
@@ -1,10 +1,17 @@
 package migrate
 
 import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"xorm.io/xorm"
+	"xorm.io/xorm/schemas"
 )
 
 // MigrateFunc is the func signature for migrating.
@@ -22,6 +29,30 @@ type Options struct {
 	TableName string
 	// IDColumnName is the name of column where the migration id will be stored.
 	IDColumnName string
+	// ChecksumColumnName is the name of column where the checksum of the
+	// migration will be stored, "checksum" if empty.
+	ChecksumColumnName string
+	// AppliedAtColumnName is the name of column where the time the migration
+	// applied will be stored, "applied_at" if empty.
+	AppliedAtColumnName string
+	// LockTimeout is the time to wait for the other process migrating the
+	// same database, DefaultLockTimeout if zero. The advisory locks of MySQL
+	// and PostgreSQL hold a connection, so ErrLockConnection is returned if
+	// the MaxOpenConns of the engine is 1.
+	LockTimeout time.Duration
+	// LockExpiration is the age after which the lock row is considered to be
+	// left by a crashed process and removed, it never expires if zero. The
+	// lock row is only used by the databases without advisory locks, i.e. not
+	// MySQL or PostgreSQL, and it could also be removed manually by deleting
+	// the row of the table named TableName + "_lock".
+	LockExpiration time.Duration
+	// AllowOutOfOrder allows to run a migration even if the later ones have
+	// been applied.
+	AllowOutOfOrder bool
+	// DryRun will not run or roll back any migration but record the steps
+	// which would be done, neither the migration table nor the lock is
+	// created so that the database is not changed.
+	DryRun bool
 }
 
 // Migration represents a database migration (a modification to be made on the database).
@@ -32,6 +63,25 @@ type Migration struct {
 	Migrate MigrateFunc
 	// Rollback will be executed on rollback. Can be nil.
 	Rollback RollbackFunc
+	// Checksum is the checksum of the content of the migration, it's an error
+	// if the checksum is changed after the migration applied. Can be empty.
+	Checksum string
+
+	// the scripts of a SQL migration by the dialects, which are run in the
+	// same transaction as the update of the migration table
+	upScripts, downScripts map[string]string
+}
+
+// Checksum returns the checksum of the content of a migration
+func Checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Step represents a migration run or rolled back
+type Step struct {
+	ID       string
+	Rollback bool
 }
 
 // Migrate represents a collection of all migrations of a database schemas.
@@ -40,13 +90,17 @@ type Migrate struct {
 	options    *Options
 	migrations []*Migration
 	initSchema InitSchemaFunc
+	steps      []Step
 }
 
 var (
 	// DefaultOptions can be used if you don't want to think about options.
 	DefaultOptions = &Options{
-		TableName:    "migrations",
-		IDColumnName: "id",
+		TableName:           "migrations",
+		IDColumnName:        "id",
+		ChecksumColumnName:  "checksum",
+		AppliedAtColumnName: "applied_at",
+		LockTimeout:         DefaultLockTimeout,
 	}
 
 	// ErrRollbackImpossible is returned when trying to rollback a migration
@@ -62,13 +116,35 @@ var (
 	// ErrNoRunnedMigration is returned when any runned migration was found while
 	// running RollbackLast
 	ErrNoRunnedMigration = errors.New("Could not find last runned migration")
+
+	// ErrMigrationOutOfOrder is returned when a migration has not been applied
+	// but the later ones have been
+	ErrMigrationOutOfOrder = errors.New("Migration out of order")
+
+	// ErrMigrationModified is returned when the checksum of an applied
+	// migration has been changed
+	ErrMigrationModified = errors.New("Migration modified after applied")
+
+	// ErrUnknownMigration is returned when the ID of the target migration is
+	// not defined
+	ErrUnknownMigration = errors.New("Unknown migration")
 )
 
 // New returns a new Gormigrate.
 func New(db *xorm.Engine, options *Options, migrations []*Migration) *Migrate {
+	opts := *options
+	if opts.ChecksumColumnName == "" {
+		opts.ChecksumColumnName = "checksum"
+	}
+	if opts.AppliedAtColumnName == "" {
+		opts.AppliedAtColumnName = "applied_at"
+	}
+	if opts.LockTimeout <= 0 {
+		opts.LockTimeout = DefaultLockTimeout
+	}
 	return &Migrate{
 		db:         db,
-		options:    options,
+		options:    &opts,
 		migrations: migrations,
 	}
 }
@@ -81,22 +157,73 @@ func (m *Migrate) InitSchema(initSchema InitSchemaFunc) {
 	m.initSchema = initSchema
 }
 
-// Migrate executes all migrations that did not run yet.
+// Migrate executes all migrations that did not run yet. The migrations are
+// locked against the other processes migrating the same database, and it's an
+// error if a migration is out of order or has been modified after applied.
 func (m *Migrate) Migrate() error {
-	if err := m.createMigrationTableIfNotExists(); err != nil {
+	return m.migrateTo(len(m.migrations) - 1)
+}
+
+// MigrateTo executes the migrations that did not run yet until the migration
+// of the id, which is included.
+func (m *Migrate) MigrateTo(id string) error {
+	last, err := m.migrationIndex(id)
+	if err != nil {
 		return err
 	}
+	return m.migrateTo(last)
+}
+
+// Steps returns the migrations run or rolled back by the last call, which are
+// only planned if it's a dry run
+func (m *Migrate) Steps() []Step {
+	return m.steps
+}
 
-	if m.initSchema != nil && m.isFirstRun() {
-		return m.runInitSchema()
+func (m *Migrate) migrationIndex(id string) (int, error) {
+	for i, migration := range m.migrations {
+		if migration.ID == id {
+			return i, nil
+		}
 	}
+	return -1, fmt.Errorf("%w: %s", ErrUnknownMigration, id)
+}
 
-	for _, migration := range m.migrations {
-		if err := m.runMigration(migration); err != nil {
+func (m *Migrate) migrateTo(last int) error {
+	m.steps = nil
+	return m.withLock(func() error {
+		if err := m.createMigrationTableIfNotExists(); err != nil {
 			return err
 		}
-	}
-	return nil
+
+		// the schema is initialized if all the migrations are to be applied
+		if m.initSchema != nil && last == len(m.migrations)-1 && m.isFirstRun() {
+			return m.runInitSchema()
+		}
+
+		applied, err := m.appliedMigrations()
+		if err != nil {
+			return err
+		}
+		if err := m.checkMigrations(applied); err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations[:last+1] {
+			appliedMig, ok := applied[migration.ID]
+			if !ok {
+				if err := m.runMigration(migration); err != nil {
+					return err
+				}
+			} else if appliedMig.Checksum == "" && migration.Checksum != "" && !m.options.DryRun {
+				// the migration was applied before the checksums recorded
+				if err := m.updateChecksum(migration); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
 }
 
 // RollbackLast undo the last migration
@@ -105,22 +232,55 @@ func (m *Migrate) RollbackLast() error {
 		return ErrNoMigrationDefined
 	}
 
-	lastRunnedMigration, err := m.getLastRunnedMigration()
+	m.steps = nil
+	return m.withLock(func() error {
+		lastRunnedMigration, err := m.getLastRunnedMigration()
+		if err != nil {
+			return err
+		}
+
+		return m.rollbackMigration(lastRunnedMigration)
+	})
+}
+
+// RollbackTo undo all the applied migrations after the migration of the id,
+// which is kept, from the last one.
+func (m *Migrate) RollbackTo(id string) error {
+	target, err := m.migrationIndex(id)
 	if err != nil {
 		return err
 	}
 
-	return m.RollbackMigration(lastRunnedMigration)
+	m.steps = nil
+	return m.withLock(func() error {
+		if err := m.createMigrationTableIfNotExists(); err != nil {
+			return err
+		}
+		applied, err := m.appliedMigrations()
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i > target; i-- {
+			if _, ok := applied[m.migrations[i].ID]; !ok {
+				continue
+			}
+			if err := m.rollbackMigration(m.migrations[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func (m *Migrate) getLastRunnedMigration() (*Migration, error) {
+	applied, err := m.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
 	for i := len(m.migrations) - 1; i >= 0; i-- {
-		migration := m.migrations[i]
-		run, err := m.migrationDidRun(migration)
-		if err != nil {
-			return nil, err
-		} else if run {
-			return migration, nil
+		if _, ok := applied[m.migrations[i].ID]; ok {
+			return m.migrations[i], nil
 		}
 	}
 	return nil, ErrNoRunnedMigration
@@ -128,28 +288,49 @@ func (m *Migrate) getLastRunnedMigration() (*Migration, error) {
 
 // RollbackMigration undo a migration.
 func (m *Migrate) RollbackMigration(mig *Migration) error {
+	m.steps = nil
+	return m.withLock(func() error {
+		return m.rollbackMigration(mig)
+	})
+}
+
+func (m *Migrate) rollbackMigration(mig *Migration) error {
 	if mig.Rollback == nil {
 		return ErrRollbackImpossible
 	}
 
-	if err := mig.Rollback(m.db); err != nil {
-		return err
+	m.steps = append(m.steps, Step{ID: mig.ID, Rollback: true})
+	if m.options.DryRun {
+		return nil
 	}
 
-	sql := fmt.Sprintf("DELETE FROM %s WHERE %s = ?", m.options.TableName, m.options.IDColumnName)
-	if _, err := m.db.Exec(sql, mig.ID); err != nil {
+	if mig.downScripts != nil {
+		return execSQLScript(m.db, mig.ID, mig.downScripts, func(session *xorm.Session) error {
+			return m.deleteMigration(session, mig)
+		})
+	}
+
+	if err := mig.Rollback(m.db); err != nil {
 		return err
 	}
-	return nil
+	return m.deleteMigration(m.db, mig)
 }
 
 func (m *Migrate) runInitSchema() error {
+	// all the migrations are considered applied with the schema
+	for _, migration := range m.migrations {
+		m.steps = append(m.steps, Step{ID: migration.ID})
+	}
+	if m.options.DryRun {
+		return nil
+	}
+
 	if err := m.initSchema(m.db); err != nil {
 		return err
 	}
 
 	for _, migration := range m.migrations {
-		if err := m.insertMigration(migration.ID); err != nil {
+		if err := m.insertMigration(m.db, migration); err != nil {
 			return err
 		}
 	}
@@ -162,53 +343,201 @@ func (m *Migrate) runMigration(migration *Migration) error {
 		return ErrMissingID
 	}
 
-	run, err := m.migrationDidRun(migration)
-	if err != nil {
+	m.steps = append(m.steps, Step{ID: migration.ID})
+	if m.options.DryRun {
+		return nil
+	}
+
+	if migration.upScripts != nil {
+		return execSQLScript(m.db, migration.ID, migration.upScripts, func(session *xorm.Session) error {
+			return m.insertMigration(session, migration)
+		})
+	}
+
+	if err := migration.Migrate(m.db); err != nil {
 		return err
 	}
 
-	if !run {
-		if err := migration.Migrate(m.db); err != nil {
-			return err
+	return m.insertMigration(m.db, migration)
+}
+
+// checkMigrations returns an error if a migration is missing the ID, is out
+// of order or has been modified after applied
+func (m *Migrate) checkMigrations(applied map[string]*appliedMigration) error {
+	var pendingID string
+	for _, migration := range m.migrations {
+		if len(migration.ID) == 0 {
+			return ErrMissingID
 		}
 
-		if err := m.insertMigration(migration.ID); err != nil {
-			return err
+		appliedMig, ok := applied[migration.ID]
+		if !ok {
+			if pendingID == "" {
+				pendingID = migration.ID
+			}
+			continue
+		}
+		if pendingID != "" && !m.options.AllowOutOfOrder {
+			return fmt.Errorf("%w: migration %s has not been applied but the later migration %s has been applied",
+				ErrMigrationOutOfOrder, pendingID, migration.ID)
+		}
+		if appliedMig.isModified(migration) {
+			return fmt.Errorf("%w: migration %s was applied with checksum %s but the checksum is %s now",
+				ErrMigrationModified, migration.ID, appliedMig.Checksum, migration.Checksum)
 		}
 	}
 	return nil
 }
 
+func (m *Migrate) migrationColumns() []*schemas.Column {
+	idCol := schemas.NewColumn(m.options.IDColumnName, "", schemas.SQLType{Name: schemas.Varchar}, 255, 0, false)
+	idCol.IsPrimaryKey = true
+	return []*schemas.Column{
+		idCol,
+		schemas.NewColumn(m.options.ChecksumColumnName, "", schemas.SQLType{Name: schemas.Varchar}, 64, 0, true),
+		schemas.NewColumn(m.options.AppliedAtColumnName, "", schemas.SQLType{Name: schemas.DateTime}, 0, 0, true),
+	}
+}
+
 func (m *Migrate) createMigrationTableIfNotExists() error {
+	if m.options.DryRun {
+		return nil
+	}
+
 	exists, err := m.db.IsTableExist(m.options.TableName)
 	if err != nil {
 		return err
 	}
-	if exists {
-		return nil
+	if !exists {
+		return m.createTable(m.options.TableName, m.migrationColumns()...)
 	}
 
-	sql := fmt.Sprintf("CREATE TABLE %s (%s VARCHAR(255) PRIMARY KEY)", m.options.TableName, m.options.IDColumnName)
-	if _, err := m.db.Exec(sql); err != nil {
+	// the migration table created by the former versions has the id column only
+	dialect := m.db.Dialect()
+	_, cols, err := dialect.GetColumns(m.db.DB(), context.Background(), m.options.TableName)
+	if err != nil {
 		return err
 	}
+	for _, col := range m.migrationColumns()[1:] {
+		if _, ok := cols[col.Name]; ok {
+			continue
+		}
+		if _, err := m.db.Exec(dialect.AddColumnSQL(m.options.TableName, col)); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (m *Migrate) migrationDidRun(mig *Migration) (bool, error) {
-	count, err := m.db.SQL(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s = ?", m.options.TableName, m.options.IDColumnName), mig.ID).Count()
-	return count > 0, err
+// createTableIfNotExists creates a table of the columns if it does not exist,
+// CREATE TABLE IF NOT EXISTS is not supported by all the databases
+func (m *Migrate) createTableIfNotExists(tableName string, cols ...*schemas.Column) error {
+	exists, err := m.db.IsTableExist(tableName)
+	if err != nil || exists {
+		return err
+	}
+	return m.createTable(tableName, cols...)
+}
+
+// createTable creates a table of the columns
+func (m *Migrate) createTable(tableName string, cols ...*schemas.Column) error {
+	table := schemas.NewEmptyTable()
+	table.Name = tableName
+	for _, col := range cols {
+		table.AddColumn(col)
+		if col.IsPrimaryKey {
+			table.PrimaryKeys = append(table.PrimaryKeys, col.Name)
+		}
+	}
+
+	sql, _, err := m.db.Dialect().CreateTableSQL(context.Background(), m.db.DB(), table, tableName)
+	if err != nil {
+		return err
+	}
+	_, err = m.db.Exec(sql)
+	return err
+}
+
+// appliedMigration represents a row of the migration table
+type appliedMigration struct {
+	ID        string    `xorm:"'id'"`
+	Checksum  string    `xorm:"'checksum'"`
+	AppliedAt time.Time `xorm:"'applied_at'"`
+}
+
+// isModified returns true if the checksum of the migration is different from
+// the applied one, both of which are recorded
+func (applied *appliedMigration) isModified(mig *Migration) bool {
+	return applied.Checksum != "" && mig.Checksum != "" && applied.Checksum != mig.Checksum
+}
+
+// appliedMigrations returns the applied migrations by IDs, none if the
+// migration table does not exist. The columns missing in the table created by
+// the former versions are left zero.
+func (m *Migrate) appliedMigrations() (map[string]*appliedMigration, error) {
+	exists, err := m.db.IsTableExist(m.options.TableName)
+	if err != nil {
+		return nil, err
+	} else if !exists {
+		return map[string]*appliedMigration{}, nil
+	}
+	_, cols, err := m.db.Dialect().GetColumns(m.db.DB(), context.Background(), m.options.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := m.db.Quote
+	fields := []string{fmt.Sprintf("%s AS %s", quote(m.options.IDColumnName), quote("id"))}
+	for _, field := range [][2]string{
+		{m.options.ChecksumColumnName, "checksum"},
+		{m.options.AppliedAtColumnName, "applied_at"},
+	} {
+		if _, ok := cols[field[0]]; ok {
+			fields = append(fields, fmt.Sprintf("%s AS %s", quote(field[0]), quote(field[1])))
+		}
+	}
+
+	var rows []*appliedMigration
+	if err := m.db.SQL(fmt.Sprintf("SELECT %s FROM %s",
+		strings.Join(fields, ", "), quote(m.options.TableName))).Find(&rows); err != nil {
+		return nil, err
+	}
+
+	applied := make(map[string]*appliedMigration, len(rows))
+	for _, row := range rows {
+		applied[row.ID] = row
+	}
+	return applied, nil
 }
 
 func (m *Migrate) isFirstRun() bool {
-	row := m.db.DB().QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s", m.options.TableName))
+	row := m.db.DB().QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s", m.db.Quote(m.options.TableName)))
 	var count int
 	_ = row.Scan(&count)
 	return count == 0
 }
 
-func (m *Migrate) insertMigration(id string) error {
-	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES (?)", m.options.TableName, m.options.IDColumnName)
-	_, err := m.db.Exec(sql, id)
+// executor executes the SQL on an engine or a session
+type executor interface {
+	Exec(sqlOrArgs ...interface{}) (sql.Result, error)
+}
+
+func (m *Migrate) insertMigration(db executor, mig *Migration) error {
+	sql := fmt.Sprintf("INSERT INTO %s (%s, %s, %s) VALUES (?, ?, ?)", m.db.Quote(m.options.TableName),
+		m.db.Quote(m.options.IDColumnName), m.db.Quote(m.options.ChecksumColumnName), m.db.Quote(m.options.AppliedAtColumnName))
+	_, err := db.Exec(sql, mig.ID, mig.Checksum, time.Now())
+	return err
+}
+
+func (m *Migrate) deleteMigration(db executor, mig *Migration) error {
+	sql := fmt.Sprintf("DELETE FROM %s WHERE %s = ?", m.db.Quote(m.options.TableName), m.db.Quote(m.options.IDColumnName))
+	_, err := db.Exec(sql, mig.ID)
+	return err
+}
+
+func (m *Migrate) updateChecksum(mig *Migration) error {
+	sql := fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ?", m.db.Quote(m.options.TableName),
+		m.db.Quote(m.options.ChecksumColumnName), m.db.Quote(m.options.IDColumnName))
+	_, err := m.db.Exec(sql, mig.Checksum, mig.ID)
 	return err
 }
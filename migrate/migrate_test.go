@@ -1,10 +1,12 @@
 package migrate
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"testing"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/assert"
@@ -145,3 +147,224 @@ func tableCount(db *xorm.Engine, tableName string) (count int) {
 	_ = row.Scan(&count)
 	return
 }
+
+func newTestEngine(t *testing.T) *xorm.Engine {
+	_ = os.Remove(dbName)
+
+	db, err := xorm.NewEngine("sqlite3", dbName)
+	assert.NoError(t, err)
+	assert.NoError(t, db.DB().Ping())
+	return db
+}
+
+func noopMigration(id string) *Migration {
+	return &Migration{
+		ID:       id,
+		Checksum: Checksum([]byte(id)),
+		Migrate: func(tx *xorm.Engine) error {
+			return nil
+		},
+	}
+}
+
+func TestMigrationChecksum(t *testing.T) {
+	db := newTestEngine(t)
+	defer db.Close()
+
+	m := New(db, DefaultOptions, []*Migration{noopMigration("1"), noopMigration("2")})
+	assert.NoError(t, m.Migrate())
+
+	var appliedAt time.Time
+	has, err := db.SQL("SELECT applied_at FROM migrations WHERE id = ?", "1").Get(&appliedAt)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.False(t, appliedAt.IsZero())
+
+	modified := noopMigration("2")
+	modified.Checksum = Checksum([]byte("modified"))
+	m = New(db, DefaultOptions, []*Migration{noopMigration("1"), modified})
+	assert.ErrorIs(t, m.Migrate(), ErrMigrationModified)
+}
+
+func TestMigrationOutOfOrder(t *testing.T) {
+	db := newTestEngine(t)
+	defer db.Close()
+
+	m := New(db, DefaultOptions, []*Migration{noopMigration("1"), noopMigration("3")})
+	assert.NoError(t, m.Migrate())
+
+	var run bool
+	migration := noopMigration("2")
+	migration.Migrate = func(tx *xorm.Engine) error {
+		run = true
+		return nil
+	}
+	migrations := []*Migration{noopMigration("1"), migration, noopMigration("3")}
+	m = New(db, DefaultOptions, migrations)
+	assert.ErrorIs(t, m.Migrate(), ErrMigrationOutOfOrder)
+	assert.False(t, run)
+
+	options := *DefaultOptions
+	options.AllowOutOfOrder = true
+	m = New(db, &options, migrations)
+	assert.NoError(t, m.Migrate())
+	assert.True(t, run)
+	assert.Equal(t, 3, tableCount(db, "migrations"))
+}
+
+func TestMigrationTableUpgrade(t *testing.T) {
+	db := newTestEngine(t)
+	defer db.Close()
+
+	// the migration table of the former versions
+	_, err := db.Exec("CREATE TABLE migrations (id VARCHAR(255) PRIMARY KEY)")
+	assert.NoError(t, err)
+	_, err = db.Exec("INSERT INTO migrations (id) VALUES (?)", "1")
+	assert.NoError(t, err)
+
+	m := New(db, &Options{TableName: "migrations", IDColumnName: "id"}, []*Migration{noopMigration("1"), noopMigration("2")})
+	assert.NoError(t, m.Migrate())
+
+	var checksum string
+	has, err := db.SQL("SELECT checksum FROM migrations WHERE id = ?", "1").Get(&checksum)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, Checksum([]byte("1")), checksum)
+	assert.Equal(t, 2, tableCount(db, "migrations"))
+}
+
+func TestStatusAndDryRunWithoutTables(t *testing.T) {
+	db := newTestEngine(t)
+	defer db.Close()
+
+	options := *DefaultOptions
+	options.DryRun = true
+	m := New(db, &options, []*Migration{noopMigration("1"), noopMigration("2")})
+
+	statuses, err := m.Status()
+	assert.NoError(t, err)
+	assert.Len(t, statuses, 2)
+	assert.False(t, statuses[0].Applied)
+	assert.False(t, statuses[1].Applied)
+
+	assert.NoError(t, m.Migrate())
+	assert.EqualValues(t, []Step{{ID: "1"}, {ID: "2"}}, m.Steps())
+	assert.Equal(t, ErrNoRunnedMigration, m.RollbackLast())
+
+	for _, tableName := range []string{"migrations", "migrations_lock"} {
+		exists, err := db.IsTableExist(tableName)
+		assert.NoError(t, err)
+		assert.False(t, exists, tableName)
+	}
+
+	// the migration table of the former versions is not upgraded
+	_, err = db.Exec("CREATE TABLE migrations (id VARCHAR(255) PRIMARY KEY)")
+	assert.NoError(t, err)
+	_, err = db.Exec("INSERT INTO migrations (id) VALUES (?)", "1")
+	assert.NoError(t, err)
+
+	statuses, err = m.Status()
+	assert.NoError(t, err)
+	assert.True(t, statuses[0].Applied)
+	assert.False(t, statuses[0].Modified)
+	assert.False(t, statuses[1].Applied)
+
+	assert.NoError(t, m.Migrate())
+	assert.EqualValues(t, []Step{{ID: "2"}}, m.Steps())
+	_, cols, err := db.Dialect().GetColumns(db.DB(), context.Background(), "migrations")
+	assert.NoError(t, err)
+	assert.Len(t, cols, 1)
+}
+
+func TestMigrationLock(t *testing.T) {
+	db := newTestEngine(t)
+	defer db.Close()
+
+	oldInterval := lockRetryInterval
+	lockRetryInterval = 10 * time.Millisecond
+	defer func() {
+		lockRetryInterval = oldInterval
+	}()
+
+	options := *DefaultOptions
+	options.LockTimeout = 50 * time.Millisecond
+	m := New(db, &options, []*Migration{noopMigration("1")})
+	assert.NoError(t, m.Migrate())
+
+	// another process is migrating
+	_, err := db.Exec("INSERT INTO migrations_lock (id, locked_at) VALUES (?, ?)", 1, time.Now())
+	assert.NoError(t, err)
+	assert.ErrorIs(t, m.Migrate(), ErrLockTimeout)
+
+	_, err = db.Exec("DELETE FROM migrations_lock")
+	assert.NoError(t, err)
+	assert.NoError(t, m.Migrate())
+	assert.Equal(t, 0, tableCount(db, "migrations_lock"))
+
+	// the lock left by a crashed process expires
+	_, err = db.Exec("INSERT INTO migrations_lock (id, locked_at) VALUES (?, ?)", 1, time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.ErrorIs(t, m.Migrate(), ErrLockTimeout)
+	options.LockExpiration = time.Minute
+	m = New(db, &options, []*Migration{noopMigration("1")})
+	assert.NoError(t, m.Migrate())
+	assert.Equal(t, 0, tableCount(db, "migrations_lock"))
+}
+
+func TestMigrationAdvisoryLockConnection(t *testing.T) {
+	db := newTestEngine(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	m := New(db, DefaultOptions, []*Migration{noopMigration("1")})
+	_, err := m.advisoryLock("SELECT 1", "SELECT 1", "key")
+	assert.ErrorIs(t, err, ErrLockConnection)
+}
+
+func TestMigrateToAndRollbackTo(t *testing.T) {
+	db := newTestEngine(t)
+	defer db.Close()
+
+	migrations := []*Migration{noopMigration("1"), noopMigration("2"), noopMigration("3")}
+	for _, migration := range migrations {
+		migration.Rollback = func(tx *xorm.Engine) error {
+			return nil
+		}
+	}
+	m := New(db, DefaultOptions, migrations)
+
+	assert.ErrorIs(t, m.MigrateTo("4"), ErrUnknownMigration)
+	assert.NoError(t, m.MigrateTo("2"))
+	assert.EqualValues(t, []Step{{ID: "1"}, {ID: "2"}}, m.Steps())
+
+	statuses, err := m.Status()
+	assert.NoError(t, err)
+	if assert.Len(t, statuses, 3) {
+		assert.True(t, statuses[0].Applied)
+		assert.False(t, statuses[0].AppliedAt.IsZero())
+		assert.True(t, statuses[1].Applied)
+		assert.False(t, statuses[2].Applied)
+		assert.True(t, statuses[2].AppliedAt.IsZero())
+	}
+
+	// nothing is done on a dry run
+	options := *DefaultOptions
+	options.DryRun = true
+	dryRun := New(db, &options, migrations)
+	assert.NoError(t, dryRun.Migrate())
+	assert.EqualValues(t, []Step{{ID: "3"}}, dryRun.Steps())
+	assert.NoError(t, dryRun.RollbackTo("1"))
+	assert.EqualValues(t, []Step{{ID: "2", Rollback: true}}, dryRun.Steps())
+	assert.Equal(t, 2, tableCount(db, "migrations"))
+
+	assert.NoError(t, m.Migrate())
+	assert.EqualValues(t, []Step{{ID: "3"}}, m.Steps())
+	assert.NoError(t, m.RollbackTo("1"))
+	assert.EqualValues(t, []Step{{ID: "3", Rollback: true}, {ID: "2", Rollback: true}}, m.Steps())
+	assert.Equal(t, 1, tableCount(db, "migrations"))
+
+	migrations[0].Checksum = Checksum([]byte("modified"))
+	statuses, err = m.Status()
+	assert.NoError(t, err)
+	assert.True(t, statuses[0].Modified)
+}
@@ -0,0 +1,193 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"xorm.io/xorm"
+	"xorm.io/xorm/dialects"
+	"xorm.io/xorm/internal/utils"
+)
+
+// sqlFileRegexp matches the names of the SQL migration files like
+// 0001_create_user.up.sql or 0001_create_user.postgres.up.sql
+var sqlFileRegexp = regexp.MustCompile(`^(\d+)_([^.]+)(?:\.([a-z0-9]+))?\.(up|down)\.sql$`)
+
+// sqlMigration represents the scripts of a SQL migration by the dialects, the
+// script of the empty dialect is used by all the dialects without variants
+type sqlMigration struct {
+	id      string
+	ups     map[string]string
+	downs   map[string]string
+	upFiles map[string]string // the contents of the up files by names
+}
+
+// LoadSQLMigrations returns the migrations of the SQL files in the root of
+// fsys, which are named NNNN_name.up.sql and NNNN_name.down.sql, the ID of the
+// migration is NNNN_name and the migrations are sorted by the number. A file
+// for a dialect like NNNN_name.postgres.up.sql is used instead of the common
+// one on that database. The statements are run as they are without converting
+// the placeholders, within the same transaction as the update of the migration
+// table if the database supports transactional DDL. The checksum of the
+// migration is the one of all its up files.
+func LoadSQLMigrations(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	sqlMigrations := make(map[uint64]*sqlMigration)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		matches := sqlFileRegexp.FindStringSubmatch(name)
+		if matches == nil {
+			return nil, fmt.Errorf("invalid name of the migration file %s", name)
+		}
+		version, err := strconv.ParseUint(matches[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version of the migration file %s: %w", name, err)
+		}
+
+		id := matches[1] + "_" + matches[2]
+		mig, ok := sqlMigrations[version]
+		if !ok {
+			mig = &sqlMigration{
+				id:      id,
+				ups:     make(map[string]string),
+				downs:   make(map[string]string),
+				upFiles: make(map[string]string),
+			}
+			sqlMigrations[version] = mig
+		} else if mig.id != id {
+			return nil, fmt.Errorf("migrations %s and %s have the same version", mig.id, id)
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		if matches[4] == "up" {
+			mig.ups[matches[3]] = string(content)
+			mig.upFiles[name] = string(content)
+		} else {
+			mig.downs[matches[3]] = string(content)
+		}
+	}
+
+	versions := make([]uint64, 0, len(sqlMigrations))
+	for version := range sqlMigrations {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i] < versions[j]
+	})
+
+	migrations := make([]*Migration, 0, len(versions))
+	for _, version := range versions {
+		mig := sqlMigrations[version]
+		if len(mig.ups) == 0 {
+			return nil, fmt.Errorf("migration %s has no up file", mig.id)
+		}
+
+		names := make([]string, 0, len(mig.upFiles))
+		for name := range mig.upFiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var content strings.Builder
+		for _, name := range names {
+			content.WriteString(mig.upFiles[name])
+		}
+
+		migration := &Migration{
+			ID:        mig.id,
+			Migrate:   sqlMigrateFunc(mig.id, mig.ups),
+			Checksum:  Checksum([]byte(content.String())),
+			upScripts: mig.ups,
+		}
+		if len(mig.downs) > 0 {
+			migration.Rollback = RollbackFunc(sqlMigrateFunc(mig.id, mig.downs))
+			migration.downScripts = mig.downs
+		}
+		migrations = append(migrations, migration)
+	}
+	return migrations, nil
+}
+
+// sqlMigrateFunc returns a func which runs the script of the dialect of the
+// engine
+func sqlMigrateFunc(id string, scripts map[string]string) MigrateFunc {
+	return func(engine *xorm.Engine) error {
+		return execSQLScript(engine, id, scripts, nil)
+	}
+}
+
+// execSQLScript executes the statements of the script of the dialect of the
+// engine and then record, if it's not nil, within a transaction if the
+// database supports transactional DDL. The statements are executed as they
+// are, the placeholders like ? are not converted.
+func execSQLScript(engine *xorm.Engine, id string, scripts map[string]string, record func(*xorm.Session) error) error {
+	dbType := string(engine.Dialect().URI().DBType)
+	script, ok := scripts[dbType]
+	if !ok {
+		if script, ok = scripts[""]; !ok {
+			return fmt.Errorf("migration %s has no script for %s", id, dbType)
+		}
+	}
+
+	session := engine.NewSession()
+	defer session.Close()
+
+	isTransactional := engine.Dialect().Features().TransactionalDDL
+	if isTransactional {
+		if err := session.Begin(); err != nil {
+			return err
+		}
+	}
+
+	// the scripts have no arguments, so their question marks are kept
+	session.Context(dialects.KeepPlaceholders(context.Background()))
+	scanner := utils.NewSQLScanner(strings.NewReader(script))
+	for scanner.Scan() {
+		query := strings.Trim(scanner.Text(), " \t\n\r")
+		if isCommentOnly(query) {
+			continue
+		}
+		if _, err := session.Exec(query); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	session.Context(context.Background())
+
+	if record != nil {
+		if err := record(session); err != nil {
+			return err
+		}
+	}
+	if isTransactional {
+		return session.Commit()
+	}
+	return nil
+}
+
+// isCommentOnly returns true if the statement is empty or has line comments only
+func isCommentOnly(query string) bool {
+	for _, line := range strings.Split(query, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
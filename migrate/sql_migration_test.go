@@ -0,0 +1,123 @@
+package migrate
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"xorm.io/xorm/contexts"
+)
+
+func TestLoadSQLMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0001_create_person.up.sql": &fstest.MapFile{Data: []byte(`-- the persons
+CREATE TABLE person (id INTEGER PRIMARY KEY, name VARCHAR(255) DEFAULT 'a;b');
+INSERT INTO person (id, name) VALUES (1, 'who?');
+-- end of the migration
+`)},
+		"0001_create_person.down.sql":       &fstest.MapFile{Data: []byte("DROP TABLE person")},
+		"0002_create_pet.up.sql":            &fstest.MapFile{Data: []byte("CREATE TABLE pet (id INTEGER PRIMARY KEY)")},
+		"0002_create_pet.sqlite3.up.sql":    &fstest.MapFile{Data: []byte("CREATE TABLE pet_sqlite (id INTEGER PRIMARY KEY)")},
+		"0010_broken.up.sql":                &fstest.MapFile{Data: []byte("CREATE TABLE broken (id INTEGER PRIMARY KEY); INSERT INTO missing VALUES (1);")},
+		"README.md":                         &fstest.MapFile{Data: []byte("not a migration")},
+		"0002_create_pet.postgres.down.sql": &fstest.MapFile{Data: []byte("DROP TABLE pet")},
+	}
+
+	migrations, err := LoadSQLMigrations(fsys)
+	assert.NoError(t, err)
+	if !assert.Len(t, migrations, 3) {
+		return
+	}
+	assert.EqualValues(t, "0001_create_person", migrations[0].ID)
+	assert.EqualValues(t, "0002_create_pet", migrations[1].ID)
+	assert.EqualValues(t, "0010_broken", migrations[2].ID)
+	assert.NotNil(t, migrations[0].Rollback)
+	assert.Nil(t, migrations[2].Rollback)
+	assert.EqualValues(t, Checksum(fsys["0010_broken.up.sql"].Data), migrations[2].Checksum)
+
+	db := newTestEngine(t)
+	defer db.Close()
+
+	m := New(db, DefaultOptions, migrations)
+	assert.Error(t, m.Migrate())
+
+	// the sqlite3 variant is used
+	exists, err := db.IsTableExist("pet_sqlite")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = db.IsTableExist("pet")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	// the broken migration is rolled back since SQLite has transactional DDL
+	exists, err = db.IsTableExist("broken")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.Equal(t, 2, tableCount(db, "migrations"))
+	assert.Equal(t, 1, tableCount(db, "person"))
+	var name string
+	has, err := db.SQL("SELECT name FROM person").Get(&name)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, "who?", name)
+
+	assert.NoError(t, m.RollbackMigration(migrations[0]))
+	exists, err = db.IsTableExist("person")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+// operationHook records the operations of the SQLs creating the tables
+type operationHook struct {
+	operations []contexts.Operation
+}
+
+func (h *operationHook) BeforeProcess(c *contexts.ContextHook) (context.Context, error) {
+	if strings.HasPrefix(c.SQL, "CREATE TABLE pet") {
+		var operation contexts.Operation
+		if md := contexts.MetadataFrom(c.Ctx); md != nil {
+			operation = md.Operation
+		}
+		h.operations = append(h.operations, operation)
+	}
+	return c.Ctx, nil
+}
+
+func (h *operationHook) AfterProcess(c *contexts.ContextHook) error {
+	return nil
+}
+
+func TestSQLMigrationHooks(t *testing.T) {
+	migrations, err := LoadSQLMigrations(fstest.MapFS{
+		"0001_create_pet.up.sql": &fstest.MapFile{Data: []byte("CREATE TABLE pet (id INTEGER PRIMARY KEY, name VARCHAR(255) DEFAULT '?')")},
+	})
+	assert.NoError(t, err)
+
+	db := newTestEngine(t)
+	defer db.Close()
+	hook := new(operationHook)
+	db.AddHook(hook)
+
+	assert.NoError(t, New(db, DefaultOptions, migrations).Migrate())
+	assert.EqualValues(t, []contexts.Operation{contexts.OperationExec}, hook.operations)
+}
+
+func TestLoadSQLMigrationsInvalid(t *testing.T) {
+	_, err := LoadSQLMigrations(fstest.MapFS{
+		"create_person.up.sql": &fstest.MapFile{Data: []byte("SELECT 1")},
+	})
+	assert.Error(t, err)
+
+	_, err = LoadSQLMigrations(fstest.MapFS{
+		"0001_create_person.up.sql": &fstest.MapFile{Data: []byte("SELECT 1")},
+		"0001_create_pet.up.sql":    &fstest.MapFile{Data: []byte("SELECT 1")},
+	})
+	assert.Error(t, err)
+
+	_, err = LoadSQLMigrations(fstest.MapFS{
+		"0001_create_person.down.sql": &fstest.MapFile{Data: []byte("SELECT 1")},
+	})
+	assert.Error(t, err)
+}
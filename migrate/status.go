@@ -0,0 +1,33 @@
+package migrate
+
+import "time"
+
+// MigrationStatus represents the status of a migration
+type MigrationStatus struct {
+	ID        string
+	Applied   bool
+	AppliedAt time.Time // zero if the migration is not applied
+	Modified  bool      // the checksum has been changed after applied
+}
+
+// Status returns the status of all the migrations in order, all of which are
+// pending if the migration table does not exist. It does not change the
+// database.
+func (m *Migrate) Status() ([]*MigrationStatus, error) {
+	applied, err := m.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{ID: migration.ID}
+		if appliedMig, ok := applied[migration.ID]; ok {
+			status.Applied = true
+			status.AppliedAt = appliedMig.AppliedAt
+			status.Modified = appliedMig.isModified(migration)
+		}
+		statuses = append(statuses, &status)
+	}
+	return statuses, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package schemas
+
+import (
+	"fmt"
+	"strings"
+)
+
+// enumerate all the referential actions of a foreign key
+const (
+	NoAction   = "NO ACTION"
+	Restrict   = "RESTRICT"
+	Cascade    = "CASCADE"
+	SetNull    = "SET NULL"
+	SetDefault = "SET DEFAULT"
+)
+
+// ForeignKey represents a foreign key constraint of a table
+type ForeignKey struct {
+	Name     string
+	Cols     []string
+	RefTable string
+	RefCols  []string
+	OnDelete string // the referential action on delete, NO ACTION if empty
+	OnUpdate string // the referential action on update, NO ACTION if empty
+}
+
+// NewForeignKey creates a foreign key of the columns referencing the columns
+// of another table
+func NewForeignKey(name string, cols []string, refTable string, refCols []string) *ForeignKey {
+	return &ForeignKey{
+		Name:     name,
+		Cols:     cols,
+		RefTable: refTable,
+		RefCols:  refCols,
+	}
+}
+
+// ReferentialAction returns the normalized referential action, the action
+// could be separated by an underline like set_null
+func ReferentialAction(action string) (string, error) {
+	action = strings.ToUpper(strings.Join(strings.Fields(strings.NewReplacer("_", " ", "'", "").Replace(action)), " "))
+	switch action {
+	case "":
+		return "", nil
+	case NoAction, Restrict, Cascade, SetNull, SetDefault:
+		return action, nil
+	}
+	return "", fmt.Errorf("unknown referential action %s", action)
+}
+
+// XName returns the name of the foreign key constraint for the table
+func (fk *ForeignKey) XName(tableName string) string {
+	if strings.HasPrefix(fk.Name, "FK_") {
+		return fk.Name
+	}
+	tableParts := strings.Split(strings.ReplaceAll(tableName, `"`, ""), ".")
+	tableName = tableParts[len(tableParts)-1]
+	return fmt.Sprintf("FK_%v_%v", tableName, fk.Name)
+}
+
+// Equal returns true if the two foreign keys reference the same columns with
+// the same actions
+func (fk *ForeignKey) Equal(dst *ForeignKey) bool {
+	if !strings.EqualFold(fk.RefTable, dst.RefTable) ||
+		!equalCols(fk.Cols, dst.Cols) || !equalCols(fk.RefCols, dst.RefCols) {
+		return false
+	}
+	return equalAction(fk.OnDelete, dst.OnDelete) && equalAction(fk.OnUpdate, dst.OnUpdate)
+}
+
+func equalCols(cols1, cols2 []string) bool {
+	if len(cols1) != len(cols2) {
+		return false
+	}
+	for i := range cols1 {
+		if !strings.EqualFold(cols1[i], cols2[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// equalAction treats RESTRICT as NO ACTION since some databases report the
+// default action as RESTRICT
+func equalAction(action1, action2 string) bool {
+	normalize := func(action string) string {
+		if action == "" || action == Restrict {
+			return NoAction
+		}
+		return action
+	}
+	return normalize(action1) == normalize(action2)
+}
+
+// SortTablesByForeignKeys returns the indexes of the tables sorted in the
+// order of dependency, so that a table is after the tables it references. The
+// cycle of the references is broken at the first table of it.
+func SortTablesByForeignKeys(tables []*Table) []int {
+	var (
+		order   = make([]int, 0, len(tables))
+		visited = make([]bool, len(tables))
+		visit   func(i int, path map[int]bool)
+	)
+	visit = func(i int, path map[int]bool) {
+		if visited[i] || path[i] {
+			return
+		}
+		path[i] = true
+		for j, table := range tables {
+			if j != i && tables[i].References(table.Name) {
+				visit(j, path)
+			}
+		}
+		delete(path, i)
+		visited[i] = true
+		order = append(order, i)
+	}
+	for i := range tables {
+		visit(i, make(map[int]bool))
+	}
+	return order
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package schemas
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForeignKeyEqual(t *testing.T) {
+	fk := NewForeignKey("user_id", []string{"user_id"}, "user", []string{"id"})
+	dst := NewForeignKey("FK_order_user_id", []string{"USER_ID"}, "User", []string{"id"})
+	dst.OnDelete = Restrict
+	assert.True(t, fk.Equal(dst))
+
+	dst.OnDelete = Cascade
+	assert.False(t, fk.Equal(dst))
+
+	assert.EqualValues(t, "FK_order_user_id", fk.XName("order"))
+	assert.EqualValues(t, "FK_order_user_id", fk.XName(`"public"."order"`))
+	assert.EqualValues(t, "FK_order_user_id", dst.XName("another"))
+}
+
+func TestReferentialAction(t *testing.T) {
+	for action, expected := range map[string]string{
+		"cascade":       Cascade,
+		"set_null":      SetNull,
+		"'set default'": SetDefault,
+		"NO_ACTION":     NoAction,
+		"":              "",
+	} {
+		got, err := ReferentialAction(action)
+		assert.NoError(t, err)
+		assert.EqualValues(t, expected, got)
+	}
+
+	_, err := ReferentialAction("drop")
+	assert.Error(t, err)
+}
+
+func TestSortTablesByForeignKeys(t *testing.T) {
+	newTable := func(name string, refs ...string) *Table {
+		table := NewEmptyTable()
+		table.Name = name
+		for _, ref := range refs {
+			table.AddForeignKey(NewForeignKey(ref+"_id", []string{ref + "_id"}, ref, []string{"id"}))
+		}
+		return table
+	}
+
+	tables := []*Table{
+		newTable("item", "order", "product"),
+		newTable("order", "user"),
+		newTable("user"),
+		newTable("product"),
+		// the cycle is broken at the first table
+		newTable("a", "b"),
+		newTable("b", "a"),
+		newTable("self", "self"),
+	}
+	var names []string
+	for _, i := range SortTablesByForeignKeys(tables) {
+		names = append(names, tables[i].Name)
+	}
+	assert.EqualValues(t, []string{"user", "order", "product", "item", "b", "a", "self"}, names)
+}
@@ -0,0 +1,61 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package schemas
+
+import (
+	"reflect"
+	"strings"
+)
+
+// RelationType represents the type of a relation between two tables
+type RelationType int
+
+// enumerate all the relation types
+const (
+	HasMany RelationType = iota + 1
+	BelongsTo
+	ManyToMany
+)
+
+// Relation represents a field of a struct which holds the structs of another
+// table related to this one
+type Relation struct {
+	FieldName  string
+	FieldIndex []int
+	Type       RelationType
+	RefType    reflect.Type // the struct type of the related table
+	// ForeignKey is the column of the related table referencing this table for
+	// has_many, or the column of this table referencing the related table for
+	// belongs_to
+	ForeignKey string
+	// JoinTable is the table of many2many joining the two tables
+	JoinTable string
+	// JoinForeignKey is the column of the join table referencing this table
+	JoinForeignKey string
+	// JoinRefKey is the column of the join table referencing the related table
+	JoinRefKey string
+}
+
+// AddRelation adds a relation of a field to the table
+func (table *Table) AddRelation(relation *Relation) {
+	if table.Relations == nil {
+		table.Relations = make(map[string]*Relation)
+	}
+	table.Relations[relation.FieldName] = relation
+}
+
+// GetRelation returns the relation of the field, the name of the field is
+// case insensitive
+func (table *Table) GetRelation(fieldName string) *Relation {
+	if relation, ok := table.Relations[fieldName]; ok {
+		return relation
+	}
+	for name, relation := range table.Relations {
+		if strings.EqualFold(name, fieldName) {
+			return relation
+		}
+	}
+	return nil
+}
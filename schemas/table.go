@@ -18,6 +18,8 @@ type Table struct {
 	columnsMap    map[string][]*Column
 	columns       []*Column
 	Indexes       map[string]*Index
+	ForeignKeys   map[string]*ForeignKey
+	Relations     map[string]*Relation
 	PrimaryKeys   []string
 	AutoIncrement string
 	Created       map[string]bool
@@ -43,6 +45,8 @@ func NewTable(name string, t reflect.Type) *Table {
 		columns:     make([]*Column, 0),
 		columnsMap:  make(map[string][]*Column),
 		Indexes:     make(map[string]*Index),
+		ForeignKeys: make(map[string]*ForeignKey),
+		Relations:   make(map[string]*Relation),
 		Created:     make(map[string]bool),
 		PrimaryKeys: make([]string, 0),
 	}
@@ -153,6 +157,46 @@ func (table *Table) AddIndex(index *Index) {
 	table.Indexes[index.Name] = index
 }
 
+// FindIndex returns the name of the index of the table which is equal to the
+// index, and false if there is none
+func (table *Table) FindIndex(index *Index) (string, bool) {
+	for name, idx := range table.Indexes {
+		if idx.Equal(index) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// IsForeignKeyIndex returns true if the index of the name is created for a
+// foreign key of the table, like MySQL does
+func (table *Table) IsForeignKeyIndex(indexName string) bool {
+	for _, fk := range table.ForeignKeys {
+		if strings.EqualFold(fk.XName(table.Name), indexName) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddForeignKey adds a foreign key to table
+func (table *Table) AddForeignKey(fk *ForeignKey) {
+	if table.ForeignKeys == nil {
+		table.ForeignKeys = make(map[string]*ForeignKey)
+	}
+	table.ForeignKeys[fk.Name] = fk
+}
+
+// References returns true if the table has a foreign key referencing the table
+func (table *Table) References(tableName string) bool {
+	for _, fk := range table.ForeignKeys {
+		if strings.EqualFold(fk.RefTable, tableName) {
+			return true
+		}
+	}
+	return false
+}
+
 // IDOfV get id from one value of struct
 func (table *Table) IDOfV(rv reflect.Value) (PK, error) {
 	v := reflect.Indirect(rv)
@@ -60,6 +60,7 @@ type Session struct {
 	statement              *statements.Statement
 	isAutoCommit           bool
 	isCommitedOrRollbacked bool
+	txLevels               []string // levels opened in the transaction, the savepoint names or "" for Begin
 	isAutoClose            bool
 	isClosed               bool
 	prepareStmt            bool
@@ -85,6 +86,15 @@ type Session struct {
 
 	ctx         context.Context
 	sessionType sessionType
+
+	// the operation running the SQL for the hooks
+	operation      contexts.Operation
+	operationBeans []interface{}
+	id             string
+
+	// the tables written in the transaction, their cached query results are
+	// invalidated again when it's committed
+	txWrittenTables []string
 }
 
 func newSessionID() string {
@@ -136,6 +146,7 @@ func newSession(engine *Engine) *Session {
 
 		sessionType: engineSession,
 	}
+	session.statement.SetServerVersion(engine.serverVersion)
 	if engine.logSessionID {
 		session.ctx = context.WithValue(session.ctx, log.SessionKey, session)
 	}
@@ -160,6 +171,7 @@ func (session *Session) Close() error {
 		// When Close be called, if session is a transaction and do not call
 		// Commit or Rollback, then call Rollback.
 		if session.tx != nil && !session.isCommitedOrRollbacked {
+			session.txLevels = nil
 			if err := session.Rollback(); err != nil {
 				return err
 			}
@@ -369,7 +381,7 @@ func (session *Session) doPrepare(db *core.DB, sqlStr string) (stmt *core.Stmt,
 	var has bool
 	stmt, has = session.stmtCache[crc]
 	if !has {
-		stmt, err = db.PrepareContext(session.ctx, sqlStr)
+		stmt, err = db.PrepareContext(session.hookContext(), sqlStr)
 		if err != nil {
 			return nil, err
 		}
@@ -384,7 +396,7 @@ func (session *Session) doPrepareTx(sqlStr string) (stmt *core.Stmt, err error)
 	var has bool
 	stmt, has = session.txStmtCache[crc]
 	if !has {
-		stmt, err = session.tx.PrepareContext(session.ctx, sqlStr)
+		stmt, err = session.tx.PrepareContext(session.hookContext(), sqlStr)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,498 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"errors"
+	"reflect"
+	"time"
+
+	"xorm.io/xorm/contexts"
+	"xorm.io/xorm/internal/statements"
+	"xorm.io/xorm/internal/utils"
+	"xorm.io/xorm/schemas"
+)
+
+var (
+	// ErrVersionConflict represents an error when a row of a batch has been
+	// modified by others since it was read, i.e. its version has changed
+	ErrVersionConflict = errors.New("the version of the row has been changed")
+	// ErrPrimaryKeyZero represents an error when a bean of a batch has no
+	// primary key to find its row
+	ErrPrimaryKeyZero = errors.New("the primary key of the bean is zero")
+)
+
+// batchSize returns how many rows one statement could take within the max
+// number of parameters
+func batchSize(maxParams, fixedParams, rowParams, rows int) int {
+	if maxParams <= 0 || rowParams <= 0 {
+		return rows
+	}
+	size := (maxParams - fixedParams) / rowParams
+	if size < 1 {
+		return 1
+	}
+	return size
+}
+
+// inListBatchSize limits the size of the batches whose rows are listed in an
+// IN expression
+func inListBatchSize(maxInList, size int) int {
+	if maxInList > 0 && size > maxInList {
+		return maxInList
+	}
+	return size
+}
+
+// batchBeans returns the pointers to the elements of the slice
+func batchBeans(rowsSlicePtr interface{}) ([]interface{}, error) {
+	sliceValue := reflect.Indirect(reflect.ValueOf(rowsSlicePtr))
+	if sliceValue.Kind() != reflect.Slice {
+		return nil, ErrPtrSliceType
+	}
+
+	beans := make([]interface{}, 0, sliceValue.Len())
+	for i := 0; i < sliceValue.Len(); i++ {
+		v := sliceValue.Index(i)
+		if v.Kind() == reflect.Interface {
+			v = v.Elem()
+		}
+		if v.Kind() == reflect.Struct {
+			if !v.CanAddr() {
+				return nil, ErrPtrSliceType
+			}
+			v = v.Addr()
+		}
+		if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+			return nil, ErrParamsType
+		}
+		beans = append(beans, v.Interface())
+	}
+	return beans, nil
+}
+
+// inBatches runs the batches of the n rows one by one within a transaction if
+// there is more than one of them or atomic is true, and the session is not in
+// a transaction. run returns the number of the rows affected by the batch from
+// start to end. after is called when all the batches succeed and before
+// committing.
+func (session *Session) inBatches(n, size int, atomic bool, run func(start, end int) (int64, error), after func()) (int64, error) {
+	var needCommit bool
+	if (n > size || atomic) && session.isAutoCommit {
+		if err := session.Begin(); err != nil {
+			return 0, err
+		}
+		needCommit = true
+	}
+
+	var affected int64
+	for start := 0; start < n; start += size {
+		end := start + size
+		if end > n {
+			end = n
+		}
+
+		cnt, err := run(start, end)
+		affected += cnt
+		if err != nil {
+			if needCommit {
+				if rbErr := session.Rollback(); rbErr != nil {
+					session.engine.logger.Errorf("rollback failed: %v", rbErr)
+				}
+			}
+			return affected, err
+		}
+	}
+
+	after()
+
+	if needCommit {
+		if err := session.Commit(); err != nil {
+			return affected, err
+		}
+	}
+	return affected, nil
+}
+
+// execBatches executes the batches one by one within a transaction if there
+// is more than one of them or the versions are checked, and the session is not
+// in a transaction. The version checked batch fails with ErrVersionConflict
+// and rolls back if any of its rows is not affected.
+func (session *Session) execBatches(rows []statements.BatchRow, size int, after func(),
+	genSQL func(rows []statements.BatchRow) (string, []interface{}, error)) (int64, error) {
+	return session.inBatches(len(rows), size, session.statement.CheckBatchVersion(), func(start, end int) (int64, error) {
+		sqlStr, args, err := genSQL(rows[start:end])
+		if err != nil {
+			return 0, err
+		}
+		res, err := session.exec(sqlStr, args...)
+		if err != nil {
+			return 0, err
+		}
+		cnt, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		if session.statement.CheckBatchVersion() && cnt < int64(end-start) {
+			return cnt, ErrVersionConflict
+		}
+		return cnt, nil
+	}, after)
+}
+
+// UpdateMulti updates the beans of the slice by their primary keys with as
+// few statements as the parameters limit of the database allows. Unlike
+// Update, all the columns are updated, including the zero values, unless Cols
+// or Omit is given. The version of a bean is checked and increased as Update
+// does, and the batch fails with ErrVersionConflict if any row's version has
+// changed.
+func (session *Session) UpdateMulti(rowsSlicePtr interface{}) (int64, error) {
+	session.setOperation(contexts.OperationUpdate, rowsSlicePtr)
+	if session.isAutoClose {
+		defer session.Close()
+	}
+
+	session.autoResetStatement = false
+	defer func() {
+		session.autoResetStatement = true
+		session.resetStatement()
+	}()
+
+	if session.statement.LastError != nil {
+		return 0, session.statement.LastError
+	}
+
+	beans, err := batchBeans(rowsSlicePtr)
+	if err != nil {
+		return 0, err
+	}
+	if len(beans) == 0 {
+		return 0, nil
+	}
+
+	if err := session.statement.SetRefBean(beans[0]); err != nil {
+		return 0, err
+	}
+	if len(session.statement.TableName()) == 0 {
+		return 0, ErrTableNotFound
+	}
+	table := session.statement.RefTable
+
+	// handle before update processors
+	for _, bean := range beans {
+		for _, closure := range session.beforeClosures {
+			closure(bean)
+		}
+		if processor, ok := bean.(BeforeUpdateProcessor); ok {
+			processor.BeforeUpdate()
+		}
+	}
+	cleanupProcessorsClosures(&session.beforeClosures)
+
+	var (
+		cols       = session.genUpdateMultiColumns()
+		colNames   = make([]string, 0, len(cols))
+		commonCols []string
+		commonArgs []interface{}
+		updatedAt  time.Time
+	)
+	for _, col := range cols {
+		colNames = append(colNames, col.Name)
+	}
+
+	if session.statement.UseAutoTime && table.Updated != "" &&
+		!session.statement.OmitColumnMap.Contain(table.Updated) {
+		col := table.UpdatedColumn()
+		val, t, err := session.engine.nowTime(col)
+		if err != nil {
+			return 0, err
+		}
+		if session.engine.dialect.URI().DBType == schemas.ORACLE {
+			commonArgs = append(commonArgs, t)
+		} else {
+			commonArgs = append(commonArgs, val)
+		}
+		commonCols = append(commonCols, col.Name)
+		updatedAt = t
+	}
+
+	rows := make([]statements.BatchRow, 0, len(beans))
+	for _, bean := range beans {
+		row, err := session.genBatchRow(bean)
+		if err != nil {
+			return 0, err
+		}
+		for _, col := range cols {
+			fieldValue, err := col.ValueOf(bean)
+			if err != nil {
+				return 0, err
+			}
+			arg, err := session.statement.Value2Interface(col, *fieldValue)
+			if err != nil {
+				return 0, err
+			}
+			row.Values = append(row.Values, arg)
+		}
+		rows = append(rows, *row)
+	}
+
+	fixedParams, err := session.statement.BatchFixedParams()
+	if err != nil {
+		return 0, err
+	}
+	features := session.engine.dialect.Features()
+	size := inListBatchSize(features.MaxInListSize, batchSize(features.MaxBindParams,
+		fixedParams+len(commonArgs), session.statement.UpdateMultiRowParams(len(cols)), len(rows)))
+
+	tableName := session.statement.TableName()
+	affected, err := session.execBatches(rows, size, func() {
+		for _, bean := range beans {
+			if len(commonCols) > 0 {
+				session.afterClosures = append(session.afterClosures, func(bean interface{}) {
+					setColumnTime(bean, table.UpdatedColumn(), updatedAt)
+				})
+			}
+			if session.statement.CheckBatchVersion() {
+				if verValue, err := table.VersionColumn().ValueOf(bean); err == nil && verValue.CanSet() {
+					session.incrVersionFieldValue(verValue)
+				}
+			}
+			session.handleAfterUpdateProcessor(bean)
+		}
+	}, func(rows []statements.BatchRow) (string, []interface{}, error) {
+		return session.statement.GenUpdateMultiSQL(colNames, commonCols, commonArgs, rows)
+	})
+
+	if cacher := session.engine.GetCacher(tableName); cacher != nil && session.statement.UseCache {
+		session.engine.logger.Debugf("[cache] clear table: %v", tableName)
+		cacher.ClearIds(tableName)
+		cacher.ClearBeans(tableName)
+	}
+
+	return affected, err
+}
+
+// genUpdateMultiColumns returns the columns updated by UpdateMulti
+func (session *Session) genUpdateMultiColumns() []*schemas.Column {
+	var (
+		table = session.statement.RefTable
+		cols  = make([]*schemas.Column, 0, len(table.Columns()))
+	)
+	for _, col := range table.Columns() {
+		if col.MapType == schemas.ONLYFROMDB || col.IsPrimaryKey || col.IsAutoIncrement ||
+			col.IsCreated || col.IsDeleted {
+			continue
+		}
+		if col.IsVersion && session.statement.CheckVersion {
+			continue
+		}
+		if col.IsUpdated && session.statement.UseAutoTime {
+			continue
+		}
+		if session.statement.OmitColumnMap.Contain(col.Name) {
+			continue
+		}
+		if len(session.statement.ColumnMap) > 0 && !session.statement.ColumnMap.Contain(col.Name) {
+			continue
+		}
+		cols = append(cols, col)
+	}
+	return cols
+}
+
+// genBatchRow returns the primary key and the version of the bean
+func (session *Session) genBatchRow(bean interface{}) (*statements.BatchRow, error) {
+	var (
+		table = session.statement.RefTable
+		row   statements.BatchRow
+	)
+	for _, col := range table.PKColumns() {
+		fieldValue, err := col.ValueOf(bean)
+		if err != nil {
+			return nil, err
+		}
+		if utils.IsValueZero(*fieldValue) {
+			return nil, ErrPrimaryKeyZero
+		}
+		arg, err := session.statement.Value2Interface(col, *fieldValue)
+		if err != nil {
+			return nil, err
+		}
+		row.PK = append(row.PK, arg)
+	}
+
+	if session.statement.CheckBatchVersion() {
+		fieldValue, err := table.VersionColumn().ValueOf(bean)
+		if err != nil {
+			return nil, err
+		}
+		row.Version = fieldValue.Interface()
+	}
+	return &row, nil
+}
+
+// handleAfterUpdateProcessor calls the after update closures and processor of
+// the bean, which are delayed until commit in a transaction
+func (session *Session) handleAfterUpdateProcessor(bean interface{}) {
+	if session.isAutoCommit {
+		for _, closure := range session.afterClosures {
+			closure(bean)
+		}
+		if processor, ok := bean.(AfterUpdateProcessor); ok {
+			processor.AfterUpdate()
+		}
+	} else {
+		if len(session.afterClosures) > 0 {
+			if value, has := session.afterUpdateBeans[bean]; has && value != nil {
+				*value = append(*value, session.afterClosures...)
+			} else {
+				afterClosures := make([]func(interface{}), len(session.afterClosures))
+				copy(afterClosures, session.afterClosures)
+				session.afterUpdateBeans[bean] = &afterClosures
+			}
+		} else if _, ok := bean.(AfterUpdateProcessor); ok {
+			session.afterUpdateBeans[bean] = nil
+		}
+	}
+	cleanupProcessorsClosures(&session.afterClosures)
+}
+
+// handleAfterDeleteProcessor calls the after delete closures and processor of
+// the bean, which are delayed until commit in a transaction
+func (session *Session) handleAfterDeleteProcessor(bean interface{}) {
+	if session.isAutoCommit {
+		for _, closure := range session.afterClosures {
+			closure(bean)
+		}
+		if processor, ok := bean.(AfterDeleteProcessor); ok {
+			processor.AfterDelete()
+		}
+	} else {
+		if len(session.afterClosures) > 0 {
+			if value, has := session.afterDeleteBeans[bean]; has && value != nil {
+				*value = append(*value, session.afterClosures...)
+			} else {
+				afterClosures := make([]func(interface{}), len(session.afterClosures))
+				copy(afterClosures, session.afterClosures)
+				session.afterDeleteBeans[bean] = &afterClosures
+			}
+		} else if _, ok := bean.(AfterDeleteProcessor); ok {
+			session.afterDeleteBeans[bean] = nil
+		}
+	}
+	cleanupProcessorsClosures(&session.afterClosures)
+}
+
+// DeleteMulti deletes the beans of the slice by their primary keys with as
+// few statements as the parameters limit of the database allows. The rows are
+// marked as deleted if the table has a deleted column, and the version of a
+// bean is checked as UpdateMulti does.
+func (session *Session) DeleteMulti(rowsSlicePtr interface{}) (int64, error) {
+	session.setOperation(contexts.OperationDelete, rowsSlicePtr)
+	if session.isAutoClose {
+		defer session.Close()
+	}
+
+	session.autoResetStatement = false
+	defer func() {
+		session.autoResetStatement = true
+		session.resetStatement()
+	}()
+
+	if session.statement.LastError != nil {
+		return 0, session.statement.LastError
+	}
+
+	beans, err := batchBeans(rowsSlicePtr)
+	if err != nil {
+		return 0, err
+	}
+	if len(beans) == 0 {
+		return 0, nil
+	}
+
+	if err := session.statement.SetRefBean(beans[0]); err != nil {
+		return 0, err
+	}
+	if len(session.statement.TableName()) == 0 {
+		return 0, ErrTableNotFound
+	}
+	table := session.statement.RefTable
+
+	// handle before delete processors
+	for _, bean := range beans {
+		for _, closure := range session.beforeClosures {
+			closure(bean)
+		}
+		if processor, ok := bean.(BeforeDeleteProcessor); ok {
+			processor.BeforeDelete()
+		}
+	}
+	cleanupProcessorsClosures(&session.beforeClosures)
+
+	var (
+		deletedArg  interface{}
+		deletedAt   time.Time
+		fixedParams int
+	)
+	deletedColumn := table.DeletedColumn()
+	softDelete := deletedColumn != nil && !session.statement.GetUnscoped()
+	if softDelete {
+		val, t, err := session.engine.nowTime(deletedColumn)
+		if err != nil {
+			return 0, err
+		}
+		deletedArg, deletedAt = val, t
+		fixedParams++
+	}
+
+	rows := make([]statements.BatchRow, 0, len(beans))
+	for _, bean := range beans {
+		row, err := session.genBatchRow(bean)
+		if err != nil {
+			return 0, err
+		}
+		rows = append(rows, *row)
+	}
+
+	condParams, err := session.statement.BatchFixedParams()
+	if err != nil {
+		return 0, err
+	}
+	features := session.engine.dialect.Features()
+	size := inListBatchSize(features.MaxInListSize, batchSize(features.MaxBindParams,
+		fixedParams+condParams, session.statement.DeleteMultiRowParams(), len(rows)))
+
+	tableName := session.statement.TableName()
+	affected, err := session.execBatches(rows, size, func() {
+		for _, bean := range beans {
+			if softDelete {
+				session.afterClosures = append(session.afterClosures, func(bean interface{}) {
+					setColumnTime(bean, deletedColumn, deletedAt)
+				})
+			}
+			session.handleAfterDeleteProcessor(bean)
+		}
+	}, func(rows []statements.BatchRow) (string, []interface{}, error) {
+		return session.statement.GenDeleteMultiSQL(rows, deletedArg)
+	})
+
+	if cacher := session.engine.GetCacher(tableName); cacher != nil && session.statement.UseCache {
+		for _, bean := range beans {
+			id, err := table.IDOfV(reflect.ValueOf(bean))
+			if err != nil {
+				continue
+			}
+			if sid, err := id.ToString(); err == nil {
+				session.engine.logger.Debugf("[cache] delete cache obj: %v, %v", tableName, id)
+				cacher.DelBean(tableName, sid)
+			}
+		}
+		session.engine.logger.Debugf("[cache] clear cache table: %v", tableName)
+		cacher.ClearIds(tableName)
+	}
+
+	return affected, err
+}
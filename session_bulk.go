@@ -0,0 +1,388 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+
+	"xorm.io/builder"
+	"xorm.io/xorm/contexts"
+	"xorm.io/xorm/dialects"
+	"xorm.io/xorm/internal/utils"
+	"xorm.io/xorm/schemas"
+)
+
+// ErrBulkLoadUnsupported is returned by a BulkLoader before reading any
+// record to fall back to the batched inserts
+var ErrBulkLoadUnsupported = errors.New("bulk load is unsupported")
+
+// defaultBulkBatchSize is the max number of the records of one insert when
+// falling back to the batched inserts
+const defaultBulkBatchSize = 1000
+
+// BulkRecords provides the values of the columns of the records one by one
+// to a BulkLoader, it is compatible with pgx.CopyFromSource
+type BulkRecords interface {
+	Next() bool
+	Values() ([]interface{}, error)
+	Err() error
+}
+
+// BulkLoader loads the records into the table by the native bulk loading of
+// a database driver. tx is not nil if the session is in a transaction. The
+// loaders of the drivers are registered by the package xorm.io/xorm/bulk.
+type BulkLoader interface {
+	BulkLoad(ctx context.Context, db *sql.DB, tx *sql.Tx, tableName string, columns []string, records BulkRecords) (int64, error)
+}
+
+// BulkSource provides the records to BulkLoad one by one like *Rows
+type BulkSource interface {
+	Next() bool
+	Scan(beans ...interface{}) error
+	Err() error
+}
+
+var (
+	bulkLoaders     = make(map[string]BulkLoader)
+	bulkLoadersLock sync.RWMutex
+)
+
+// RegisterBulkLoader registers the bulk loader of the driver
+func RegisterBulkLoader(driverName string, loader BulkLoader) {
+	bulkLoadersLock.Lock()
+	defer bulkLoadersLock.Unlock()
+	bulkLoaders[driverName] = loader
+}
+
+func queryBulkLoader(driverName string) BulkLoader {
+	bulkLoadersLock.RLock()
+	defer bulkLoadersLock.RUnlock()
+	return bulkLoaders[driverName]
+}
+
+// BulkLoad loads the records of a slice of the structs, a *Rows or a
+// BulkSource into the table faster than InsertMulti. It uses the native bulk
+// loading of the driver if its loader is registered by the package
+// xorm.io/xorm/bulk, otherwise the records are inserted by the prepared
+// statements of batches within a transaction. The table of a BulkSource
+// other than *Rows should be given by Table(bean). BeforeInsert of the
+// records is called but AfterInsert is not.
+func (session *Session) BulkLoad(source interface{}) (int64, error) {
+	session.setOperation(contexts.OperationInsert, source)
+	if session.isAutoClose {
+		defer session.Close()
+	}
+
+	session.autoResetStatement = false
+	defer func() {
+		session.autoResetStatement = true
+		session.resetStatement()
+	}()
+
+	if session.statement.LastError != nil {
+		return 0, session.statement.LastError
+	}
+
+	records, err := session.newBulkRecords(source)
+	if err != nil {
+		return 0, err
+	}
+	if !records.Next() {
+		return 0, records.Err()
+	}
+	records.peeked = true
+	if err := records.initColumns(); err != nil {
+		return 0, err
+	}
+
+	tableName := dialects.TableNameWithSchema(session.engine.dialect, session.statement.TableName())
+	if loader := queryBulkLoader(session.engine.driverName); loader != nil {
+		var tx *sql.Tx
+		if !session.isAutoCommit {
+			tx = session.tx.Tx
+		}
+		affected, err := loader.BulkLoad(session.ctx, session.DB().DB, tx, tableName, records.columnNames(), records)
+		if err != ErrBulkLoadUnsupported {
+			if err == nil {
+				_ = session.cacheInsert(session.statement.TableName())
+				session.invalidateQueryCacheTables(session.statement.TableName())
+			}
+			return affected, err
+		}
+	}
+
+	needCommit := session.isAutoCommit
+	if needCommit {
+		if err := session.Begin(); err != nil {
+			return 0, err
+		}
+	}
+	affected, err := session.bulkInsert(tableName, records)
+	if needCommit {
+		if err != nil {
+			if rbErr := session.Rollback(); rbErr != nil {
+				session.engine.logger.Errorf("rollback failed: %v", rbErr)
+			}
+			return 0, err
+		}
+		if err := session.Commit(); err != nil {
+			return 0, err
+		}
+	}
+	if err == nil {
+		_ = session.cacheInsert(session.statement.TableName())
+	}
+	return affected, err
+}
+
+// bulkInsert inserts the records by the prepared statements of batches like
+// InsertMulti, the auto increment ids of Oracle and Dameng are taken from the
+// sequence of the table
+func (session *Session) bulkInsert(tableName string, records *bulkRecords) (int64, error) {
+	var (
+		colNames   = records.columnNames()
+		batch      = batchSize(session.engine.dialect.Features().MaxBindParams, 0, len(colNames), defaultBulkBatchSize)
+		args       = make([]interface{}, 0, batch*len(colNames))
+		colPlaces  = strings.TrimSuffix(strings.Repeat("?,", len(colNames)), ",")
+		multiPlace = make([]string, 0, batch)
+		affected   int64
+	)
+	if autoIncr := records.table.AutoIncrColumn(); autoIncr != nil && !records.hasColumn(autoIncr.Name) &&
+		session.engine.dialect.Features().AutoincrMode == dialects.SequenceAutoincrMode {
+		colNames = append(colNames, autoIncr.Name)
+		colPlaces += "," + utils.SeqName(tableName) + ".nextval"
+	}
+
+	session.prepareStmt = true
+	flush := func() error {
+		if len(multiPlace) == 0 {
+			return nil
+		}
+		w := builder.NewWriter()
+		if err := session.statement.WriteInsertMultiple(w, tableName, colNames, multiPlace); err != nil {
+			return err
+		}
+		res, err := session.exec(w.String(), args...)
+		if err != nil {
+			return err
+		}
+		cnt, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		affected += cnt
+		args = args[:0]
+		multiPlace = multiPlace[:0]
+		return nil
+	}
+
+	for records.Next() {
+		values, err := records.Values()
+		if err != nil {
+			return affected, err
+		}
+		args = append(args, values...)
+		multiPlace = append(multiPlace, colPlaces)
+		if len(multiPlace) == batch {
+			if err := flush(); err != nil {
+				return affected, err
+			}
+		}
+	}
+	if err := records.Err(); err != nil {
+		return affected, err
+	}
+	return affected, flush()
+}
+
+// bulkRecords converts the structs of the source to the values of the
+// columns like the inserts
+type bulkRecords struct {
+	session *Session
+	table   *schemas.Table
+	columns []*schemas.Column
+	next    func() (reflect.Value, bool, error)
+	current reflect.Value
+	peeked  bool
+	err     error
+}
+
+func (session *Session) newBulkRecords(source interface{}) (*bulkRecords, error) {
+	records := &bulkRecords{session: session}
+
+	var beanType reflect.Type
+	switch t := source.(type) {
+	case *Rows:
+		beanType = t.beanType
+		records.next = bulkSourceNext(t, beanType)
+	case BulkSource:
+		if session.statement.RefTable == nil {
+			return nil, errors.New("BulkLoad needs the table of the source by Table(bean)")
+		}
+		beanType = session.statement.RefTable.Type
+		records.next = bulkSourceNext(t, beanType)
+	default:
+		sliceValue := reflect.Indirect(reflect.ValueOf(source))
+		if sliceValue.Kind() != reflect.Slice {
+			return nil, errors.New("BulkLoad needs a slice, a *Rows or a BulkSource")
+		}
+		beanType = sliceValue.Type().Elem()
+		for beanType.Kind() == reflect.Ptr || beanType.Kind() == reflect.Interface {
+			if beanType.Kind() == reflect.Interface {
+				if sliceValue.Len() == 0 {
+					return nil, ErrNoElementsOnSlice
+				}
+				beanType = reflect.Indirect(sliceValue.Index(0).Elem()).Type()
+				break
+			}
+			beanType = beanType.Elem()
+		}
+		i := 0
+		records.next = func() (reflect.Value, bool, error) {
+			if i >= sliceValue.Len() {
+				return reflect.Value{}, false, nil
+			}
+			v := sliceValue.Index(i)
+			i++
+			if v.Kind() == reflect.Interface {
+				v = v.Elem()
+			}
+			return reflect.Indirect(v), true, nil
+		}
+	}
+
+	if beanType.Kind() != reflect.Struct {
+		return nil, errors.New("BulkLoad needs the records of structs")
+	}
+	if session.statement.RefTable == nil {
+		if err := session.statement.SetRefValue(reflect.New(beanType)); err != nil {
+			return nil, err
+		}
+	}
+	if len(session.statement.TableName()) == 0 {
+		return nil, ErrTableNotFound
+	}
+	records.table = session.statement.RefTable
+	return records, nil
+}
+
+// bulkSourceNext returns the func to scan the records of the source
+func bulkSourceNext(source BulkSource, beanType reflect.Type) func() (reflect.Value, bool, error) {
+	return func() (reflect.Value, bool, error) {
+		if !source.Next() {
+			return reflect.Value{}, false, source.Err()
+		}
+		bean := reflect.New(beanType)
+		if err := source.Scan(bean.Interface()); err != nil {
+			return reflect.Value{}, false, err
+		}
+		return bean.Elem(), true, nil
+	}
+}
+
+// initColumns decides the columns by the first record, the auto increment
+// column is loaded only if the record has its value
+func (records *bulkRecords) initColumns() error {
+	statement := records.session.statement
+	for _, col := range records.table.Columns() {
+		if col.MapType == schemas.ONLYFROMDB || col.IsDeleted {
+			continue
+		}
+		if statement.OmitColumnMap.Contain(col.Name) {
+			continue
+		}
+		if len(statement.ColumnMap) > 0 && !statement.ColumnMap.Contain(col.Name) {
+			continue
+		}
+		if col.IsAutoIncrement {
+			fieldValue, err := col.ValueOfV(&records.current)
+			if err != nil {
+				return err
+			}
+			if utils.IsValueZero(*fieldValue) {
+				continue
+			}
+		}
+		records.columns = append(records.columns, col)
+	}
+	if len(records.columns) == 0 {
+		return errors.New("BulkLoad needs at least one column")
+	}
+	return nil
+}
+
+func (records *bulkRecords) hasColumn(name string) bool {
+	for _, col := range records.columns {
+		if col.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (records *bulkRecords) columnNames() []string {
+	names := make([]string, 0, len(records.columns))
+	for _, col := range records.columns {
+		names = append(names, col.Name)
+	}
+	return names
+}
+
+func (records *bulkRecords) Next() bool {
+	if records.err != nil {
+		return false
+	}
+	if records.peeked {
+		records.peeked = false
+		return true
+	}
+	var ok bool
+	records.current, ok, records.err = records.next()
+	return ok && records.err == nil
+}
+
+func (records *bulkRecords) Values() ([]interface{}, error) {
+	if records.current.CanAddr() {
+		if processor, ok := records.current.Addr().Interface().(BeforeInsertProcessor); ok {
+			processor.BeforeInsert()
+		}
+	}
+
+	values := make([]interface{}, 0, len(records.columns))
+	for _, col := range records.columns {
+		if (col.IsCreated || col.IsUpdated) && records.session.statement.UseAutoTime {
+			val, _, err := records.session.engine.nowTime(col)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, val)
+			continue
+		}
+		if col.IsVersion && records.session.statement.CheckVersion {
+			values = append(values, 1)
+			continue
+		}
+
+		fieldValue, err := col.ValueOfV(&records.current)
+		if err != nil {
+			return nil, err
+		}
+		value, err := records.session.statement.Value2Interface(col, *fieldValue)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func (records *bulkRecords) Err() error {
+	return records.err
+}
@@ -5,11 +5,14 @@
 package xorm
 
 import (
+	"database/sql"
 	"errors"
+	"reflect"
 	"strconv"
 
 	"xorm.io/builder"
 	"xorm.io/xorm/caches"
+	"xorm.io/xorm/contexts"
 	"xorm.io/xorm/schemas"
 )
 
@@ -86,12 +89,14 @@ func (session *Session) cacheDelete(table *schemas.Table, tableName, sqlStr stri
 // Delete records, bean's non-empty fields are conditions
 // At least one condition must be set.
 func (session *Session) Delete(beans ...interface{}) (int64, error) {
+	session.setOperation(contexts.OperationDelete, beans...)
 	return session.delete(beans, true)
 }
 
 // Truncate records, bean's non-empty fields are conditions
 // In contrast to Delete this method allows deletes without conditions.
 func (session *Session) Truncate(beans ...interface{}) (int64, error) {
+	session.setOperation(contexts.OperationDelete, beans...)
 	return session.delete(beans, false)
 }
 
@@ -163,7 +168,15 @@ func (session *Session) delete(beans []interface{}, mustHaveConditions bool) (in
 	}
 
 	session.statement.RefTable = table
-	res, err := session.exec(realSQLWriter.String(), realSQLWriter.Args()...)
+	var affected int64
+	if session.statement.IsReturning() {
+		affected, err = session.deleteReturning(realSQLWriter.String(), realSQLWriter.Args(), bean)
+	} else {
+		var res sql.Result
+		if res, err = session.exec(realSQLWriter.String(), realSQLWriter.Args()...); err == nil {
+			affected, err = res.RowsAffected()
+		}
+	}
 	if err != nil {
 		return 0, err
 	}
@@ -197,5 +210,23 @@ func (session *Session) delete(beans []interface{}, mustHaveConditions bool) (in
 	cleanupProcessorsClosures(&session.afterClosures)
 	// --
 
-	return res.RowsAffected()
+	return affected, nil
+}
+
+// deleteReturning executes the delete and scans the first deleted row into the
+// bean
+func (session *Session) deleteReturning(sqlStr string, args []interface{}, bean interface{}) (int64, error) {
+	table := session.statement.RefTable
+	rows, err := session.queryRows(sqlStr, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	return session.scanReturning(rows, table, func(i int) interface{} {
+		if i > 0 || bean == nil || reflect.ValueOf(bean).Kind() != reflect.Ptr {
+			return nil
+		}
+		return bean
+	})
 }
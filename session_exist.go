@@ -4,8 +4,11 @@
 
 package xorm
 
+import "xorm.io/xorm/contexts"
+
 // Exist returns true if the record exist otherwise return false
 func (session *Session) Exist(bean ...interface{}) (bool, error) {
+	session.setOperation(contexts.OperationGet, bean...)
 	if session.isAutoClose {
 		defer session.Close()
 	}
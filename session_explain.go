@@ -0,0 +1,528 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"xorm.io/xorm/contexts"
+	"xorm.io/xorm/schemas"
+)
+
+// QueryPlan represents the plan of a query explained by the database
+type QueryPlan struct {
+	SQL  string
+	Args []interface{}
+	// Raw is the plan returned by the database, it's JSON on MySQL and
+	// Postgres, XML on MSSQL and the rows of EXPLAIN QUERY PLAN on SQLite
+	Raw  string
+	Root *PlanNode
+}
+
+// PlanNode represents an operation of the plan
+type PlanNode struct {
+	// Operation is the name of the operation of the database like
+	// Seq Scan, Index Scan, SCAN, SEARCH or Clustered Index Seek
+	Operation string
+	Table     string
+	Index     string
+	// FullScan reports whether the operation reads all the rows of the
+	// table or the index
+	FullScan      bool
+	EstimatedRows float64
+	// ActualRows is only available on Postgres which runs the query
+	ActualRows float64
+	Cost       float64
+	Detail     string
+	Children   []*PlanNode
+}
+
+// FullScans returns the operations reading all the rows of the tables
+func (plan *QueryPlan) FullScans() []*PlanNode {
+	var nodes []*PlanNode
+	plan.Walk(func(node *PlanNode, depth int) {
+		if node.FullScan {
+			nodes = append(nodes, node)
+		}
+	})
+	return nodes
+}
+
+// Walk calls fn for every node of the plan tree depth first
+func (plan *QueryPlan) Walk(fn func(node *PlanNode, depth int)) {
+	var walk func(node *PlanNode, depth int)
+	walk = func(node *PlanNode, depth int) {
+		fn(node, depth)
+		for _, child := range node.Children {
+			walk(child, depth+1)
+		}
+	}
+	if plan.Root != nil {
+		walk(plan.Root, 0)
+	}
+}
+
+// String returns the plan tree indented by the depths of the nodes
+func (plan *QueryPlan) String() string {
+	var buf strings.Builder
+	plan.Walk(func(node *PlanNode, depth int) {
+		buf.WriteString(strings.Repeat("  ", depth))
+		buf.WriteString(node.Operation)
+		if node.Table != "" {
+			buf.WriteString(" on " + node.Table)
+		}
+		if node.Index != "" {
+			buf.WriteString(" using " + node.Index)
+		}
+		if node.FullScan {
+			buf.WriteString(" (full scan)")
+		}
+		buf.WriteByte('\n')
+	})
+	return buf.String()
+}
+
+// Explain returns the plan of the query which Get would run for the bean
+func (session *Session) Explain(beans ...interface{}) (*QueryPlan, error) {
+	session.setOperation(contexts.OperationQuery, beans...)
+	if session.isAutoClose {
+		defer session.Close()
+	}
+	defer session.resetStatement()
+	if session.statement.LastError != nil {
+		return nil, session.statement.LastError
+	}
+
+	sqlStr, args, _, err := session.genGetSQL(beans...)
+	if err != nil {
+		return nil, err
+	}
+	return session.explain(sqlStr, args)
+}
+
+// ExplainFind returns the plan of the query which Find would run for the
+// slice or the map
+func (session *Session) ExplainFind(rowsSlicePtr interface{}, condiBean ...interface{}) (*QueryPlan, error) {
+	session.setOperation(contexts.OperationQuery, rowsSlicePtr)
+	if session.isAutoClose {
+		defer session.Close()
+	}
+	defer session.resetStatement()
+	if session.statement.LastError != nil {
+		return nil, session.statement.LastError
+	}
+
+	sqlStr, args, err := session.genFindSQL(rowsSlicePtr, condiBean...)
+	if err != nil {
+		return nil, err
+	}
+	return session.explain(sqlStr, args)
+}
+
+func (session *Session) explain(sqlStr string, args []interface{}) (*QueryPlan, error) {
+	plan := &QueryPlan{SQL: sqlStr, Args: args}
+
+	var err error
+	switch session.engine.dialect.URI().DBType {
+	case schemas.MYSQL:
+		if plan.Raw, err = session.queryExplainString("EXPLAIN FORMAT=JSON "+sqlStr, args); err != nil {
+			return nil, err
+		}
+		plan.Root, err = parseMySQLPlan(plan.Raw)
+	case schemas.POSTGRES:
+		if plan.Raw, err = session.queryExplainString("EXPLAIN (ANALYZE, FORMAT JSON) "+sqlStr, args); err != nil {
+			return nil, err
+		}
+		plan.Root, err = parsePostgresPlan(plan.Raw)
+	case schemas.SQLITE:
+		var rows []sqlitePlanRow
+		if rows, err = session.querySQLitePlan("EXPLAIN QUERY PLAN "+sqlStr, args); err != nil {
+			return nil, err
+		}
+		plan.Raw, plan.Root = buildSQLitePlan(rows)
+	case schemas.MSSQL:
+		if plan.Raw, err = session.queryShowPlanXML(sqlStr, args); err != nil {
+			return nil, err
+		}
+		plan.Root, err = parseMSSQLPlan(plan.Raw)
+	default:
+		return nil, fmt.Errorf("explain is unsupported on %s", session.engine.dialect.URI().DBType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// queryExplainString returns the first column of the first row of the query
+func (session *Session) queryExplainString(sqlStr string, args []interface{}) (string, error) {
+	rows, err := session.queryRows(sqlStr, args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var raw string
+	if rows.Next() {
+		if err := rows.Scan(&raw); err != nil {
+			return "", err
+		}
+	}
+	return raw, rows.Err()
+}
+
+// queryShowPlanXML returns the plan of the query by SHOWPLAN_XML which must
+// be set on the connection running the query
+func (session *Session) queryShowPlanXML(sqlStr string, args []interface{}) (string, error) {
+	session.queryPreprocess(&sqlStr, args...)
+
+	var conn interface {
+		ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+		QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	}
+	if session.isAutoCommit {
+		c, err := session.DB().Conn(session.ctx)
+		if err != nil {
+			return "", err
+		}
+		defer c.Close()
+		conn = c
+	} else {
+		conn = session.tx.Tx
+	}
+
+	if _, err := conn.ExecContext(session.ctx, "SET SHOWPLAN_XML ON"); err != nil {
+		return "", err
+	}
+	defer func() {
+		if _, err := conn.ExecContext(session.ctx, "SET SHOWPLAN_XML OFF"); err != nil {
+			session.engine.logger.Errorf("set showplan_xml off failed: %v", err)
+		}
+	}()
+
+	rows, err := conn.QueryContext(session.ctx, sqlStr, args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var raw string
+	if rows.Next() {
+		if err := rows.Scan(&raw); err != nil {
+			return "", err
+		}
+	}
+	return raw, rows.Err()
+}
+
+type sqlitePlanRow struct {
+	ID     int64
+	Parent int64
+	Detail string
+}
+
+func (session *Session) querySQLitePlan(sqlStr string, args []interface{}) ([]sqlitePlanRow, error) {
+	rows, err := session.queryRows(sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var planRows []sqlitePlanRow
+	for rows.Next() {
+		var (
+			row     sqlitePlanRow
+			notUsed int64
+		)
+		if err := rows.Scan(&row.ID, &row.Parent, &notUsed, &row.Detail); err != nil {
+			return nil, err
+		}
+		planRows = append(planRows, row)
+	}
+	return planRows, rows.Err()
+}
+
+// planRoot returns the only node or a node of all the nodes
+func planRoot(nodes []*PlanNode) *PlanNode {
+	if len(nodes) == 1 {
+		return nodes[0]
+	}
+	return &PlanNode{Operation: "PLAN", Children: nodes}
+}
+
+// buildSQLitePlan builds the tree by the parents of the rows, the details
+// are like SCAN user, SEARCH user USING INDEX idx_name (name=?) or
+// SCAN TABLE user on the old versions
+func buildSQLitePlan(rows []sqlitePlanRow) (string, *PlanNode) {
+	var (
+		raw   strings.Builder
+		nodes = make(map[int64]*PlanNode, len(rows))
+		roots []*PlanNode
+	)
+	for _, row := range rows {
+		fmt.Fprintf(&raw, "%d|%d|%s\n", row.ID, row.Parent, row.Detail)
+
+		node := &PlanNode{Detail: row.Detail}
+		fields := strings.Fields(row.Detail)
+		if len(fields) > 0 {
+			node.Operation = fields[0]
+		}
+		if (node.Operation == "SCAN" || node.Operation == "SEARCH") && len(fields) > 1 {
+			table := fields[1]
+			if table == "TABLE" && len(fields) > 2 {
+				table = fields[2]
+			}
+			if table != "CONSTANT" && !strings.HasPrefix(table, "SUBQUERY") {
+				node.Table = table
+				node.FullScan = node.Operation == "SCAN"
+			}
+		}
+		if i := strings.Index(row.Detail, "INDEX "); i >= 0 {
+			node.Index = strings.Fields(row.Detail[i+len("INDEX "):])[0]
+		} else if strings.Contains(row.Detail, "INTEGER PRIMARY KEY") {
+			node.Index = "INTEGER PRIMARY KEY"
+		}
+
+		nodes[row.ID] = node
+		if parent, ok := nodes[row.Parent]; ok && row.Parent != row.ID {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+	return raw.String(), planRoot(roots)
+}
+
+// parsePostgresPlan parses the plan of EXPLAIN (FORMAT JSON) which is an
+// array of the objects with the key Plan
+func parsePostgresPlan(raw string) (*PlanNode, error) {
+	var plans []struct {
+		Plan json.RawMessage `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(raw), &plans); err != nil {
+		return nil, err
+	}
+
+	var convert func(data json.RawMessage) (*PlanNode, error)
+	convert = func(data json.RawMessage) (*PlanNode, error) {
+		var p struct {
+			NodeType     string            `json:"Node Type"`
+			RelationName string            `json:"Relation Name"`
+			Schema       string            `json:"Schema"`
+			IndexName    string            `json:"Index Name"`
+			PlanRows     float64           `json:"Plan Rows"`
+			ActualRows   float64           `json:"Actual Rows"`
+			TotalCost    float64           `json:"Total Cost"`
+			Filter       string            `json:"Filter"`
+			IndexCond    string            `json:"Index Cond"`
+			Plans        []json.RawMessage `json:"Plans"`
+		}
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, err
+		}
+		node := &PlanNode{
+			Operation:     p.NodeType,
+			Table:         p.RelationName,
+			Index:         p.IndexName,
+			FullScan:      p.NodeType == "Seq Scan",
+			EstimatedRows: p.PlanRows,
+			ActualRows:    p.ActualRows,
+			Cost:          p.TotalCost,
+			Detail:        p.Filter,
+		}
+		if node.Detail == "" {
+			node.Detail = p.IndexCond
+		}
+		for _, child := range p.Plans {
+			childNode, err := convert(child)
+			if err != nil {
+				return nil, err
+			}
+			node.Children = append(node.Children, childNode)
+		}
+		return node, nil
+	}
+
+	nodes := make([]*PlanNode, 0, len(plans))
+	for _, plan := range plans {
+		node, err := convert(plan.Plan)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	return planRoot(nodes), nil
+}
+
+// parseMySQLPlan parses the plan of EXPLAIN FORMAT=JSON, the tables are the
+// values of the keys table and the other objects like nested_loop or
+// ordering_operation are the operations on them
+func parseMySQLPlan(raw string) (*PlanNode, error) {
+	var plan map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &plan); err != nil {
+		return nil, err
+	}
+
+	var convert func(operation string, obj map[string]interface{}) *PlanNode
+	children := func(node *PlanNode, value interface{}) {
+		switch t := value.(type) {
+		case map[string]interface{}:
+			node.Children = append(node.Children, convert("", t))
+		case []interface{}:
+			for _, v := range t {
+				if obj, ok := v.(map[string]interface{}); ok {
+					node.Children = append(node.Children, convert("", obj))
+				}
+			}
+		}
+	}
+	convert = func(operation string, obj map[string]interface{}) *PlanNode {
+		// the items of nested_loop are the objects of a key table
+		if table, ok := obj["table"].(map[string]interface{}); ok && len(obj) == 1 {
+			obj, operation = table, ""
+		}
+
+		node := &PlanNode{Operation: operation}
+		if tableName, ok := obj["table_name"].(string); ok {
+			accessType, _ := obj["access_type"].(string)
+			node.Operation = accessType
+			node.Table = tableName
+			node.Index, _ = obj["key"].(string)
+			// ALL reads the table and index reads the whole index
+			node.FullScan = accessType == "ALL" || accessType == "index"
+			node.EstimatedRows = mysqlPlanNumber(obj["rows_examined_per_scan"])
+			node.Detail, _ = obj["attached_condition"].(string)
+		}
+		if costInfo, ok := obj["cost_info"].(map[string]interface{}); ok {
+			node.Cost = mysqlPlanNumber(costInfo["query_cost"])
+			if node.Cost == 0 {
+				node.Cost = mysqlPlanNumber(costInfo["prefix_cost"])
+			}
+		}
+
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			switch v := obj[k].(type) {
+			case map[string]interface{}:
+				if k == "cost_info" {
+					continue
+				}
+				if k == "table" {
+					node.Children = append(node.Children, convert("", v))
+				} else {
+					node.Children = append(node.Children, convert(k, v))
+				}
+			case []interface{}:
+				if k == "nested_loop" {
+					child := &PlanNode{Operation: k}
+					children(child, v)
+					node.Children = append(node.Children, child)
+				}
+			}
+		}
+		return node
+	}
+
+	if block, ok := plan["query_block"].(map[string]interface{}); ok {
+		return convert("query_block", block), nil
+	}
+	return convert("", plan), nil
+}
+
+// mysqlPlanNumber converts the numbers of the plan which may be strings
+func mysqlPlanNumber(v interface{}) float64 {
+	switch t := v.(type) {
+	case float64:
+		return t
+	case string:
+		f, _ := strconv.ParseFloat(t, 64)
+		return f
+	}
+	return 0
+}
+
+// parseMSSQLPlan parses the RelOp elements of the showplan XML, the table
+// and the index of an operation are the attributes of its first Object
+// element
+func parseMSSQLPlan(raw string) (*PlanNode, error) {
+	var (
+		decoder = xml.NewDecoder(strings.NewReader(raw))
+		stack   []*PlanNode
+		roots   []*PlanNode
+	)
+	// the plan is declared as utf-16 but decoded to a string by the driver
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "RelOp":
+				node := &PlanNode{}
+				for _, attr := range t.Attr {
+					switch attr.Name.Local {
+					case "PhysicalOp":
+						node.Operation = attr.Value
+					case "LogicalOp":
+						node.Detail = attr.Value
+					case "EstimateRows":
+						node.EstimatedRows, _ = strconv.ParseFloat(attr.Value, 64)
+					case "EstimatedTotalSubtreeCost":
+						node.Cost, _ = strconv.ParseFloat(attr.Value, 64)
+					}
+				}
+				node.FullScan = node.Operation == "Table Scan" ||
+					node.Operation == "Clustered Index Scan" ||
+					node.Operation == "Index Scan"
+				if len(stack) > 0 {
+					parent := stack[len(stack)-1]
+					parent.Children = append(parent.Children, node)
+				} else {
+					roots = append(roots, node)
+				}
+				stack = append(stack, node)
+			case "Object":
+				if len(stack) == 0 || stack[len(stack)-1].Table != "" {
+					continue
+				}
+				node := stack[len(stack)-1]
+				for _, attr := range t.Attr {
+					switch attr.Name.Local {
+					case "Table":
+						node.Table = strings.Trim(attr.Value, "[]")
+					case "Index":
+						node.Index = strings.Trim(attr.Value, "[]")
+					}
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "RelOp" && len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	return planRoot(roots), nil
+}
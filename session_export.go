@@ -0,0 +1,307 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"xorm.io/xorm/contexts"
+	"xorm.io/xorm/convert"
+	"xorm.io/xorm/core"
+	"xorm.io/xorm/dialects"
+	"xorm.io/xorm/schemas"
+)
+
+// ExportFormat represents the format of the exported records
+type ExportFormat int
+
+// enumerate all the export formats
+const (
+	// ExportCSV writes a header of the column names and a line per record
+	ExportCSV ExportFormat = iota
+	// ExportJSONLines writes a JSON object of the columns per line
+	ExportJSONLines
+	// ExportGob writes the column names as a []string and a []interface{} of
+	// the values per record by encoding/gob
+	ExportGob
+)
+
+func init() {
+	// the times are sent as the values of the interfaces
+	gob.Register(time.Time{})
+}
+
+// Export streams the records of the query into w by the format record by
+// record, the query is a raw SQL like Query or the one built by the session
+// like Table(new(User)).Where(...). The integers, the floats, the booleans
+// and the times are typed by the column types, while the decimals, the times
+// of day and the years are exported as strings. The binaries are encoded as
+// base64 in CSV and JSON Lines.
+func (session *Session) Export(w io.Writer, format ExportFormat, sqlOrArgs ...interface{}) error {
+	session.setOperation(contexts.OperationQuery)
+	if session.isAutoClose {
+		defer session.Close()
+	}
+
+	sqlStr, args, err := session.statement.GenQuerySQL(sqlOrArgs...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := session.queryRows(sqlStr, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	fields, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+
+	var exporter recordExporter
+	switch format {
+	case ExportCSV:
+		exporter = &csvExporter{w: csv.NewWriter(w)}
+	case ExportJSONLines:
+		exporter = &jsonLinesExporter{w: bufio.NewWriter(w)}
+	case ExportGob:
+		exporter = &gobExporter{enc: gob.NewEncoder(w)}
+	default:
+		return fmt.Errorf("unknown export format %d", format)
+	}
+	if err := exporter.WriteHeader(fields); err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		values, err := session.engine.row2exportValues(rows, types)
+		if err != nil {
+			return err
+		}
+		if err := exporter.WriteRecord(values); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return exporter.Flush()
+}
+
+var (
+	// the values of the times without dates, the years and the intervals
+	// are not times but strings, e.g. 838:59:59 of a MySQL TIME
+	exportStringTypes = map[string]bool{
+		schemas.Time: true, schemas.Year: true, "INTERVAL": true,
+	}
+	exportIntTypes = map[string]bool{
+		schemas.TinyInt: true, schemas.UnsignedTinyInt: true,
+		schemas.SmallInt: true, schemas.UnsignedSmallInt: true,
+		schemas.MediumInt: true, schemas.UnsignedMediumInt: true,
+		schemas.Int: true, schemas.UnsignedInt: true, schemas.Integer: true,
+		schemas.BigInt: true, schemas.UnsignedBigInt: true,
+		schemas.Serial: true, schemas.BigSerial: true,
+		"INT2": true, "INT4": true, "INT8": true,
+	}
+	exportFloatTypes = map[string]bool{
+		schemas.Real: true, schemas.Float: true, schemas.UnsignedFloat: true, schemas.Double: true,
+		"DOUBLE PRECISION": true, "FLOAT4": true, "FLOAT8": true,
+		"BINARY_FLOAT": true, "BINARY_DOUBLE": true,
+	}
+)
+
+// row2exportValues scans the row and converts the values by the kinds of the
+// column types, the decimals are kept as strings not to lose the precision
+func (engine *Engine) row2exportValues(rows *core.Rows, types []*sql.ColumnType) ([]interface{}, error) {
+	scanResults := make([]interface{}, len(types))
+	for i := range types {
+		scanResults[i] = &sql.NullString{}
+	}
+	if err := engine.driver.Scan(&dialects.ScanContext{
+		DBLocation:   engine.DatabaseTZ,
+		UserLocation: engine.TZLocation,
+	}, rows, types, scanResults...); err != nil {
+		return nil, err
+	}
+
+	values := make([]interface{}, len(types))
+	for i, tp := range types {
+		s := scanResults[i].(*sql.NullString)
+		if !s.Valid {
+			continue
+		}
+
+		var scanKind reflect.Kind
+		if scanType := tp.ScanType(); scanType != nil {
+			scanKind = scanType.Kind()
+		}
+		typeName := strings.ToUpper(tp.DatabaseTypeName())
+		switch kind := engine.dialect.ColumnTypeKind(typeName); {
+		case exportStringTypes[typeName]:
+			values[i] = s.String
+		case kind == schemas.TIME_TYPE:
+			t, err := engine.exportTime(s.String)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = t
+		case kind == schemas.BOOL_TYPE || scanKind == reflect.Bool:
+			b, err := convert.AsBool(s.String)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = b
+		case exportIntTypes[typeName] || (scanKind >= reflect.Int && scanKind <= reflect.Uint64):
+			if n, err := strconv.ParseInt(s.String, 10, 64); err == nil {
+				values[i] = n
+			} else if n, err := strconv.ParseUint(s.String, 10, 64); err == nil {
+				values[i] = n
+			} else {
+				values[i] = s.String
+			}
+		case exportFloatTypes[typeName] || scanKind == reflect.Float32 || scanKind == reflect.Float64:
+			if f, err := strconv.ParseFloat(s.String, 64); err == nil {
+				values[i] = f
+			} else {
+				values[i] = s.String
+			}
+		case kind == schemas.BLOB_TYPE:
+			values[i] = []byte(s.String)
+		default:
+			values[i] = s.String
+		}
+	}
+	return values, nil
+}
+
+// exportTime parses a time scanned as a string. The times of the drivers are
+// formatted as RFC3339Nano, whose offsets, e.g. the negative ones, are not all
+// parsed by String2Time, while the UTC ones are in the database time zone.
+func (engine *Engine) exportTime(s string) (time.Time, error) {
+	if len(s) > 19 && s[10] == 'T' && !strings.HasSuffix(s, "Z") {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return t.In(engine.TZLocation), nil
+		}
+	}
+	t, err := convert.String2Time(s, engine.DatabaseTZ, engine.TZLocation)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return *t, nil
+}
+
+// recordExporter writes the records of a format
+type recordExporter interface {
+	WriteHeader(fields []string) error
+	WriteRecord(values []interface{}) error
+	Flush() error
+}
+
+// exportString formats the value as a string for CSV
+func exportString(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	case []byte:
+		return base64.StdEncoding.EncodeToString(t)
+	}
+	return convert.AsString(v)
+}
+
+type csvExporter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func (e *csvExporter) WriteHeader(fields []string) error {
+	e.record = make([]string, len(fields))
+	return e.w.Write(fields)
+}
+
+func (e *csvExporter) WriteRecord(values []interface{}) error {
+	for i, v := range values {
+		e.record[i] = exportString(v)
+	}
+	return e.w.Write(e.record)
+}
+
+func (e *csvExporter) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type jsonLinesExporter struct {
+	w    *bufio.Writer
+	keys [][]byte
+}
+
+func (e *jsonLinesExporter) WriteHeader(fields []string) error {
+	// the keys are written in the order of the columns
+	e.keys = make([][]byte, len(fields))
+	for i, field := range fields {
+		key, err := json.Marshal(field)
+		if err != nil {
+			return err
+		}
+		e.keys[i] = key
+	}
+	return nil
+}
+
+func (e *jsonLinesExporter) WriteRecord(values []interface{}) error {
+	_ = e.w.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			_ = e.w.WriteByte(',')
+		}
+		_, _ = e.w.Write(e.keys[i])
+		_ = e.w.WriteByte(':')
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, _ = e.w.Write(data)
+	}
+	_, err := e.w.WriteString("}\n")
+	return err
+}
+
+func (e *jsonLinesExporter) Flush() error {
+	return e.w.Flush()
+}
+
+type gobExporter struct {
+	enc *gob.Encoder
+}
+
+func (e *gobExporter) WriteHeader(fields []string) error {
+	return e.enc.Encode(fields)
+}
+
+func (e *gobExporter) WriteRecord(values []interface{}) error {
+	return e.enc.Encode(values)
+}
+
+func (e *gobExporter) Flush() error {
+	return nil
+}
@@ -12,6 +12,7 @@ import (
 
 	"xorm.io/builder"
 	"xorm.io/xorm/caches"
+	"xorm.io/xorm/contexts"
 	"xorm.io/xorm/convert"
 	"xorm.io/xorm/internal/statements"
 	"xorm.io/xorm/internal/utils"
@@ -27,18 +28,25 @@ const (
 // are conditions. beans could be []Struct, []*Struct, map[int64]Struct
 // map[int64]*Struct
 func (session *Session) Find(rowsSlicePtr interface{}, condiBean ...interface{}) error {
+	session.setOperation(contexts.OperationFind, rowsSlicePtr)
 	if session.isAutoClose {
 		defer session.Close()
 	}
-	return session.find(rowsSlicePtr, condiBean...)
+	preloads := session.statement.Preloads()
+	if err := session.find(rowsSlicePtr, condiBean...); err != nil {
+		return err
+	}
+	return session.preload(rowsSlicePtr, preloads)
 }
 
 // FindAndCount find the results and also return the counts
 func (session *Session) FindAndCount(rowsSlicePtr interface{}, condiBean ...interface{}) (int64, error) {
+	session.setOperation(contexts.OperationFind, rowsSlicePtr)
 	if session.isAutoClose {
 		defer session.Close()
 	}
 
+	preloads := session.statement.Preloads()
 	session.autoResetStatement = false
 	err := session.find(rowsSlicePtr, condiBean...)
 	if err != nil {
@@ -63,6 +71,10 @@ func (session *Session) FindAndCount(rowsSlicePtr interface{}, condiBean ...inte
 		session.statement.ColumnMap = []string{}
 	}
 	session.statement.ResetOrderBy()
+	// the count is cached by its own key
+	if cacheFor := session.statement.CacheFor; cacheFor != nil && cacheFor.Key != "" {
+		session.statement.SetCacheFor(cacheFor.TTL, cacheFor.Key+":count")
+	}
 	if session.statement.LimitN != nil {
 		session.statement.LimitN = nil
 	}
@@ -71,11 +83,16 @@ func (session *Session) FindAndCount(rowsSlicePtr interface{}, condiBean ...inte
 	}
 
 	// session has stored the conditions so we use `unscoped` to avoid duplicated condition.
+	var count int64
 	if sliceElementType.Kind() == reflect.Struct {
-		return session.Unscoped().Count(reflect.New(sliceElementType).Interface())
+		count, err = session.Unscoped().Count(reflect.New(sliceElementType).Interface())
+	} else {
+		count, err = session.Unscoped().Count()
 	}
-
-	return session.Unscoped().Count()
+	if err != nil {
+		return 0, err
+	}
+	return count, session.preload(rowsSlicePtr, preloads)
 }
 
 func (session *Session) find(rowsSlicePtr interface{}, condiBean ...interface{}) error {
@@ -84,11 +101,76 @@ func (session *Session) find(rowsSlicePtr interface{}, condiBean ...interface{})
 		return session.statement.LastError
 	}
 
+	sqlStr, args, err := session.genFindSQL(rowsSlicePtr, condiBean...)
+	if err != nil {
+		return err
+	}
+
+	var (
+		sliceValue       = reflect.Indirect(reflect.ValueOf(rowsSlicePtr))
+		sliceElementType = sliceValue.Type().Elem()
+		table            = session.statement.RefTable
+		isSlice          = sliceValue.Kind() == reflect.Slice
+		start            = sliceValue.Len()
+	)
+
+	// the records appended to the slice are cached, the maps are cached only
+	// if they are empty
+	var (
+		query  *cachedQuery
+		result reflect.Value
+	)
+	if isSlice || start == 0 {
+		result = reflect.New(sliceValue.Type())
+		query = session.newCachedQuery("Find", sqlStr, args, result.Interface())
+		if _, hit := session.getCachedQuery(query, result.Interface()); hit {
+			if isSlice {
+				sliceValue.Set(reflect.AppendSlice(sliceValue, result.Elem()))
+			} else if sliceValue.IsNil() {
+				sliceValue.Set(result.Elem())
+			} else {
+				iter := result.Elem().MapRange()
+				for iter.Next() {
+					sliceValue.SetMapIndex(iter.Key(), iter.Value())
+				}
+			}
+			return nil
+		}
+	}
+
+	if session.statement.ColumnMap.IsEmpty() && session.canCache() {
+		if cacher := session.engine.GetCacher(session.statement.TableName()); cacher != nil &&
+			!session.statement.IsDistinct &&
+			!session.statement.GetUnscoped() {
+			err = session.cacheFind(sliceElementType, sqlStr, rowsSlicePtr, args...)
+			if err != ErrCacheFailed {
+				return err
+			}
+			session.engine.logger.Warnf("Cache Find Failed")
+		}
+	}
+
+	if err := session.noCacheFind(table, sliceValue, sqlStr, args...); err != nil {
+		return err
+	}
+	if query != nil {
+		if isSlice {
+			result.Elem().Set(sliceValue.Slice(start, sliceValue.Len()))
+		} else {
+			result.Elem().Set(sliceValue)
+		}
+		session.putCachedQuery(query, true, result.Interface())
+	}
+	return nil
+}
+
+// genFindSQL generates the SQL of Find for the slice or the map
+func (session *Session) genFindSQL(rowsSlicePtr interface{}, condiBean ...interface{}) (string, []interface{}, error) {
 	sliceValue := reflect.Indirect(reflect.ValueOf(rowsSlicePtr))
 	isSlice := sliceValue.Kind() == reflect.Slice
 	isMap := sliceValue.Kind() == reflect.Map
 	if !isSlice && !isMap {
-		return errors.New("needs a pointer to a slice or a map")
+		return "", nil, errors.New("needs a pointer to a slice or a map")
 	}
 
 	sliceElementType := sliceValue.Type().Elem()
@@ -99,7 +181,7 @@ func (session *Session) find(rowsSlicePtr interface{}, condiBean ...interface{})
 			if sliceElementType.Elem().Kind() == reflect.Struct {
 				pv := reflect.New(sliceElementType.Elem())
 				if err := session.statement.SetRefValue(pv); err != nil {
-					return err
+					return "", nil, err
 				}
 			} else {
 				tp = tpNonStruct
@@ -107,7 +189,7 @@ func (session *Session) find(rowsSlicePtr interface{}, condiBean ...interface{})
 		} else if sliceElementType.Kind() == reflect.Struct {
 			pv := reflect.New(sliceElementType)
 			if err := session.statement.SetRefValue(pv); err != nil {
-				return err
+				return "", nil, err
 			}
 		} else {
 			tp = tpNonStruct
@@ -123,11 +205,11 @@ func (session *Session) find(rowsSlicePtr interface{}, condiBean ...interface{})
 		if !session.statement.NoAutoCondition && len(condiBean) > 0 {
 			condTable, err := session.engine.tagParser.Parse(reflect.ValueOf(condiBean[0]))
 			if err != nil {
-				return err
+				return "", nil, err
 			}
 			autoCond, err = session.statement.BuildConds(condTable, condiBean[0], true, true, false, true, addedTableName)
 			if err != nil {
-				return err
+				return "", nil, err
 			}
 		} else {
 			if col := table.DeletedColumn(); col != nil && !session.statement.GetUnscoped() { // tag "deleted" is enabled
@@ -143,24 +225,7 @@ func (session *Session) find(rowsSlicePtr interface{}, condiBean ...interface{})
 		}
 	}
 
-	sqlStr, args, err := session.statement.GenFindSQL(autoCond)
-	if err != nil {
-		return err
-	}
-
-	if session.statement.ColumnMap.IsEmpty() && session.canCache() {
-		if cacher := session.engine.GetCacher(session.statement.TableName()); cacher != nil &&
-			!session.statement.IsDistinct &&
-			!session.statement.GetUnscoped() {
-			err = session.cacheFind(sliceElementType, sqlStr, rowsSlicePtr, args...)
-			if err != ErrCacheFailed {
-				return err
-			}
-			session.engine.logger.Warnf("Cache Find Failed")
-		}
-	}
-
-	return session.noCacheFind(table, sliceValue, sqlStr, args...)
+	return session.statement.GenFindSQL(autoCond)
 }
 
 type QueryedField struct {
@@ -459,6 +524,7 @@ func (session *Session) cacheFind(t reflect.Type, sqlStr string, rowsSlicePtr in
 			session.engine.tagParser,
 			session.engine.DatabaseTZ,
 		)
+		session.statement.SetServerVersion(session.engine.serverVersion)
 		if len(table.PrimaryKeys) == 1 {
 			ff := make([]interface{}, 0, len(ides))
 			for _, ie := range ides {
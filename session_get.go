@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"xorm.io/xorm/caches"
+	"xorm.io/xorm/contexts"
 	"xorm.io/xorm/convert"
 	"xorm.io/xorm/core"
 	"xorm.io/xorm/internal/utils"
@@ -28,10 +29,16 @@ var (
 // Get retrieve one record from database, bean's non-empty fields
 // will be as conditions
 func (session *Session) Get(beans ...interface{}) (bool, error) {
+	session.setOperation(contexts.OperationGet, beans...)
 	if session.isAutoClose {
 		defer session.Close()
 	}
-	return session.get(beans...)
+	preloads := session.statement.Preloads()
+	has, err := session.get(beans...)
+	if err != nil || !has || len(preloads) == 0 {
+		return has, err
+	}
+	return has, session.preload(beans[0], preloads)
 }
 
 func isPtrOfTime(v interface{}) bool {
@@ -53,46 +60,20 @@ func (session *Session) get(beans ...interface{}) (bool, error) {
 	if session.statement.LastError != nil {
 		return false, session.statement.LastError
 	}
-	if len(beans) == 0 {
-		return false, errors.New("needs at least one parameter for get")
-	}
-
-	beanValue := reflect.ValueOf(beans[0])
-	if beanValue.Kind() != reflect.Ptr {
-		return false, errors.New("needs a pointer to a value")
-	} else if beanValue.Elem().Kind() == reflect.Ptr {
-		return false, errors.New("a pointer to a pointer is not allowed")
-	} else if beanValue.IsNil() {
-		return false, ErrObjectIsNil
-	}
 
-	var isStruct = beanValue.Elem().Kind() == reflect.Struct && !isPtrOfTime(beans[0])
-	if isStruct {
-		if err := session.statement.SetRefBean(beans[0]); err != nil {
-			return false, err
-		}
+	sqlStr, args, isStruct, err := session.genGetSQL(beans...)
+	if err != nil {
+		return false, err
 	}
 
-	var sqlStr string
-	var args []interface{}
-	var err error
+	beanValue := reflect.ValueOf(beans[0])
+	table := session.statement.RefTable
 
-	if session.statement.RawSQL == "" {
-		if len(session.statement.TableName()) == 0 {
-			return false, ErrTableNotFound
-		}
-		session.statement.Limit(1)
-		sqlStr, args, err = session.statement.GenGetSQL(beans[0])
-		if err != nil {
-			return false, err
-		}
-	} else {
-		sqlStr = session.statement.GenRawSQL()
-		args = session.statement.RawParams
+	query := session.newCachedQuery("Get", sqlStr, args, beans...)
+	if has, hit := session.getCachedQuery(query, beans...); hit {
+		return has, nil
 	}
 
-	table := session.statement.RefTable
-
 	if session.statement.ColumnMap.IsEmpty() && session.canCache() && isStruct {
 		if cacher := session.engine.GetCacher(session.statement.TableName()); cacher != nil &&
 			!session.statement.GetUnscoped() {
@@ -118,9 +99,13 @@ func (session *Session) get(beans ...interface{}) (bool, error) {
 	}
 
 	has, err := session.nocacheGet(beanValue.Elem().Kind(), table, beans, sqlStr, args...)
-	if err != nil || !has {
+	if err != nil {
 		return has, err
 	}
+	session.putCachedQuery(query, has, beans...)
+	if !has {
+		return false, nil
+	}
 
 	if context != nil && isStruct {
 		context.Put(fmt.Sprintf("%v-%v", sqlStr, args), beans[0])
@@ -129,6 +114,50 @@ func (session *Session) get(beans ...interface{}) (bool, error) {
 	return true, nil
 }
 
+// genGetSQL generates the SQL of Get for the beans, isStruct reports whether
+// the bean is a struct
+func (session *Session) genGetSQL(beans ...interface{}) (string, []interface{}, bool, error) {
+	if len(beans) == 0 {
+		return "", nil, false, errors.New("needs at least one parameter for get")
+	}
+
+	beanValue := reflect.ValueOf(beans[0])
+	if beanValue.Kind() != reflect.Ptr {
+		return "", nil, false, errors.New("needs a pointer to a value")
+	} else if beanValue.Elem().Kind() == reflect.Ptr {
+		return "", nil, false, errors.New("a pointer to a pointer is not allowed")
+	} else if beanValue.IsNil() {
+		return "", nil, false, ErrObjectIsNil
+	}
+
+	var isStruct = beanValue.Elem().Kind() == reflect.Struct && !isPtrOfTime(beans[0])
+	if isStruct {
+		if err := session.statement.SetRefBean(beans[0]); err != nil {
+			return "", nil, false, err
+		}
+	}
+
+	var (
+		sqlStr string
+		args   []interface{}
+		err    error
+	)
+	if session.statement.RawSQL == "" {
+		if len(session.statement.TableName()) == 0 {
+			return "", nil, false, ErrTableNotFound
+		}
+		session.statement.Limit(1)
+		sqlStr, args, err = session.statement.GenGetSQL(beans[0])
+		if err != nil {
+			return "", nil, false, err
+		}
+	} else {
+		sqlStr = session.statement.GenRawSQL()
+		args = session.statement.RawParams
+	}
+	return sqlStr, args, isStruct, nil
+}
+
 func isScannableStruct(bean interface{}, typeLen int) bool {
 	switch bean.(type) {
 	case *time.Time:
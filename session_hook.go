@@ -0,0 +1,44 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"context"
+
+	"xorm.io/xorm/contexts"
+	"xorm.io/xorm/log"
+)
+
+// setOperation records the operation of the session and its beans which are
+// passed to the hooks with the SQL
+func (session *Session) setOperation(operation contexts.Operation, beans ...interface{}) {
+	session.operation = operation
+	session.operationBeans = beans
+}
+
+// sessionID returns the id of the session logged with the SQL or a new one
+func (session *Session) sessionID() string {
+	if id, ok := session.ctx.Value(log.SessionIDKey).(string); ok {
+		return id
+	}
+	if session.id == "" {
+		session.id = newSessionID()
+	}
+	return session.id
+}
+
+// hookContext returns the context of the session carrying the metadata of
+// the operation to the hooks
+func (session *Session) hookContext() context.Context {
+	if session.operation == "" {
+		return session.ctx
+	}
+	return contexts.WithMetadata(session.ctx, &contexts.Metadata{
+		Operation: session.operation,
+		Table:     session.statement.RefTable,
+		Beans:     session.operationBeans,
+		SessionID: session.sessionID(),
+	})
+}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"xorm.io/builder"
+	"xorm.io/xorm/contexts"
 	"xorm.io/xorm/convert"
 	"xorm.io/xorm/dialects"
 	"xorm.io/xorm/internal/utils"
@@ -24,6 +25,7 @@ var ErrNoElementsOnSlice = errors.New("no element on slice when insert")
 
 // Insert insert one or more beans
 func (session *Session) Insert(beans ...interface{}) (int64, error) {
+	session.setOperation(contexts.OperationInsert, beans...)
 	var affected int64
 	var err error
 
@@ -37,6 +39,10 @@ func (session *Session) Insert(beans ...interface{}) (int64, error) {
 		session.resetStatement()
 	}()
 
+	if session.statement.IsUpsert() {
+		return session.upsert(beans...)
+	}
+
 	for _, bean := range beans {
 		var cnt int64
 		var err error
@@ -187,54 +193,190 @@ func (session *Session) insertMultipleStruct(rowsSlicePtr interface{}) (int64, e
 	}
 	cleanupProcessorsClosures(&session.beforeClosures)
 
-	w := builder.NewWriter()
-	if err := session.statement.WriteInsertMultiple(w, tableName, colNames, colMultiPlaces); err != nil {
-		return 0, err
+	// split the rows into batches within the parameters limit of the database
+	var (
+		rowParams = len(args) / size
+		batch     = batchSize(session.engine.dialect.Features().MaxBindParams, 0, rowParams, size)
+		assignsID = len(table.AutoIncrement) > 0 && utils.IndexSlice(colNames, table.AutoIncrement) < 0
+		idStep    int64
+	)
+	if assignsID && batch > 1 {
+		idStep = session.insertIDStep()
 	}
 
-	res, err := session.exec(w.String(), args...)
+	return session.inBatches(size, batch, false, func(start, end int) (int64, error) {
+		return session.insertMultipleBatch(sliceValue, start, end, tableName, colNames,
+			colMultiPlaces[start:end], args[start*rowParams:end*rowParams], assignsID, idStep)
+	}, func() {
+		_ = session.cacheInsert(tableName)
+
+		lenAfterClosures := len(session.afterClosures)
+		for i := 0; i < size; i++ {
+			elemValue := reflect.Indirect(sliceValue.Index(i)).Addr().Interface()
+
+			// handle AfterInsertProcessor
+			if session.isAutoCommit {
+				// !nashtsai! does user expect it's same slice to passed closure when using Before()/After() when insert multi??
+				for _, closure := range session.afterClosures {
+					closure(elemValue)
+				}
+				if processor, ok := elemValue.(AfterInsertProcessor); ok {
+					processor.AfterInsert()
+				}
+			} else {
+				if lenAfterClosures > 0 {
+					if value, has := session.afterInsertBeans[elemValue]; has && value != nil {
+						*value = append(*value, session.afterClosures...)
+					} else {
+						afterClosures := make([]func(interface{}), lenAfterClosures)
+						copy(afterClosures, session.afterClosures)
+						session.afterInsertBeans[elemValue] = &afterClosures
+					}
+				} else {
+					if _, ok := elemValue.(AfterInsertProcessor); ok {
+						session.afterInsertBeans[elemValue] = nil
+					}
+				}
+			}
+		}
+
+		cleanupProcessorsClosures(&session.afterClosures)
+	})
+}
+
+// insertIDStep returns the step between the auto increment ids generated by
+// a multiple insert of MySQL, which are consecutive unless InnoDB interleaves
+// the ids of the concurrent inserts with innodb_autoinc_lock_mode = 2. It's 0
+// if the ids can't be computed from the first one.
+func (session *Session) insertIDStep() int64 {
+	if session.engine.dialect.URI().DBType != schemas.MYSQL {
+		return 0
+	}
+	rows, err := session.queryRows("SELECT @@innodb_autoinc_lock_mode, @@auto_increment_increment")
 	if err != nil {
-		return 0, err
+		return 0
 	}
+	defer rows.Close()
 
-	_ = session.cacheInsert(tableName)
+	var lockMode, increment int64
+	if !rows.Next() || rows.Scan(&lockMode, &increment) != nil {
+		return 0
+	}
+	if lockMode == 2 || increment < 1 {
+		return 0
+	}
+	return increment
+}
 
-	lenAfterClosures := len(session.afterClosures)
-	for i := 0; i < size; i++ {
-		elemValue := reflect.Indirect(sliceValue.Index(i)).Addr().Interface()
+// insertMultipleBatch inserts the rows from start to end of the slice with one
+// statement, and writes the generated auto increment ids back into them
+func (session *Session) insertMultipleBatch(sliceValue reflect.Value, start, end int, tableName string,
+	colNames, colMultiPlaces []string, args []interface{}, assignsID bool, idStep int64) (int64, error) {
+	w := builder.NewWriter()
+	if err := session.statement.WriteInsertMultiple(w, tableName, colNames, colMultiPlaces); err != nil {
+		return 0, err
+	}
+
+	if session.statement.IsReturning() {
+		rows, err := session.queryRows(w.String(), args...)
+		if err != nil {
+			return 0, err
+		}
+		defer rows.Close()
 
-		// handle AfterInsertProcessor
-		if session.isAutoCommit {
-			// !nashtsai! does user expect it's same slice to passed closure when using Before()/After() when insert multi??
-			for _, closure := range session.afterClosures {
-				closure(elemValue)
+		// the rows returned in an arbitrary order are not written back
+		inOrder := end-start == 1 || session.statement.ReturnsInOrder()
+		return session.scanReturning(rows, session.statement.RefTable, func(i int) interface{} {
+			if !inOrder || start+i >= end {
+				return nil
 			}
-			if processor, ok := elemValue.(AfterInsertProcessor); ok {
-				processor.AfterInsert()
+			return sliceElemBean(sliceValue.Index(start + i))
+		})
+	}
+
+	if session.statement.InsertMultipleReturnsID(colNames, end-start) {
+		rows, err := session.queryRows(w.String(), args...)
+		if err != nil {
+			return 0, err
+		}
+		defer rows.Close()
+
+		var affected int64
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				return affected, err
 			}
-		} else {
-			if lenAfterClosures > 0 {
-				if value, has := session.afterInsertBeans[elemValue]; has && value != nil {
-					*value = append(*value, session.afterClosures...)
-				} else {
-					afterClosures := make([]func(interface{}), lenAfterClosures)
-					copy(afterClosures, session.afterClosures)
-					session.afterInsertBeans[elemValue] = &afterClosures
-				}
-			} else {
-				if _, ok := elemValue.(AfterInsertProcessor); ok {
-					session.afterInsertBeans[elemValue] = nil
+			if start+int(affected) < end {
+				if err := session.assignAutoIncrID(sliceValue.Index(start+int(affected)), id); err != nil {
+					return affected, err
 				}
 			}
+			affected++
 		}
+		return affected, rows.Err()
 	}
 
-	cleanupProcessorsClosures(&session.afterClosures)
-	return res.RowsAffected()
+	res, err := session.exec(w.String(), args...)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if !assignsID || affected != int64(end-start) {
+		return affected, nil
+	}
+
+	// the id of the only row is returned, or the first one of MySQL and the
+	// others are computed by the step
+	switch session.engine.dialect.URI().DBType {
+	case schemas.MYSQL, schemas.SQLITE:
+	default:
+		return affected, nil
+	}
+	if end-start > 1 && idStep <= 0 {
+		return affected, nil
+	}
+	firstID, err := res.LastInsertId()
+	if err != nil || firstID <= 0 {
+		return affected, nil
+	}
+	for i := start; i < end; i++ {
+		if err := session.assignAutoIncrID(sliceValue.Index(i), firstID+int64(i-start)*idStep); err != nil {
+			return affected, err
+		}
+	}
+	return affected, nil
 }
 
-// InsertMulti insert multiple records
+// assignAutoIncrID sets the auto increment field of the element of a slice
+func (session *Session) assignAutoIncrID(elem reflect.Value, id int64) error {
+	bean := sliceElemBean(elem)
+	if bean == nil {
+		return nil
+	}
+	aiValue, err := session.statement.RefTable.AutoIncrColumn().ValueOf(bean)
+	if err != nil {
+		return err
+	}
+	if aiValue == nil || !aiValue.IsValid() || !aiValue.CanSet() {
+		return nil
+	}
+	return convert.AssignValue(*aiValue, id)
+}
+
+// InsertMulti insert multiple records, which are split into batches by the
+// parameters limit of the database and inserted within a transaction if the
+// session is not in one. The generated auto increment ids are written back
+// into the records when the database returns them in the order of the
+// records, which is PostgreSQL, or MySQL if the ids of a statement are known
+// to be consecutive, i.e. innodb_autoinc_lock_mode is not 2. Otherwise they
+// are written back only when a batch has one record, since the rows returned
+// by SQLite and MSSQL are in an arbitrary order.
 func (session *Session) InsertMulti(rowsSlicePtr interface{}) (int64, error) {
+	session.setOperation(contexts.OperationInsert, rowsSlicePtr)
 	if session.isAutoClose {
 		defer session.Close()
 	}
@@ -244,6 +386,12 @@ func (session *Session) InsertMulti(rowsSlicePtr interface{}) (int64, error) {
 		return 0, ErrPtrSliceType
 	}
 
+	session.autoResetStatement = false
+	defer func() {
+		session.autoResetStatement = true
+		session.resetStatement()
+	}()
+
 	return session.insertMultipleStruct(rowsSlicePtr)
 }
 
@@ -279,35 +427,14 @@ func (session *Session) insertStruct(bean interface{}) (int64, error) {
 	}
 	sqlStr = session.engine.dialect.Quoter().Replace(sqlStr)
 
-	handleAfterInsertProcessorFunc := func(bean interface{}) {
-		if session.isAutoCommit {
-			for _, closure := range session.afterClosures {
-				closure(bean)
-			}
-			if processor, ok := interface{}(bean).(AfterInsertProcessor); ok {
-				processor.AfterInsert()
-			}
-		} else {
-			lenAfterClosures := len(session.afterClosures)
-			if lenAfterClosures > 0 {
-				if value, has := session.afterInsertBeans[bean]; has && value != nil {
-					*value = append(*value, session.afterClosures...)
-				} else {
-					afterClosures := make([]func(interface{}), lenAfterClosures)
-					copy(afterClosures, session.afterClosures)
-					session.afterInsertBeans[bean] = &afterClosures
-				}
-			} else {
-				if _, ok := interface{}(bean).(AfterInsertProcessor); ok {
-					session.afterInsertBeans[bean] = nil
-				}
-			}
-		}
-		cleanupProcessorsClosures(&session.afterClosures) // cleanup after used
+	if session.statement.IsReturning() {
+		return session.insertReturning(bean, sqlStr, args...)
 	}
 
-	// if there is auto increment column and driver don't support return it
-	if len(table.AutoIncrement) > 0 && !session.engine.driver.Features().SupportReturnInsertedID {
+	// if there is auto increment column and driver don't support return it,
+	// the ids generated by YDB are not returned
+	if len(table.AutoIncrement) > 0 && !session.engine.driver.Features().SupportReturnInsertedID &&
+		session.engine.dialect.URI().DBType != schemas.YDB {
 		var sql string
 		var newArgs []interface{}
 		var needCommit bool
@@ -352,7 +479,7 @@ func (session *Session) insertStruct(bean interface{}) (int64, error) {
 			return 0, errors.New("insert successfully but not returned id")
 		}
 
-		defer handleAfterInsertProcessorFunc(bean)
+		defer session.handleAfterInsertProcessor(bean)
 
 		_ = session.cacheInsert(tableName)
 
@@ -382,7 +509,7 @@ func (session *Session) insertStruct(bean interface{}) (int64, error) {
 		return 0, err
 	}
 
-	defer handleAfterInsertProcessorFunc(bean)
+	defer session.handleAfterInsertProcessor(bean)
 
 	_ = session.cacheInsert(tableName)
 
@@ -421,11 +548,73 @@ func (session *Session) insertStruct(bean interface{}) (int64, error) {
 	return res.RowsAffected()
 }
 
+// insertReturning inserts the bean and scans the returned row into it
+func (session *Session) insertReturning(bean interface{}, sqlStr string, args ...interface{}) (int64, error) {
+	tableName := session.statement.TableName()
+	table := session.statement.RefTable
+
+	rows, err := session.queryRows(sqlStr, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	affected, err := session.scanReturning(rows, table, func(int) interface{} { return bean })
+	if err != nil {
+		return affected, err
+	}
+	rows.Close()
+
+	defer session.handleAfterInsertProcessor(bean)
+
+	_ = session.cacheInsert(tableName)
+
+	if table.Version != "" && session.statement.CheckVersion && !session.statement.IsReturningColumn(table.Version) {
+		verValue, err := table.VersionColumn().ValueOf(bean)
+		if err != nil {
+			session.engine.logger.Errorf("%v", err)
+		} else if verValue.IsValid() && verValue.CanSet() {
+			session.incrVersionFieldValue(verValue)
+		}
+	}
+	return affected, nil
+}
+
+// handleAfterInsertProcessor calls the after insert closures and processor of
+// the bean, which are delayed until commit in a transaction
+func (session *Session) handleAfterInsertProcessor(bean interface{}) {
+	if session.isAutoCommit {
+		for _, closure := range session.afterClosures {
+			closure(bean)
+		}
+		if processor, ok := interface{}(bean).(AfterInsertProcessor); ok {
+			processor.AfterInsert()
+		}
+	} else {
+		lenAfterClosures := len(session.afterClosures)
+		if lenAfterClosures > 0 {
+			if value, has := session.afterInsertBeans[bean]; has && value != nil {
+				*value = append(*value, session.afterClosures...)
+			} else {
+				afterClosures := make([]func(interface{}), lenAfterClosures)
+				copy(afterClosures, session.afterClosures)
+				session.afterInsertBeans[bean] = &afterClosures
+			}
+		} else {
+			if _, ok := interface{}(bean).(AfterInsertProcessor); ok {
+				session.afterInsertBeans[bean] = nil
+			}
+		}
+	}
+	cleanupProcessorsClosures(&session.afterClosures) // cleanup after used
+}
+
 // InsertOne insert only one struct into database as a record.
 // The in parameter bean must a struct or a point to struct. The return
 // parameter is inserted and error
 // Deprecated: Please use Insert directly
 func (session *Session) InsertOne(bean interface{}) (int64, error) {
+	session.setOperation(contexts.OperationInsert, bean)
 	if session.isAutoClose {
 		defer session.Close()
 	}
@@ -462,9 +651,10 @@ func (session *Session) genInsertColumns(bean interface{}) ([]string, []interfac
 		if len(session.statement.ColumnMap) > 0 && !session.statement.ColumnMap.Contain(col.Name) {
 			continue
 		}
-		if session.statement.IncrColumns.IsColExist(col.Name) {
+		// an upsert inserts the incremented columns as is and increases them on update
+		if session.statement.IncrColumns.IsColExist(col.Name) && !session.statement.IsUpsert() {
 			continue
-		} else if session.statement.DecrColumns.IsColExist(col.Name) {
+		} else if session.statement.DecrColumns.IsColExist(col.Name) && !session.statement.IsUpsert() {
 			continue
 		} else if session.statement.ExprColumns.IsColExist(col.Name) {
 			continue
@@ -7,6 +7,7 @@ package xorm
 import (
 	"reflect"
 
+	"xorm.io/xorm/contexts"
 	"xorm.io/xorm/internal/utils"
 )
 
@@ -16,6 +17,7 @@ type IterFunc func(idx int, bean interface{}) error
 // Rows return sql.Rows compatible Rows obj, as a forward Iterator object for iterating record by record, bean's non-empty fields
 // are conditions.
 func (session *Session) Rows(bean interface{}) (*Rows, error) {
+	session.setOperation(contexts.OperationFind, bean)
 	return newRows(session, bean)
 }
 
@@ -23,6 +25,7 @@ func (session *Session) Rows(bean interface{}) (*Rows, error) {
 // are conditions. beans could be []Struct, []*Struct, map[int64]Struct
 // map[int64]*Struct
 func (session *Session) Iterate(bean interface{}, fun IterFunc) error {
+	session.setOperation(contexts.OperationFind, bean)
 	if session.isAutoClose {
 		defer session.Close()
 	}
@@ -0,0 +1,236 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"xorm.io/xorm/contexts"
+)
+
+// ErrInvalidCursor is returned when the cursor is malformed or not signed by
+// the key of the engine
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PageAfter retrieves the records after the one of the values of the order
+// by columns, the columns are ordered ascending if there is no order by.
+// It builds the condition like (a, b) > (?, ?) or
+// (a > ? OR (a = ? AND b > ?)) instead of the offset which scans the skipped
+// records.
+func (session *Session) PageAfter(orderCols []string, lastValues []interface{}) *Session {
+	session.statement.PageAfter(orderCols, lastValues)
+	return session
+}
+
+// Seek retrieves the records after the cursor returned by FindPage, it
+// should be called after OrderBy with the same order as the previous page.
+// The empty cursor means the first page.
+func (session *Session) Seek(cursor string) *Session {
+	if cursor == "" {
+		return session
+	}
+	cols, values, err := session.engine.decodeCursor(cursor)
+	if err != nil {
+		session.statement.LastError = err
+		return session
+	}
+	session.statement.PageAfter(cols, values)
+	return session
+}
+
+// FindPage retrieves the records like Find, which should be ordered by the
+// columns of the struct, and returns the cursor of the next page which is
+// empty if there are less records than the limit
+func (session *Session) FindPage(rowsSlicePtr interface{}, condiBean ...interface{}) (string, error) {
+	session.setOperation(contexts.OperationFind, rowsSlicePtr)
+	if session.isAutoClose {
+		defer session.Close()
+	}
+
+	cols, _, err := session.statement.KeysetColumns()
+	if err != nil {
+		return "", err
+	}
+	if len(cols) == 0 {
+		return "", errors.New("FindPage needs the order by columns")
+	}
+	limit := 0
+	if session.statement.LimitN != nil {
+		limit = *session.statement.LimitN
+	}
+	preloads := session.statement.Preloads()
+
+	if err := session.find(rowsSlicePtr, condiBean...); err != nil {
+		return "", err
+	}
+	if err := session.preload(rowsSlicePtr, preloads); err != nil {
+		return "", err
+	}
+
+	sliceValue := reflect.Indirect(reflect.ValueOf(rowsSlicePtr))
+	if sliceValue.Kind() != reflect.Slice {
+		return "", errors.New("FindPage needs a pointer to a slice")
+	}
+	if sliceValue.Len() == 0 || (limit > 0 && sliceValue.Len() < limit) {
+		return "", nil
+	}
+
+	last := reflect.Indirect(sliceValue.Index(sliceValue.Len() - 1))
+	if last.Kind() != reflect.Struct {
+		return "", errors.New("FindPage needs a slice of the structs")
+	}
+	table, err := session.engine.tagParser.ParseWithCache(last)
+	if err != nil {
+		return "", err
+	}
+	values := make([]interface{}, 0, len(cols))
+	for _, colName := range cols {
+		col := table.GetColumn(colName[strings.LastIndex(colName, ".")+1:])
+		if col == nil {
+			return "", fmt.Errorf("order by column %s is not a column of %s", colName, table.Name)
+		}
+		fieldValue, err := col.ValueOfV(&last)
+		if err != nil {
+			return "", err
+		}
+		value, err := session.statement.Value2Interface(col, *fieldValue)
+		if err != nil {
+			return "", err
+		}
+		values = append(values, value)
+	}
+	return session.engine.encodeCursor(cols, values)
+}
+
+func newCursorKey() []byte {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	return key
+}
+
+// cursorValue keeps the type of a value of the cursor through JSON
+type cursorValue struct {
+	Type  string `json:"t"`
+	Value string `json:"v,omitempty"`
+}
+
+type cursorPayload struct {
+	Columns []string      `json:"c"`
+	Values  []cursorValue `json:"v"`
+}
+
+func toCursorValue(v interface{}) (cursorValue, error) {
+	switch t := v.(type) {
+	case nil:
+		return cursorValue{Type: "n"}, nil
+	case bool:
+		return cursorValue{Type: "b", Value: strconv.FormatBool(t)}, nil
+	case string:
+		return cursorValue{Type: "s", Value: t}, nil
+	case []byte:
+		return cursorValue{Type: "x", Value: base64.StdEncoding.EncodeToString(t)}, nil
+	case time.Time:
+		return cursorValue{Type: "t", Value: t.Format(time.RFC3339Nano)}, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cursorValue{Type: "i", Value: strconv.FormatInt(rv.Int(), 10)}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cursorValue{Type: "u", Value: strconv.FormatUint(rv.Uint(), 10)}, nil
+	case reflect.Float32, reflect.Float64:
+		return cursorValue{Type: "f", Value: strconv.FormatFloat(rv.Float(), 'g', -1, 64)}, nil
+	case reflect.String:
+		return cursorValue{Type: "s", Value: rv.String()}, nil
+	}
+	return cursorValue{}, fmt.Errorf("unsupported type %T of the cursor value", v)
+}
+
+func (cv cursorValue) value() (interface{}, error) {
+	switch cv.Type {
+	case "n":
+		return nil, nil
+	case "b":
+		return strconv.ParseBool(cv.Value)
+	case "s":
+		return cv.Value, nil
+	case "x":
+		return base64.StdEncoding.DecodeString(cv.Value)
+	case "t":
+		return time.Parse(time.RFC3339Nano, cv.Value)
+	case "i":
+		return strconv.ParseInt(cv.Value, 10, 64)
+	case "u":
+		return strconv.ParseUint(cv.Value, 10, 64)
+	case "f":
+		return strconv.ParseFloat(cv.Value, 64)
+	}
+	return nil, ErrInvalidCursor
+}
+
+func (engine *Engine) signCursor(payload []byte) []byte {
+	mac := hmac.New(sha256.New, engine.cursorKey)
+	_, _ = mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// encodeCursor returns the token of the base64 encoded payload and its
+// signature separated by a dot
+func (engine *Engine) encodeCursor(cols []string, values []interface{}) (string, error) {
+	payload := cursorPayload{Columns: cols}
+	for _, v := range values {
+		cv, err := toCursorValue(v)
+		if err != nil {
+			return "", err
+		}
+		payload.Values = append(payload.Values, cv)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data) + "." +
+		base64.RawURLEncoding.EncodeToString(engine.signCursor(data)), nil
+}
+
+func (engine *Engine) decodeCursor(cursor string) ([]string, []interface{}, error) {
+	parts := strings.Split(cursor, ".")
+	if len(parts) != 2 {
+		return nil, nil, ErrInvalidCursor
+	}
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, nil, ErrInvalidCursor
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(sig, engine.signCursor(data)) {
+		return nil, nil, ErrInvalidCursor
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil || len(payload.Columns) != len(payload.Values) {
+		return nil, nil, ErrInvalidCursor
+	}
+	values := make([]interface{}, 0, len(payload.Values))
+	for _, cv := range payload.Values {
+		v, err := cv.value()
+		if err != nil {
+			return nil, nil, ErrInvalidCursor
+		}
+		values = append(values, v)
+	}
+	return payload.Columns, values, nil
+}
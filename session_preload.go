@@ -0,0 +1,395 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"xorm.io/builder"
+	"xorm.io/xorm/schemas"
+)
+
+// Preload loads the related structs of the fields tagged with has_many,
+// belongs_to or many2many for all the results of Find or Get, with one query
+// per relation of each level, i.e. Preload("Orders.Items") loads the orders
+// of all the users and then the items of all the orders.
+func (session *Session) Preload(paths ...string) *Session {
+	session.statement.Preload(paths...)
+	return session
+}
+
+// preload loads the relations of the paths into the beans, which is a pointer
+// to a struct, a slice or a map of the structs
+func (session *Session) preload(beans interface{}, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	parents, err := preloadStructs(reflect.ValueOf(beans))
+	if err != nil {
+		return err
+	}
+	return session.preloadPaths(parents, paths)
+}
+
+// preloadStructs returns the addressable structs of the beans
+func preloadStructs(v reflect.Value) ([]reflect.Value, error) {
+	v = reflect.Indirect(v)
+	switch v.Kind() {
+	case reflect.Struct:
+		return []reflect.Value{v}, nil
+	case reflect.Slice, reflect.Map:
+		structs := make([]reflect.Value, 0, v.Len())
+		var elems []reflect.Value
+		if v.Kind() == reflect.Slice {
+			for i := 0; i < v.Len(); i++ {
+				elems = append(elems, v.Index(i))
+			}
+		} else {
+			elems = make([]reflect.Value, 0, v.Len())
+			iter := v.MapRange()
+			for iter.Next() {
+				elems = append(elems, iter.Value())
+			}
+		}
+		for _, elem := range elems {
+			if elem.Kind() == reflect.Ptr {
+				if elem.IsNil() {
+					continue
+				}
+				elem = elem.Elem()
+			}
+			if elem.Kind() != reflect.Struct || !elem.CanAddr() {
+				return nil, errors.New("preload needs the structs or the pointers in a slice or the pointers in a map")
+			}
+			structs = append(structs, elem)
+		}
+		return structs, nil
+	}
+	return nil, errors.New("preload needs a pointer to a struct, a slice or a map")
+}
+
+// preloadPaths loads the relations of the first fields of the paths, and the
+// rest of the paths on the related structs
+func (session *Session) preloadPaths(parents []reflect.Value, paths []string) error {
+	if len(parents) == 0 {
+		return nil
+	}
+	table, err := session.engine.tagParser.ParseWithCache(parents[0])
+	if err != nil {
+		return err
+	}
+
+	subPaths := make(map[string][]string)
+	for _, path := range paths {
+		fields := strings.SplitN(path, ".", 2)
+		if _, ok := subPaths[fields[0]]; !ok {
+			subPaths[fields[0]] = nil
+		}
+		if len(fields) > 1 {
+			subPaths[fields[0]] = append(subPaths[fields[0]], fields[1])
+		}
+	}
+	fieldNames := make([]string, 0, len(subPaths))
+	for fieldName := range subPaths {
+		fieldNames = append(fieldNames, fieldName)
+	}
+	sort.Strings(fieldNames)
+
+	for _, fieldName := range fieldNames {
+		relation := table.GetRelation(fieldName)
+		if relation == nil {
+			return fmt.Errorf("%s has no relation of field %s", table.Type.Name(), fieldName)
+		}
+
+		var err error
+		switch relation.Type {
+		case schemas.HasMany:
+			err = session.preloadHasMany(table, relation, parents, subPaths[fieldName])
+		case schemas.BelongsTo:
+			err = session.preloadBelongsTo(table, relation, parents, subPaths[fieldName])
+		case schemas.ManyToMany:
+			err = session.preloadManyToMany(table, relation, parents, subPaths[fieldName])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// preloadKey returns the key of the value to match the related structs, false
+// if the value is nil or zero
+func preloadKey(v reflect.Value) (string, bool) {
+	v = reflect.Indirect(v)
+	if !v.IsValid() || v.IsZero() {
+		return "", false
+	}
+	return fmt.Sprint(v.Interface()), true
+}
+
+// preloadPKColumn returns the only primary key column of the table
+func preloadPKColumn(table *schemas.Table) (*schemas.Column, error) {
+	pkCols := table.PKColumns()
+	if len(pkCols) != 1 {
+		return nil, fmt.Errorf("preload needs the table %s has one primary key", table.Name)
+	}
+	return pkCols[0], nil
+}
+
+// preloadColumnKeys returns the keys of the column of the structs and the
+// structs by the keys
+func preloadColumnKeys(col *schemas.Column, structs []reflect.Value) ([]interface{}, map[string][]reflect.Value, error) {
+	var (
+		args    []interface{}
+		byKeys  = make(map[string][]reflect.Value)
+		fieldsV []reflect.Value
+	)
+	for i := range structs {
+		fieldValue, err := col.ValueOfV(&structs[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		fieldsV = append(fieldsV, *fieldValue)
+	}
+	for i, fieldValue := range fieldsV {
+		key, ok := preloadKey(fieldValue)
+		if !ok {
+			continue
+		}
+		if _, ok := byKeys[key]; !ok {
+			args = append(args, reflect.Indirect(fieldValue).Interface())
+		}
+		byKeys[key] = append(byKeys[key], structs[i])
+	}
+	return args, byKeys, nil
+}
+
+// loadRelated finds the structs of the related table whose column is in the
+// args, and loads the sub paths on them
+func (session *Session) loadRelated(relation *schemas.Relation, colName string, args []interface{}, subPaths []string) (*schemas.Table, []reflect.Value, error) {
+	refTable, err := session.engine.tagParser.ParseWithCache(reflect.New(relation.RefType).Elem())
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(args) == 0 {
+		return refTable, nil, nil
+	}
+
+	var structs []reflect.Value
+	if err := session.preloadBatches(args, func(batch []interface{}) error {
+		related := reflect.New(reflect.SliceOf(reflect.PtrTo(relation.RefType)))
+		if err := session.In(colName, batch...).find(related.Interface()); err != nil {
+			return err
+		}
+		for i := 0; i < related.Elem().Len(); i++ {
+			structs = append(structs, related.Elem().Index(i).Elem())
+		}
+		return nil
+	}); err != nil {
+		return nil, nil, err
+	}
+	if err := session.preloadPaths(structs, subPaths); err != nil {
+		return nil, nil, err
+	}
+	return refTable, structs, nil
+}
+
+// preloadBatches runs the query of the keys in batches, each of which is
+// within the limits of the bind parameters and the IN list of the dialect
+func (session *Session) preloadBatches(args []interface{}, query func(batch []interface{}) error) error {
+	features := session.engine.dialect.Features()
+	size := inListBatchSize(features.MaxInListSize, batchSize(features.MaxBindParams, 0, 1, len(args)))
+	for start := 0; start < len(args); start += size {
+		end := start + size
+		if end > len(args) {
+			end = len(args)
+		}
+		if err := query(args[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendRelated appends the related struct to the slice field
+func appendRelated(field, related reflect.Value) {
+	if field.Type().Elem().Kind() == reflect.Ptr {
+		field.Set(reflect.Append(field, related.Addr()))
+	} else {
+		field.Set(reflect.Append(field, related))
+	}
+}
+
+func (session *Session) preloadHasMany(table *schemas.Table, relation *schemas.Relation, parents []reflect.Value, subPaths []string) error {
+	pkCol, err := preloadPKColumn(table)
+	if err != nil {
+		return err
+	}
+	args, parentsByKeys, err := preloadColumnKeys(pkCol, parents)
+	if err != nil {
+		return err
+	}
+	for _, parent := range parents {
+		field := parent.FieldByIndex(relation.FieldIndex)
+		field.Set(reflect.MakeSlice(field.Type(), 0, 0))
+	}
+
+	refTable, children, err := session.loadRelated(relation, relation.ForeignKey, args, subPaths)
+	if err != nil {
+		return err
+	}
+	fkCol := refTable.GetColumn(relation.ForeignKey)
+	if fkCol == nil {
+		return fmt.Errorf("table %s has no column %s of has_many", refTable.Name, relation.ForeignKey)
+	}
+	for i := range children {
+		fieldValue, err := fkCol.ValueOfV(&children[i])
+		if err != nil {
+			return err
+		}
+		key, ok := preloadKey(*fieldValue)
+		if !ok {
+			continue
+		}
+		for _, parent := range parentsByKeys[key] {
+			appendRelated(parent.FieldByIndex(relation.FieldIndex), children[i])
+		}
+	}
+	return nil
+}
+
+func (session *Session) preloadBelongsTo(table *schemas.Table, relation *schemas.Relation, parents []reflect.Value, subPaths []string) error {
+	fkCol := table.GetColumn(relation.ForeignKey)
+	if fkCol == nil {
+		return fmt.Errorf("table %s has no column %s of belongs_to", table.Name, relation.ForeignKey)
+	}
+	args, parentsByKeys, err := preloadColumnKeys(fkCol, parents)
+	if err != nil {
+		return err
+	}
+
+	refTable, err := session.engine.tagParser.ParseWithCache(reflect.New(relation.RefType).Elem())
+	if err != nil {
+		return err
+	}
+	pkCol, err := preloadPKColumn(refTable)
+	if err != nil {
+		return err
+	}
+	_, related, err := session.loadRelated(relation, pkCol.Name, args, subPaths)
+	if err != nil {
+		return err
+	}
+	for i := range related {
+		fieldValue, err := pkCol.ValueOfV(&related[i])
+		if err != nil {
+			return err
+		}
+		key, _ := preloadKey(*fieldValue)
+		for _, parent := range parentsByKeys[key] {
+			field := parent.FieldByIndex(relation.FieldIndex)
+			if field.Kind() == reflect.Ptr {
+				field.Set(related[i].Addr())
+			} else {
+				field.Set(related[i])
+			}
+		}
+	}
+	return nil
+}
+
+func (session *Session) preloadManyToMany(table *schemas.Table, relation *schemas.Relation, parents []reflect.Value, subPaths []string) error {
+	pkCol, err := preloadPKColumn(table)
+	if err != nil {
+		return err
+	}
+	args, parentsByKeys, err := preloadColumnKeys(pkCol, parents)
+	if err != nil {
+		return err
+	}
+	for _, parent := range parents {
+		field := parent.FieldByIndex(relation.FieldIndex)
+		field.Set(reflect.MakeSlice(field.Type(), 0, 0))
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	// query the pairs of the keys from the join table
+	var (
+		quote   = session.engine.dialect.Quoter().Quote
+		pairs   [][2]string
+		refArgs []interface{}
+		refKeys = make(map[string]bool)
+	)
+	if err := session.preloadBatches(args, func(batch []interface{}) error {
+		sqlStr, sqlArgs, err := builder.Select(quote(relation.JoinForeignKey), quote(relation.JoinRefKey)).
+			From(quote(relation.JoinTable)).
+			Where(builder.In(quote(relation.JoinForeignKey), batch...)).ToSQL()
+		if err != nil {
+			return err
+		}
+		rows, err := session.queryRows(sqlStr, sqlArgs...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var fk, ref sql.NullString
+			if err := rows.Scan(&fk, &ref); err != nil {
+				return err
+			}
+			if !fk.Valid || !ref.Valid {
+				continue
+			}
+			pairs = append(pairs, [2]string{fk.String, ref.String})
+			if !refKeys[ref.String] {
+				refKeys[ref.String] = true
+				refArgs = append(refArgs, ref.String)
+			}
+		}
+		return rows.Err()
+	}); err != nil {
+		return err
+	}
+
+	refTable, err := session.engine.tagParser.ParseWithCache(reflect.New(relation.RefType).Elem())
+	if err != nil {
+		return err
+	}
+	refPKCol, err := preloadPKColumn(refTable)
+	if err != nil {
+		return err
+	}
+	_, related, err := session.loadRelated(relation, refPKCol.Name, refArgs, subPaths)
+	if err != nil {
+		return err
+	}
+	relatedByKeys := make(map[string]reflect.Value, len(related))
+	for i := range related {
+		fieldValue, err := refPKCol.ValueOfV(&related[i])
+		if err != nil {
+			return err
+		}
+		key, _ := preloadKey(*fieldValue)
+		relatedByKeys[key] = related[i]
+	}
+
+	for _, pair := range pairs {
+		child, ok := relatedByKeys[pair[1]]
+		if !ok {
+			continue
+		}
+		for _, parent := range parentsByKeys[pair[0]] {
+			appendRelated(parent.FieldByIndex(relation.FieldIndex), child)
+		}
+	}
+	return nil
+}
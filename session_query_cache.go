@@ -0,0 +1,141 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"xorm.io/xorm/caches"
+	"xorm.io/xorm/internal/utils"
+)
+
+// CacheFor caches the whole result of the next Find, Get, Count, Sum or Query
+// of the session for the ttl, ttl <= 0 means until it's invalidated. The
+// result is cached by the key, whose parts are joined by ":", or by the SQL,
+// its arguments and the types of the result if there is no key, the count of
+// FindAndCount is cached by the key with the suffix ":count". It's
+// invalidated when a table referenced by the SQL is written, and it's neither
+// read nor cached in a transaction. The rows changed by the database itself
+// don't invalidate it, e.g. the child rows deleted or updated by a foreign key
+// of ON DELETE CASCADE or ON UPDATE CASCADE, or by a trigger, so the results
+// of such tables should be cached with a short ttl or invalidated explicitly
+// by QueryCache().Invalidate with the names of the tables.
+func (session *Session) CacheFor(ttl time.Duration, key ...string) *Session {
+	session.statement.SetCacheFor(ttl, strings.Join(key, ":"))
+	return session
+}
+
+// queryCacheEntry is a result kept by the query cache, the values are the
+// copies of the values pointed by the destinations of the query
+type queryCacheEntry struct {
+	has    bool
+	values []interface{}
+}
+
+// cachedQuery represents a query whose result is cached
+type cachedQuery struct {
+	key        string
+	sql        string
+	ttl        time.Duration
+	generation uint64
+}
+
+// newCachedQuery returns the cached query of the SQL, it's nil if the result
+// shouldn't be cached. It has to be called before running the SQL.
+func (session *Session) newCachedQuery(kind, sqlStr string, args []interface{}, dests ...interface{}) *cachedQuery {
+	cacheFor := session.statement.CacheFor
+	if cacheFor == nil || !session.isAutoCommit || session.engine.queryCache == nil ||
+		utils.IsWriteSQL(sqlStr) {
+		return nil
+	}
+	for _, dest := range dests {
+		if v := reflect.ValueOf(dest); v.Kind() != reflect.Ptr || v.IsNil() {
+			return nil
+		}
+	}
+
+	key := cacheFor.Key
+	if key == "" {
+		types := make([]string, len(dests))
+		for i, dest := range dests {
+			types[i] = reflect.TypeOf(dest).String()
+		}
+		key = fmt.Sprintf("%s-%s-%v", kind, caches.GenSqlKey(sqlStr, args), types)
+	}
+	return &cachedQuery{
+		key:        key,
+		sql:        sqlStr,
+		ttl:        cacheFor.TTL,
+		generation: session.engine.queryCache.Generation(),
+	}
+}
+
+// getCachedQuery copies the cached result into the destinations, hit is false
+// if there is no result of the same types
+func (session *Session) getCachedQuery(query *cachedQuery, dests ...interface{}) (has bool, hit bool) {
+	if query == nil {
+		return false, false
+	}
+	v, ok := session.engine.queryCache.Get(query.key)
+	if !ok {
+		return false, false
+	}
+	entry, ok := v.(*queryCacheEntry)
+	if !ok || len(entry.values) != len(dests) {
+		return false, false
+	}
+	for i, dest := range dests {
+		if reflect.TypeOf(entry.values[i]) != reflect.TypeOf(dest).Elem() {
+			return false, false
+		}
+	}
+	if entry.has {
+		for i, dest := range dests {
+			reflect.ValueOf(dest).Elem().Set(utils.DeepCopy(reflect.ValueOf(entry.values[i])))
+		}
+	}
+
+	session.engine.logger.Debugf("hit query cache: %s", query.sql)
+	session.resetStatement()
+	session.lastSQL = ""
+	session.lastSQLArgs = nil
+	return entry.has, true
+}
+
+// putCachedQuery caches the copies of the values pointed by the destinations
+func (session *Session) putCachedQuery(query *cachedQuery, has bool, dests ...interface{}) {
+	if query == nil {
+		return
+	}
+	values := make([]interface{}, len(dests))
+	for i, dest := range dests {
+		values[i] = utils.DeepCopy(reflect.ValueOf(dest).Elem()).Interface()
+	}
+	session.engine.queryCache.Put(query.key, &queryCacheEntry{
+		has:    has,
+		values: values,
+	}, query.ttl, utils.ReferencedTables(query.sql), query.generation)
+}
+
+// invalidateQueryCache invalidates the cached results of the tables written
+// by the SQL
+func (session *Session) invalidateQueryCache(sqlStr string) {
+	session.invalidateQueryCacheTables(utils.ReferencedTables(sqlStr)...)
+}
+
+// invalidateQueryCacheTables invalidates the cached results of the tables,
+// they are invalidated again when the transaction is committed
+func (session *Session) invalidateQueryCacheTables(tables ...string) {
+	if len(tables) == 0 || session.engine.queryCache == nil {
+		return
+	}
+	session.engine.queryCache.Invalidate(tables...)
+	if !session.isAutoCommit {
+		session.txWrittenTables = append(session.txWrittenTables, tables...)
+	}
+}
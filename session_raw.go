@@ -8,7 +8,9 @@ import (
 	"database/sql"
 	"strings"
 
+	"xorm.io/xorm/contexts"
 	"xorm.io/xorm/core"
+	"xorm.io/xorm/internal/utils"
 )
 
 func (session *Session) queryPreprocess(sqlStr *string, paramStr ...interface{}) {
@@ -31,6 +33,11 @@ func (session *Session) queryRows(sqlStr string, args ...interface{}) (*core.Row
 	session.lastSQL = sqlStr
 	session.lastSQLArgs = args
 
+	// the writes returning the rows
+	if utils.IsWriteSQL(sqlStr) {
+		defer session.invalidateQueryCache(sqlStr)
+	}
+
 	if session.isAutoCommit {
 		var db *core.DB
 		if session.sessionType == groupSession && strings.EqualFold(strings.TrimSpace(sqlStr)[:6], "select") && !session.statement.IsForUpdate {
@@ -46,10 +53,10 @@ func (session *Session) queryRows(sqlStr string, args ...interface{}) (*core.Row
 				return nil, err
 			}
 
-			return stmt.QueryContext(session.ctx, args...)
+			return stmt.QueryContext(session.hookContext(), args...)
 		}
 
-		return db.QueryContext(session.ctx, sqlStr, args...)
+		return db.QueryContext(session.hookContext(), sqlStr, args...)
 	}
 
 	if session.prepareStmt {
@@ -58,10 +65,10 @@ func (session *Session) queryRows(sqlStr string, args ...interface{}) (*core.Row
 			return nil, err
 		}
 
-		return stmt.QueryContext(session.ctx, args...)
+		return stmt.QueryContext(session.hookContext(), args...)
 	}
 
-	return session.tx.QueryContext(session.ctx, sqlStr, args...)
+	return session.tx.QueryContext(session.hookContext(), sqlStr, args...)
 }
 
 func (session *Session) queryRow(sqlStr string, args ...interface{}) *core.Row {
@@ -70,6 +77,7 @@ func (session *Session) queryRow(sqlStr string, args ...interface{}) *core.Row {
 
 // Query runs a raw sql and return records as []map[string][]byte
 func (session *Session) Query(sqlOrArgs ...interface{}) ([]map[string][]byte, error) {
+	session.setOperation(contexts.OperationQuery)
 	if session.isAutoClose {
 		defer session.Close()
 	}
@@ -79,17 +87,28 @@ func (session *Session) Query(sqlOrArgs ...interface{}) ([]map[string][]byte, er
 		return nil, err
 	}
 
+	var result []map[string][]byte
+	query := session.newCachedQuery("Query", sqlStr, args, &result)
+	if _, hit := session.getCachedQuery(query, &result); hit {
+		return result, nil
+	}
+
 	rows, err := session.queryRows(sqlStr, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	return session.engine.scanByteMaps(rows)
+	if result, err = session.engine.scanByteMaps(rows); err != nil {
+		return nil, err
+	}
+	session.putCachedQuery(query, true, &result)
+	return result, nil
 }
 
 // QueryString runs a raw sql and return records as []map[string]string
 func (session *Session) QueryString(sqlOrArgs ...interface{}) ([]map[string]string, error) {
+	session.setOperation(contexts.OperationQuery)
 	if session.isAutoClose {
 		defer session.Close()
 	}
@@ -99,17 +118,28 @@ func (session *Session) QueryString(sqlOrArgs ...interface{}) ([]map[string]stri
 		return nil, err
 	}
 
+	var result []map[string]string
+	query := session.newCachedQuery("Query", sqlStr, args, &result)
+	if _, hit := session.getCachedQuery(query, &result); hit {
+		return result, nil
+	}
+
 	rows, err := session.queryRows(sqlStr, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	return session.engine.ScanStringMaps(rows)
+	if result, err = session.engine.ScanStringMaps(rows); err != nil {
+		return nil, err
+	}
+	session.putCachedQuery(query, true, &result)
+	return result, nil
 }
 
 // QuerySliceString runs a raw sql and return records as [][]string
 func (session *Session) QuerySliceString(sqlOrArgs ...interface{}) ([][]string, error) {
+	session.setOperation(contexts.OperationQuery)
 	if session.isAutoClose {
 		defer session.Close()
 	}
@@ -119,17 +149,28 @@ func (session *Session) QuerySliceString(sqlOrArgs ...interface{}) ([][]string,
 		return nil, err
 	}
 
+	var result [][]string
+	query := session.newCachedQuery("Query", sqlStr, args, &result)
+	if _, hit := session.getCachedQuery(query, &result); hit {
+		return result, nil
+	}
+
 	rows, err := session.queryRows(sqlStr, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	return session.engine.ScanStringSlices(rows)
+	if result, err = session.engine.ScanStringSlices(rows); err != nil {
+		return nil, err
+	}
+	session.putCachedQuery(query, true, &result)
+	return result, nil
 }
 
 // QueryInterface runs a raw sql and return records as []map[string]interface{}
 func (session *Session) QueryInterface(sqlOrArgs ...interface{}) ([]map[string]interface{}, error) {
+	session.setOperation(contexts.OperationQuery)
 	if session.isAutoClose {
 		defer session.Close()
 	}
@@ -139,13 +180,23 @@ func (session *Session) QueryInterface(sqlOrArgs ...interface{}) ([]map[string]i
 		return nil, err
 	}
 
+	var result []map[string]interface{}
+	query := session.newCachedQuery("Query", sqlStr, args, &result)
+	if _, hit := session.getCachedQuery(query, &result); hit {
+		return result, nil
+	}
+
 	rows, err := session.queryRows(sqlStr, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	return session.engine.ScanInterfaceMaps(rows)
+	if result, err = session.engine.ScanInterfaceMaps(rows); err != nil {
+		return nil, err
+	}
+	session.putCachedQuery(query, true, &result)
+	return result, nil
 }
 
 func (session *Session) exec(sqlStr string, args ...interface{}) (sql.Result, error) {
@@ -156,15 +207,17 @@ func (session *Session) exec(sqlStr string, args ...interface{}) (sql.Result, er
 	session.lastSQL = sqlStr
 	session.lastSQLArgs = args
 
+	defer session.invalidateQueryCache(sqlStr)
+
 	if !session.isAutoCommit {
 		if session.prepareStmt {
 			stmt, err := session.doPrepareTx(sqlStr)
 			if err != nil {
 				return nil, err
 			}
-			return stmt.ExecContext(session.ctx, args...)
+			return stmt.ExecContext(session.hookContext(), args...)
 		}
-		return session.tx.ExecContext(session.ctx, sqlStr, args...)
+		return session.tx.ExecContext(session.hookContext(), sqlStr, args...)
 	}
 
 	if session.prepareStmt {
@@ -172,14 +225,15 @@ func (session *Session) exec(sqlStr string, args ...interface{}) (sql.Result, er
 		if err != nil {
 			return nil, err
 		}
-		return stmt.ExecContext(session.ctx, args...)
+		return stmt.ExecContext(session.hookContext(), args...)
 	}
 
-	return session.DB().ExecContext(session.ctx, sqlStr, args...)
+	return session.DB().ExecContext(session.hookContext(), sqlStr, args...)
 }
 
 // Exec raw sql
 func (session *Session) Exec(sqlOrArgs ...interface{}) (sql.Result, error) {
+	session.setOperation(contexts.OperationExec)
 	if session.isAutoClose {
 		defer session.Close()
 	}
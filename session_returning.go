@@ -0,0 +1,93 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"reflect"
+
+	"xorm.io/xorm/core"
+	"xorm.io/xorm/internal/statements"
+	"xorm.io/xorm/internal/utils"
+	"xorm.io/xorm/schemas"
+)
+
+// ErrReturningNotSupported represents an error the database could not return
+// the changed rows
+var ErrReturningNotSupported = statements.ErrReturningNotSupported
+
+// Returning makes Insert, InsertMulti, Update, Delete and Upsert write the
+// columns of the changed rows back into the beans, so that the values
+// generated by the database, like the defaults, the results of the triggers
+// and the auto increment ids, are loaded without querying again. All the
+// columns are returned if no column given. It's supported by PostgreSQL,
+// SQLite 3.35.0+, MSSQL and MariaDB 10.5+ except on Update and soft Delete,
+// ErrReturningNotSupported is returned by the others. The rows of SQLite and
+// MSSQL are returned in an arbitrary order, so InsertMulti writes them back
+// only if a batch has one record.
+func (session *Session) Returning(cols ...string) *Session {
+	session.statement.Returning(cols...)
+	return session
+}
+
+// scanReturning scans the rows returned by RETURNING or OUTPUT into the beans
+// given by beanAt with the index of the row, and returns the number of the rows.
+// The row is skipped if beanAt returns nil.
+func (session *Session) scanReturning(rows *core.Rows, table *schemas.Table, beanAt func(int) interface{}) (int64, error) {
+	fields, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, err
+	}
+	columnsSchema := ParseColumnsSchema(fields, types, table)
+
+	var cnt int64
+	for rows.Next() {
+		scanResults := make([]interface{}, len(fields))
+		for i := range scanResults {
+			var cell interface{}
+			scanResults[i] = &cell
+		}
+		if err := session.engine.scan(rows, fields, types, scanResults...); err != nil {
+			return cnt, err
+		}
+
+		bean := beanAt(int(cnt))
+		cnt++
+		if bean == nil {
+			continue
+		}
+
+		dataStruct := utils.ReflectValue(bean)
+		for i, field := range columnsSchema.Fields {
+			col, fieldValue, err := getField(&dataStruct, table, field)
+			if _, ok := err.(ErrFieldIsNotExist); ok {
+				continue
+			} else if err != nil {
+				return cnt, err
+			}
+			if err := session.convertBeanField(col, fieldValue, scanResults[i], table); err != nil {
+				return cnt, err
+			}
+		}
+	}
+	return cnt, rows.Err()
+}
+
+// sliceElemBean returns the pointer to the struct of the element of a slice,
+// or nil if the struct could not be set
+func sliceElemBean(elem reflect.Value) interface{} {
+	if elem.Kind() == reflect.Interface {
+		elem = elem.Elem()
+	}
+	// a struct held by an interface could not be set
+	elem = reflect.Indirect(elem)
+	if !elem.CanAddr() {
+		return nil
+	}
+	return elem.Addr().Interface()
+}
@@ -5,7 +5,6 @@
 package xorm
 
 import (
-	"bufio"
 	"context"
 	"database/sql"
 	"fmt"
@@ -13,6 +12,7 @@ import (
 	"os"
 	"strings"
 
+	"xorm.io/xorm/contexts"
 	"xorm.io/xorm/dialects"
 	"xorm.io/xorm/internal/utils"
 )
@@ -29,6 +29,7 @@ func (session *Session) Ping() error {
 
 // CreateTable create a table according a bean
 func (session *Session) CreateTable(bean interface{}) error {
+	session.setOperation(contexts.OperationDDL, bean)
 	if session.isAutoClose {
 		defer session.Close()
 	}
@@ -68,6 +69,7 @@ func (session *Session) createTable(bean interface{}) error {
 
 // CreateIndexes create indexes
 func (session *Session) CreateIndexes(bean interface{}) error {
+	session.setOperation(contexts.OperationDDL, bean)
 	if session.isAutoClose {
 		defer session.Close()
 	}
@@ -92,6 +94,7 @@ func (session *Session) createIndexes(bean interface{}) error {
 
 // CreateUniques create uniques
 func (session *Session) CreateUniques(bean interface{}) error {
+	session.setOperation(contexts.OperationDDL, bean)
 	if session.isAutoClose {
 		defer session.Close()
 	}
@@ -115,6 +118,7 @@ func (session *Session) createUniques(bean interface{}) error {
 
 // DropIndexes drop indexes
 func (session *Session) DropIndexes(bean interface{}) error {
+	session.setOperation(contexts.OperationDDL, bean)
 	if session.isAutoClose {
 		defer session.Close()
 	}
@@ -139,6 +143,7 @@ func (session *Session) dropIndexes(bean interface{}) error {
 
 // DropTable drop table will drop table if exist, if drop failed, it will return error
 func (session *Session) DropTable(beanOrTableName interface{}) error {
+	session.setOperation(contexts.OperationDDL)
 	if session.isAutoClose {
 		defer session.Close()
 	}
@@ -187,6 +192,7 @@ func (session *Session) dropTable(beanOrTableName interface{}) error {
 
 // IsTableExist if a table is exist
 func (session *Session) IsTableExist(beanOrTableName interface{}) (bool, error) {
+	session.setOperation(contexts.OperationDDL)
 	if session.isAutoClose {
 		defer session.Close()
 	}
@@ -202,6 +208,7 @@ func (session *Session) isTableExist(tableName string) (bool, error) {
 
 // IsTableEmpty if table have any records
 func (session *Session) IsTableEmpty(bean interface{}) (bool, error) {
+	session.setOperation(contexts.OperationQuery, bean)
 	if session.isAutoClose {
 		defer session.Close()
 	}
@@ -222,27 +229,6 @@ func (session *Session) isTableEmpty(tableName string) (bool, error) {
 	return total == 0, nil
 }
 
-func (session *Session) addColumn(colName string) error {
-	col := session.statement.RefTable.GetColumn(colName)
-	sql := session.engine.dialect.AddColumnSQL(session.statement.TableName(), col)
-	_, err := session.exec(sql)
-	return err
-}
-
-func (session *Session) addIndex(tableName, idxName string) error {
-	index := session.statement.RefTable.Indexes[idxName]
-	sqlStr := session.engine.dialect.CreateIndexSQL(tableName, index)
-	_, err := session.exec(sqlStr)
-	return err
-}
-
-func (session *Session) addUnique(tableName, uqeName string) error {
-	index := session.statement.RefTable.Indexes[uqeName]
-	sqlStr := session.engine.dialect.CreateIndexSQL(tableName, index)
-	_, err := session.exec(sqlStr)
-	return err
-}
-
 // ImportFile SQL DDL file
 func (session *Session) ImportFile(ddlPath string) ([]sql.Result, error) {
 	file, err := os.Open(ddlPath)
@@ -255,49 +241,13 @@ func (session *Session) ImportFile(ddlPath string) ([]sql.Result, error) {
 
 // Import SQL DDL from io.Reader
 func (session *Session) Import(r io.Reader) ([]sql.Result, error) {
+	session.setOperation(contexts.OperationExec)
 	var (
-		results       []sql.Result
-		lastError     error
-		inSingleQuote bool
-		startComment  bool
+		results   []sql.Result
+		lastError error
 	)
 
-	scanner := bufio.NewScanner(r)
-	semiColSpliter := func(data []byte, atEOF bool) (advance int, token []byte, err error) {
-		if atEOF && len(data) == 0 {
-			return 0, nil, nil
-		}
-		oriInSingleQuote := inSingleQuote
-		for i, b := range data {
-			if startComment {
-				if b == '\n' {
-					startComment = false
-				}
-			} else {
-				if !inSingleQuote && i > 0 && data[i-1] == '-' && data[i] == '-' {
-					startComment = true
-					continue
-				}
-
-				if b == '\'' {
-					inSingleQuote = !inSingleQuote
-				}
-				if !inSingleQuote && b == ';' {
-					return i + 1, data[0:i], nil
-				}
-			}
-		}
-		// If we're at EOF, we have a final, non-terminated line. Return it.
-		if atEOF {
-			return len(data), data, nil
-		}
-		inSingleQuote = oriInSingleQuote
-		// Request more data.
-		return 0, nil, nil
-	}
-
-	scanner.Split(semiColSpliter)
-
+	scanner := utils.NewSQLScanner(r)
 	for scanner.Scan() {
 		query := strings.Trim(scanner.Text(), " \t\n\r")
 		if len(query) > 0 {
@@ -8,11 +8,14 @@ import (
 	"database/sql"
 	"errors"
 	"reflect"
+
+	"xorm.io/xorm/contexts"
 )
 
 // Count counts the records. bean's non-empty fields
 // are conditions.
 func (session *Session) Count(bean ...interface{}) (int64, error) {
+	session.setOperation(contexts.OperationQuery, bean...)
 	if session.isAutoClose {
 		defer session.Close()
 	}
@@ -23,8 +26,14 @@ func (session *Session) Count(bean ...interface{}) (int64, error) {
 	}
 
 	var total int64
+	query := session.newCachedQuery("Count", sqlStr, args, &total)
+	if _, hit := session.getCachedQuery(query, &total); hit {
+		return total, nil
+	}
+
 	err = session.queryRow(sqlStr, args...).Scan(&total)
 	if err == nil {
+		session.putCachedQuery(query, true, &total)
 		return total, nil
 	}
 
@@ -47,12 +56,18 @@ func (session *Session) sum(res interface{}, bean interface{}, columnNames ...st
 		return err
 	}
 
+	query := session.newCachedQuery("Sum", sqlStr, args, res)
+	if _, hit := session.getCachedQuery(query, res); hit {
+		return nil
+	}
+
 	if v.Elem().Kind() == reflect.Slice {
 		err = session.queryRow(sqlStr, args...).ScanSlice(res)
 	} else {
 		err = session.queryRow(sqlStr, args...).Scan(res)
 	}
 	if err == sql.ErrNoRows || err == nil {
+		session.putCachedQuery(query, true, res)
 		return nil
 	}
 	return err
@@ -60,22 +75,26 @@ func (session *Session) sum(res interface{}, bean interface{}, columnNames ...st
 
 // Sum call sum some column. bean's non-empty fields are conditions.
 func (session *Session) Sum(bean interface{}, columnName string) (res float64, err error) {
+	session.setOperation(contexts.OperationQuery, bean)
 	return res, session.sum(&res, bean, columnName)
 }
 
 // SumInt call sum some column. bean's non-empty fields are conditions.
 func (session *Session) SumInt(bean interface{}, columnName string) (res int64, err error) {
+	session.setOperation(contexts.OperationQuery, bean)
 	return res, session.sum(&res, bean, columnName)
 }
 
 // Sums call sum some columns. bean's non-empty fields are conditions.
 func (session *Session) Sums(bean interface{}, columnNames ...string) ([]float64, error) {
+	session.setOperation(contexts.OperationQuery, bean)
 	res := make([]float64, len(columnNames))
 	return res, session.sum(&res, bean, columnNames...)
 }
 
 // SumsInt sum specify columns and return as []int64 instead of []float64
 func (session *Session) SumsInt(bean interface{}, columnNames ...string) ([]int64, error) {
+	session.setOperation(contexts.OperationQuery, bean)
 	res := make([]int64, len(columnNames))
 	return res, session.sum(&res, bean, columnNames...)
 }
@@ -4,10 +4,27 @@
 
 package xorm
 
+import (
+	"database/sql"
+	"strconv"
+
+	"xorm.io/xorm/contexts"
+)
+
 // Begin a transaction
 func (session *Session) Begin() error {
+	return session.beginTx(nil)
+}
+
+func (session *Session) beginTx(opts *sql.TxOptions) error {
+	if !session.isAutoCommit && len(session.txLevels) > 0 {
+		// a Begin inside BeginNested opens a level too, so the matching
+		// Commit doesn't release the savepoint of the caller
+		session.txLevels = append(session.txLevels, "")
+		return nil
+	}
 	if session.isAutoCommit {
-		tx, err := session.DB().BeginTx(session.ctx, nil)
+		tx, err := session.DB().BeginTx(session.ctx, opts)
 		if err != nil {
 			return err
 		}
@@ -20,12 +37,76 @@ func (session *Session) Begin() error {
 	return nil
 }
 
+// BeginNested begins a transaction, or creates a savepoint if the session is
+// already in a transaction. Every BeginNested should be paired with a Commit
+// or a Rollback, which releases or rollbacks to its savepoint. A Begin called
+// inside it is paired with its own Commit, which does nothing, or Rollback,
+// which rollbacks the whole transaction.
+func (session *Session) BeginNested() error {
+	if session.isAutoCommit {
+		return session.Begin()
+	}
+
+	name := savepointName(len(session.txLevels) + 1)
+	sqlStr, err := session.engine.dialect.SavepointSQL(name)
+	if err != nil {
+		return err
+	}
+	if err := session.execSavepointSQL(sqlStr); err != nil {
+		return err
+	}
+	session.txLevels = append(session.txLevels, name)
+	return nil
+}
+
+func savepointName(depth int) string {
+	return "xorm_sp_" + strconv.Itoa(depth)
+}
+
+// execSavepointSQL executes the savepoint SQL like the other SQLs, but it
+// doesn't reset the statement being built and it's never prepared
+func (session *Session) execSavepointSQL(sqlStr string) error {
+	if sqlStr == "" {
+		return nil
+	}
+	autoResetStatement, prepareStmt := session.autoResetStatement, session.prepareStmt
+	session.autoResetStatement, session.prepareStmt = false, false
+	defer func() {
+		session.autoResetStatement, session.prepareStmt = autoResetStatement, prepareStmt
+	}()
+
+	session.setOperation(contexts.OperationExec)
+	_, err := session.exec(sqlStr)
+	return err
+}
+
+// popTxLevel removes the latest level opened in the transaction
+func (session *Session) popTxLevel() string {
+	n := len(session.txLevels)
+	name := session.txLevels[n-1]
+	session.txLevels = session.txLevels[:n-1]
+	return name
+}
+
 // Rollback When using transaction, you can rollback if any error
 func (session *Session) Rollback() error {
+	if len(session.txLevels) > 0 && !session.isCommitedOrRollbacked {
+		// a level opened by Begin rollbacks the whole transaction
+		if name := session.popTxLevel(); name != "" {
+			sqlStr, err := session.engine.dialect.RollbackToSavepointSQL(name)
+			if err != nil {
+				return err
+			}
+			return session.execSavepointSQL(sqlStr)
+		}
+	}
+
 	if !session.isAutoCommit && !session.isCommitedOrRollbacked {
 		session.saveLastSQL("ROLL BACK")
 		session.isCommitedOrRollbacked = true
 		session.isAutoCommit = true
+		session.txLevels = nil
+		session.txWrittenTables = nil
 
 		return session.tx.Rollback()
 	}
@@ -34,6 +115,19 @@ func (session *Session) Rollback() error {
 
 // Commit When using transaction, Commit will commit all operations.
 func (session *Session) Commit() error {
+	if len(session.txLevels) > 0 && !session.isCommitedOrRollbacked {
+		name := session.popTxLevel()
+		if name == "" {
+			// the transaction is committed by the one who began it
+			return nil
+		}
+		sqlStr, err := session.engine.dialect.ReleaseSavepointSQL(name)
+		if err != nil {
+			return err
+		}
+		return session.execSavepointSQL(sqlStr)
+	}
+
 	if !session.isAutoCommit && !session.isCommitedOrRollbacked {
 		session.saveLastSQL("COMMIT")
 		session.isCommitedOrRollbacked = true
@@ -42,6 +136,9 @@ func (session *Session) Commit() error {
 		if err := session.tx.Commit(); err != nil {
 			return err
 		}
+		tables := session.txWrittenTables
+		session.txWrittenTables = nil
+		session.invalidateQueryCacheTables(tables...)
 
 		// handle processors after tx committed
 		closureCallFunc := func(closuresPtr *[]func(interface{}), bean interface{}) {
@@ -89,3 +186,14 @@ func (session *Session) Commit() error {
 func (session *Session) IsInTx() bool {
 	return !session.isAutoCommit
 }
+
+// TxDepth returns how many savepoints created by BeginNested are still open
+func (session *Session) TxDepth() int {
+	var depth int
+	for _, name := range session.txLevels {
+		if name != "" {
+			depth++
+		}
+	}
+	return depth
+}
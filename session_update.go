@@ -5,9 +5,11 @@
 package xorm
 
 import (
+	"database/sql"
 	"reflect"
 
 	"xorm.io/builder"
+	"xorm.io/xorm/contexts"
 	"xorm.io/xorm/internal/statements"
 	"xorm.io/xorm/internal/utils"
 	"xorm.io/xorm/schemas"
@@ -61,6 +63,7 @@ func (session *Session) genAutoCond(condiBean interface{}) (builder.Cond, error)
 //	 You should call UseBool if you have bool to use.
 //	2.float32 & float64 may be not inexact as conditions
 func (session *Session) Update(bean interface{}, condiBean ...interface{}) (int64, error) {
+	session.setOperation(contexts.OperationUpdate, bean)
 	if session.isAutoClose {
 		defer session.Close()
 	}
@@ -193,10 +196,18 @@ func (session *Session) Update(bean interface{}, condiBean ...interface{}) (int6
 	tableName := session.statement.TableName() // table name must been get before exec because statement will be reset
 	useCache := session.statement.UseCache
 
-	res, err := session.exec(updateWriter.String(), updateWriter.Args()...)
+	var affected int64
+	if session.statement.IsReturning() {
+		affected, err = session.updateReturning(updateWriter.String(), updateWriter.Args(), bean, isStruct)
+	} else {
+		var res sql.Result
+		if res, err = session.exec(updateWriter.String(), updateWriter.Args()...); err == nil {
+			affected, err = res.RowsAffected()
+		}
+	}
 	if err != nil {
 		return 0, err
-	} else if doIncVer {
+	} else if doIncVer && !session.statement.IsReturningColumn(table.Version) {
 		if verValue != nil && verValue.IsValid() && verValue.CanSet() {
 			session.incrVersionFieldValue(verValue)
 		}
@@ -237,7 +248,25 @@ func (session *Session) Update(bean interface{}, condiBean ...interface{}) (int6
 	cleanupProcessorsClosures(&session.afterClosures) // cleanup after used
 	// --
 
-	return res.RowsAffected()
+	return affected, nil
+}
+
+// updateReturning executes the update and scans the first returned row into
+// the bean if it's a pointer to a struct
+func (session *Session) updateReturning(sqlStr string, args []interface{}, bean interface{}, isStruct bool) (int64, error) {
+	table := session.statement.RefTable
+	rows, err := session.queryRows(sqlStr, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	return session.scanReturning(rows, table, func(i int) interface{} {
+		if i > 0 || !isStruct || reflect.ValueOf(bean).Kind() != reflect.Ptr {
+			return nil
+		}
+		return bean
+	})
 }
 
 func (session *Session) genUpdateColumns(bean interface{}) ([]string, []interface{}, error) {
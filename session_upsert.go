@@ -0,0 +1,179 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"errors"
+	"reflect"
+
+	"xorm.io/xorm/contexts"
+	"xorm.io/xorm/convert"
+)
+
+// ErrUpsertType represents an error when the bean of upsert is not a struct
+var ErrUpsertType = errors.New("upsert needs a struct, a pointer to struct or a slice of them")
+
+// OnConflict makes the insert update the existing row when it conflicts on
+// the unique key of the columns. The primary key and the unique indexes of the
+// table are used if no column given.
+func (session *Session) OnConflict(cols ...string) *Session {
+	session.statement.OnConflict(cols...)
+	return session
+}
+
+// DoUpdate sets the columns to update when the insert conflicts. All the
+// inserted columns except the conflict key and the created time are updated if
+// no column given. The updated time is always refreshed and the version is
+// increased.
+func (session *Session) DoUpdate(cols ...string) *Session {
+	session.statement.DoUpdate(cols...)
+	return session
+}
+
+// DoNothing keeps the existing row when the insert conflicts
+func (session *Session) DoNothing() *Session {
+	session.statement.DoNothing()
+	return session
+}
+
+// Upsert inserts the beans or updates the existing rows which conflict with
+// them. The returned value is the number of the inserted or updated rows.
+//
+// The auto increment and version fields of the bean are refreshed from the
+// database on PostgreSQL, SQLite and MSSQL, on MySQL only the auto increment
+// field is.
+func (session *Session) Upsert(beans ...interface{}) (int64, error) {
+	session.setOperation(contexts.OperationInsert, beans...)
+	session.statement.OnConflict()
+	return session.Insert(beans...)
+}
+
+// upsert is called by Insert once the conflict is handled
+func (session *Session) upsert(beans ...interface{}) (int64, error) {
+	var affected int64
+	for _, bean := range beans {
+		v := reflect.Indirect(reflect.ValueOf(bean))
+		switch v.Kind() {
+		case reflect.Struct:
+			cnt, err := session.upsertStruct(bean)
+			if err != nil {
+				return affected, err
+			}
+			affected += cnt
+		case reflect.Slice:
+			for i := 0; i < v.Len(); i++ {
+				elem := v.Index(i)
+				if elem.Kind() == reflect.Struct {
+					elem = elem.Addr()
+				}
+				cnt, err := session.upsertStruct(elem.Interface())
+				if err != nil {
+					return affected, err
+				}
+				affected += cnt
+			}
+		default:
+			return affected, ErrUpsertType
+		}
+	}
+	return affected, nil
+}
+
+func (session *Session) upsertStruct(bean interface{}) (int64, error) {
+	if err := session.statement.SetRefBean(bean); err != nil {
+		return 0, err
+	}
+	if len(session.statement.TableName()) == 0 {
+		return 0, ErrTableNotFound
+	}
+
+	// handle BeforeInsertProcessor
+	for _, closure := range session.beforeClosures {
+		closure(bean)
+	}
+	cleanupProcessorsClosures(&session.beforeClosures) // cleanup after used
+
+	if processor, ok := interface{}(bean).(BeforeInsertProcessor); ok {
+		processor.BeforeInsert()
+	}
+
+	tableName := session.statement.TableName()
+	table := session.statement.RefTable
+
+	colNames, args, err := session.genInsertColumns(bean)
+	if err != nil {
+		return 0, err
+	}
+
+	sqlStr, args, err := session.statement.GenUpsertSQL(colNames, args)
+	if err != nil {
+		return 0, err
+	}
+	sqlStr = session.engine.dialect.Quoter().Replace(sqlStr)
+
+	var affected int64
+	if returning := session.statement.UpsertReturning(); len(returning) > 0 {
+		rows, err := session.queryRows(sqlStr, args...)
+		if err != nil {
+			return 0, err
+		}
+		defer rows.Close()
+
+		// no row is returned if the conflicting row is kept
+		affected, err = session.scanReturning(rows, table, func(int) interface{} { return bean })
+		if err != nil {
+			return affected, err
+		}
+		rows.Close()
+	} else {
+		res, err := session.exec(sqlStr, args...)
+		if err != nil {
+			return 0, err
+		}
+		affected, err = res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		// MySQL reports 2 for an updated row
+		if affected > 1 {
+			affected = 1
+		}
+
+		if len(table.AutoIncrement) > 0 {
+			if id, err := res.LastInsertId(); err == nil && id > 0 {
+				aiValue, err := table.AutoIncrColumn().ValueOf(bean)
+				if err != nil {
+					session.engine.logger.Errorf("%v", err)
+				} else if aiValue != nil && aiValue.IsValid() && aiValue.CanSet() {
+					if err := convert.AssignValue(*aiValue, id); err != nil {
+						return 0, err
+					}
+				}
+			}
+		}
+	}
+
+	defer session.handleAfterInsertProcessor(bean)
+
+	_ = session.cacheUpsert(tableName)
+
+	return affected, nil
+}
+
+// cacheUpsert clears the cached ids and beans of the table since the upsert
+// may have updated the existing rows
+func (session *Session) cacheUpsert(table string) error {
+	if !session.statement.UseCache {
+		return nil
+	}
+	cacher := session.engine.cacherMgr.GetCacher(table)
+	if cacher == nil {
+		return nil
+	}
+	session.engine.logger.Debugf("[cache] clear SQL and beans: %v", table)
+	cacher.ClearIds(table)
+	cacher.ClearBeans(table)
+	return nil
+}
@@ -5,9 +5,14 @@
 package xorm
 
 import (
+	"fmt"
+	"sort"
 	"strings"
 
+	"xorm.io/xorm/contexts"
+	"xorm.io/xorm/dialects"
 	"xorm.io/xorm/internal/utils"
+	"xorm.io/xorm/log"
 	"xorm.io/xorm/schemas"
 )
 
@@ -17,12 +22,46 @@ type SyncOptions struct {
 	IgnoreConstrains bool
 	// IgnoreIndices will not add or delete indices
 	IgnoreIndices bool
+	// DropColumns will drop the columns which have no related struct fields
+	DropColumns bool
+	// AlterColumns will change the types, the nullability and the defaults of
+	// the columns if they are different from the struct fields, the defaults
+	// of MSSQL are constraints which are reported in Warnings instead
+	AlterColumns bool
+	// DryRun will not execute anything but plan the SQLs into SyncResult
+	DryRun bool
 }
 
-type SyncResult struct{}
+// SyncResult represents the changes of a synchronization
+type SyncResult struct {
+	// SQLs are the DDL statements executed, or to be executed if it's a dry run,
+	// in order
+	SQLs []string
+	// Warnings are the differences between the database and the structs which
+	// could not be synchronized
+	Warnings []string
+}
+
+// exec executes the DDL and records it in the result, the DDL is only recorded
+// if it's a dry run
+func (result *SyncResult) exec(session *Session, opts SyncOptions, sql string) error {
+	result.SQLs = append(result.SQLs, sql)
+	if opts.DryRun {
+		return nil
+	}
+	_, err := session.exec(sql)
+	return err
+}
+
+// warnf logs the warning and records it in the result
+func (result *SyncResult) warnf(logger log.ContextLogger, format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	result.Warnings = append(result.Warnings, msg)
+	logger.Warnf("%s", msg)
+}
 
 // Sync the new struct changes to database, this method will automatically add
-// table, column, index, unique. but will not delete or change anything.
+// table, column, index, unique, foreign key. but will not delete or change anything.
 // If you change some field, you should change the database manually.
 func (engine *Engine) Sync(beans ...interface{}) error {
 	session := engine.NewSession()
@@ -59,7 +98,12 @@ func (session *Session) Sync(beans ...interface{}) error {
 	return err
 }
 
+// SyncWithOptions synchronize structs to database tables according to the
+// options. The obsolete columns are dropped and the changed columns are altered
+// only if it's asked by the options, and nothing is executed on a dry run but
+// the SQLs and the warnings are planned into the result.
 func (session *Session) SyncWithOptions(opts SyncOptions, beans ...interface{}) (*SyncResult, error) {
+	session.setOperation(contexts.OperationDDL, beans...)
 	engine := session.engine
 
 	if session.isAutoClose {
@@ -78,14 +122,22 @@ func (session *Session) SyncWithOptions(opts SyncOptions, beans ...interface{})
 		session.resetStatement()
 	}()
 
-	var syncResult SyncResult
-
+	var (
+		syncResult  SyncResult
+		beanTables  = make([]*schemas.Table, 0, len(beans))
+		foreignKeys []pendingForeignKey
+	)
 	for _, bean := range beans {
-		v := utils.ReflectValue(bean)
-		table, err := engine.tagParser.ParseWithCache(v)
+		table, err := engine.tagParser.ParseWithCache(utils.ReflectValue(bean))
 		if err != nil {
 			return nil, err
 		}
+		beanTables = append(beanTables, table)
+	}
+
+	// the referenced tables are synchronized first
+	for _, i := range schemas.SortTablesByForeignKeys(beanTables) {
+		bean, table := beans[i], beanTables[i]
 		var tbName string
 		if len(session.statement.AltTableName) > 0 {
 			tbName = session.statement.AltTableName
@@ -104,22 +156,19 @@ func (session *Session) SyncWithOptions(opts SyncOptions, beans ...interface{})
 
 		// this is a new table
 		if oriTable == nil {
-			err = session.StoreEngine(session.statement.StoreEngine).createTable(bean)
+			sqls, err := session.createTableSQLs(bean, opts)
 			if err != nil {
 				return nil, err
 			}
-
-			if !opts.IgnoreConstrains {
-				err = session.createUniques(bean)
-				if err != nil {
+			for _, sql := range sqls {
+				if err = syncResult.exec(session, opts, sql); err != nil {
 					return nil, err
 				}
 			}
 
-			if !opts.IgnoreIndices {
-				err = session.createIndexes(bean)
-				if err != nil {
-					return nil, err
+			if !opts.IgnoreConstrains && engine.dialect.URI().DBType != schemas.YDB {
+				for _, fk := range sortedForeignKeys(table) {
+					foreignKeys = append(foreignKeys, pendingForeignKey{tbNameWithSchema, fk, true})
 				}
 			}
 
@@ -143,79 +192,73 @@ func (session *Session) SyncWithOptions(opts SyncOptions, beans ...interface{})
 
 			// column is not exist on table
 			if oriCol == nil {
-				session.statement.RefTable = table
-				session.statement.SetTableName(tbNameWithSchema)
-				if err = session.addColumn(col.Name); err != nil {
+				if err = syncResult.exec(session, opts, engine.dialect.AddColumnSQL(tbNameWithSchema, col)); err != nil {
 					return nil, err
 				}
 				continue
 			}
 
-			err = nil
-			expectedType := engine.dialect.SQLType(col)
-			curType := engine.dialect.SQLType(oriCol)
-			if expectedType != curType {
-				if expectedType == schemas.Text &&
-					strings.HasPrefix(curType, schemas.Varchar) {
-					// currently only support mysql & postgres
-					if engine.dialect.URI().DBType == schemas.MYSQL ||
-						engine.dialect.URI().DBType == schemas.POSTGRES {
-						engine.logger.Infof("Table %s column %s change type from %s to %s\n",
-							tbNameWithSchema, col.Name, curType, expectedType)
-						_, err = session.exec(engine.dialect.ModifyColumnSQL(tbNameWithSchema, col))
-					} else {
-						engine.logger.Warnf("Table %s column %s db type is %s, struct type is %s\n",
-							tbNameWithSchema, col.Name, curType, expectedType)
-					}
-				} else if strings.HasPrefix(curType, schemas.Varchar) && strings.HasPrefix(expectedType, schemas.Varchar) {
-					if engine.dialect.URI().DBType == schemas.MYSQL {
-						if oriCol.Length < col.Length {
-							engine.logger.Infof("Table %s column %s change type from varchar(%d) to varchar(%d)\n",
-								tbNameWithSchema, col.Name, oriCol.Length, col.Length)
-							_, err = session.exec(engine.dialect.ModifyColumnSQL(tbNameWithSchema, col))
-						}
-					}
-				} else {
-					if !(strings.HasPrefix(curType, expectedType) && curType[len(expectedType)] == '(') {
-						if !strings.EqualFold(schemas.SQLTypeName(curType), engine.dialect.Alias(schemas.SQLTypeName(expectedType))) {
-							engine.logger.Warnf("Table %s column %s db type is %s, struct type is %s",
-								tbNameWithSchema, col.Name, curType, expectedType)
-						}
-					}
-				}
-			} else if expectedType == schemas.Varchar {
-				if engine.dialect.URI().DBType == schemas.MYSQL {
-					if oriCol.Length < col.Length {
-						engine.logger.Infof("Table %s column %s change type from varchar(%d) to varchar(%d)\n",
-							tbNameWithSchema, col.Name, oriCol.Length, col.Length)
-						_, err = session.exec(engine.dialect.ModifyColumnSQL(tbNameWithSchema, col))
-					}
+			var (
+				changes      = dialects.ColumnChanges(engine.dialect, col, oriCol)
+				expectedType = engine.dialect.SQLType(col)
+				curType      = engine.dialect.SQLType(oriCol)
+				dbType       = engine.dialect.URI().DBType
+			)
+			if changes&dialects.ColumnTypeChanged != 0 {
+				switch {
+				// currently only support mysql & postgres
+				case expectedType == schemas.Text && strings.HasPrefix(curType, schemas.Varchar) &&
+					(dbType == schemas.MYSQL || dbType == schemas.POSTGRES):
+					engine.logger.Infof("Table %s column %s change type from %s to %s\n",
+						tbNameWithSchema, col.Name, curType, expectedType)
+					err = syncResult.exec(session, opts, engine.dialect.ModifyColumnSQL(tbNameWithSchema, col))
+					changes &^= dialects.ColumnTypeChanged
+				case dbType == schemas.MYSQL && strings.HasPrefix(curType, schemas.Varchar) &&
+					strings.HasPrefix(expectedType, schemas.Varchar) && oriCol.Length < col.Length:
+					engine.logger.Infof("Table %s column %s change type from varchar(%d) to varchar(%d)\n",
+						tbNameWithSchema, col.Name, oriCol.Length, col.Length)
+					err = syncResult.exec(session, opts, engine.dialect.ModifyColumnSQL(tbNameWithSchema, col))
+					changes &^= dialects.ColumnTypeChanged
+				case !opts.AlterColumns:
+					syncResult.warnf(engine.logger, "Table %s column %s db type is %s, struct type is %s",
+						tbNameWithSchema, col.Name, curType, expectedType)
+					changes &^= dialects.ColumnTypeChanged
 				}
 			} else if col.Comment != oriCol.Comment {
-				if engine.dialect.URI().DBType == schemas.POSTGRES ||
-					engine.dialect.URI().DBType == schemas.MYSQL {
-					_, err = session.exec(engine.dialect.ModifyColumnSQL(tbNameWithSchema, col))
+				if dbType == schemas.POSTGRES || dbType == schemas.MYSQL {
+					err = syncResult.exec(session, opts, engine.dialect.ModifyColumnSQL(tbNameWithSchema, col))
 				}
 			}
+			if err != nil {
+				return nil, err
+			}
 
-			if col.Default != oriCol.Default {
-				switch {
-				case col.IsAutoIncrement: // For autoincrement column, don't check default
-				case (col.SQLType.Name == schemas.Bool || col.SQLType.Name == schemas.Boolean) &&
-					((strings.EqualFold(col.Default, "true") && oriCol.Default == "1") ||
-						(strings.EqualFold(col.Default, "false") && oriCol.Default == "0")):
-				default:
-					engine.logger.Warnf("Table %s Column %s db default is %s, struct default is %s",
-						tbName, col.Name, oriCol.Default, col.Default)
-				}
+			if changes&dialects.ColumnDefaultChanged != 0 && !opts.AlterColumns {
+				syncResult.warnf(engine.logger, "Table %s Column %s db default is %s, struct default is %s",
+					tbName, col.Name, oriCol.Default, col.Default)
+				changes &^= dialects.ColumnDefaultChanged
 			}
-			if col.Nullable != oriCol.Nullable {
-				engine.logger.Warnf("Table %s Column %s db nullable is %v, struct nullable is %v",
+			if changes&dialects.ColumnNullableChanged != 0 && !opts.AlterColumns {
+				syncResult.warnf(engine.logger, "Table %s Column %s db nullable is %v, struct nullable is %v",
 					tbName, col.Name, oriCol.Nullable, col.Nullable)
+				changes &^= dialects.ColumnNullableChanged
 			}
 
-			if err != nil {
-				return nil, err
+			if unalterable := changes & dialects.UnalterableColumnChanges(engine.dialect); unalterable&dialects.ColumnDefaultChanged != 0 {
+				syncResult.warnf(engine.logger, "Table %s Column %s db default is %s, struct default is %s, the default constraint could not be altered",
+					tbName, col.Name, oriCol.Default, col.Default)
+				changes &^= unalterable
+			}
+			if changes != 0 {
+				sqls := engine.dialect.AlterColumnSQL(tbNameWithSchema, col, changes)
+				if len(sqls) == 0 {
+					syncResult.warnf(engine.logger, "Table %s Column %s has been changed but could not be altered", tbName, col.Name)
+				}
+				for _, sql := range sqls {
+					if err = syncResult.exec(session, opts, sql); err != nil {
+						return nil, err
+					}
+				}
 			}
 		}
 
@@ -226,22 +269,20 @@ func (session *Session) SyncWithOptions(opts SyncOptions, beans ...interface{})
 
 		// drop indices that exist in orig and new table schema but are not equal
 		for name, index := range table.Indexes {
-			var oriIndex *schemas.Index
-			for name2, index2 := range oriTable.Indexes {
-				if index.Equal(index2) {
-					oriIndex = index2
-					foundIndexNames[name2] = true
-					break
-				}
-			}
-
-			if oriIndex == nil {
+			if name2, ok := oriTable.FindIndex(index); ok {
+				foundIndexNames[name2] = true
+			} else {
 				addedNames[name] = index
 			}
 		}
 
 		// drop all indices that do not exist in new schema or have changed
-		for name2, index2 := range oriTable.Indexes {
+		for _, name2 := range sortedIndexNames(oriTable.Indexes) {
+			index2 := oriTable.Indexes[name2]
+			// MySQL creates the indices of the foreign keys which could not be dropped
+			if oriTable.IsForeignKeyIndex(name2) {
+				continue
+			}
 			if _, ok := foundIndexNames[name2]; !ok {
 				// ignore based on there type
 				if (index2.Type == schemas.IndexType && opts.IgnoreIndices) ||
@@ -251,39 +292,157 @@ func (session *Session) SyncWithOptions(opts SyncOptions, beans ...interface{})
 					continue
 				}
 
-				sql := engine.dialect.DropIndexSQL(tbNameWithSchema, index2)
-				_, err = session.exec(sql)
-				if err != nil {
+				if err = syncResult.exec(session, opts, engine.dialect.DropIndexSQL(tbNameWithSchema, index2)); err != nil {
 					return nil, err
 				}
 			}
 		}
 
 		// Add new indices because either they did not exist before or were dropped to update them
-		for name, index := range addedNames {
-			if index.Type == schemas.UniqueType && !opts.IgnoreConstrains {
-				session.statement.RefTable = table
-				session.statement.SetTableName(tbNameWithSchema)
-				err = session.addUnique(tbNameWithSchema, name)
-			} else if index.Type == schemas.IndexType && !opts.IgnoreIndices {
-				session.statement.RefTable = table
-				session.statement.SetTableName(tbNameWithSchema)
-				err = session.addIndex(tbNameWithSchema, name)
+		for _, name := range sortedIndexNames(addedNames) {
+			index := addedNames[name]
+			if (index.Type == schemas.UniqueType && !opts.IgnoreConstrains) ||
+				(index.Type == schemas.IndexType && !opts.IgnoreIndices) {
+				if err = syncResult.exec(session, opts, engine.dialect.CreateIndexSQL(tbNameWithSchema, index)); err != nil {
+					return nil, err
+				}
 			}
-			if err != nil {
-				return nil, err
+		}
+
+		// add the foreign keys not exist and re-create the changed ones
+		if !opts.IgnoreConstrains {
+			for _, fk := range sortedForeignKeys(table) {
+				var found, changed *schemas.ForeignKey
+				for _, oriFK := range oriTable.ForeignKeys {
+					if fk.Equal(oriFK) {
+						found = oriFK
+						break
+					}
+					if strings.EqualFold(oriFK.XName(tbName), fk.XName(tbName)) {
+						changed = oriFK
+					}
+				}
+				if found != nil {
+					continue
+				}
+				if changed != nil {
+					sql := engine.dialect.DropForeignKeySQL(tbNameWithSchema, changed)
+					if sql == "" {
+						syncResult.warnf(engine.logger, "Table %s foreign key %s has been changed but could not be dropped", tbName, fk.XName(tbName))
+						continue
+					}
+					if err = syncResult.exec(session, opts, sql); err != nil {
+						return nil, err
+					}
+				}
+				foreignKeys = append(foreignKeys, pendingForeignKey{tbNameWithSchema, fk, false})
 			}
 		}
 
-		if opts.WarnIfDatabaseColumnMissed {
-			// check all the columns which removed from struct fields but left on database tables.
-			for _, colName := range oriTable.ColumnsSeq() {
-				if table.GetColumn(colName) == nil {
-					engine.logger.Warnf("Table %s has column %s but struct has not related field", engine.TableName(oriTable.Name, true), colName)
+		// check all the columns which removed from struct fields but left on
+		// database tables, they are dropped after the indices on them
+		for _, colName := range oriTable.ColumnsSeq() {
+			if table.GetColumn(colName) != nil {
+				continue
+			}
+			if opts.DropColumns {
+				if err = syncResult.exec(session, opts, engine.dialect.DropColumnSQL(tbNameWithSchema, colName)); err != nil {
+					return nil, err
 				}
+			} else if opts.WarnIfDatabaseColumnMissed {
+				syncResult.warnf(engine.logger, "Table %s has column %s but struct has not related field", engine.TableName(oriTable.Name, true), colName)
 			}
 		}
 	}
 
+	// the foreign keys are added after all the tables are created since the
+	// tables may reference each other
+	for _, item := range foreignKeys {
+		sql := engine.dialect.CreateForeignKeySQL(item.tableName, item.fk)
+		if sql == "" {
+			if !item.isNewTable {
+				syncResult.warnf(engine.logger, "Table %s foreign key %s could not be added to the existing table", item.tableName, item.fk.XName(item.tableName))
+			}
+			continue
+		}
+		if err = syncResult.exec(session, opts, sql); err != nil {
+			return nil, err
+		}
+	}
+
 	return &syncResult, nil
 }
+
+// createTableSQLs returns the SQLs to create the table of the bean with its
+// indices and unique constrains
+func (session *Session) createTableSQLs(bean interface{}, opts SyncOptions) ([]string, error) {
+	if err := session.statement.SetRefBean(bean); err != nil {
+		return nil, err
+	}
+	session.statement.RefTable.StoreEngine = session.statement.StoreEngine
+	session.statement.RefTable.Charset = session.statement.Charset
+
+	var (
+		dialect   = session.engine.dialect
+		tableName = session.statement.TableName()
+		refTable  = session.statement.RefTable
+		sqls      []string
+	)
+	if refTable.AutoIncrement != "" && dialect.Features().AutoincrMode == dialects.SequenceAutoincrMode {
+		sql, err := dialect.CreateSequenceSQL(session.ctx, session.engine.db, utils.SeqName(tableName))
+		if err != nil {
+			return nil, err
+		}
+		sqls = append(sqls, sql)
+	}
+
+	sql, _, err := dialect.CreateTableSQL(session.ctx, session.engine.db, refTable, tableName)
+	if err != nil {
+		return nil, err
+	}
+	sqls = append(sqls, sql)
+
+	// YDB declares the secondary indexes within CREATE TABLE
+	if dialect.URI().DBType == schemas.YDB {
+		return sqls, nil
+	}
+	if !opts.IgnoreConstrains {
+		sqls = append(sqls, session.statement.GenUniqueSQL()...)
+	}
+	if !opts.IgnoreIndices {
+		sqls = append(sqls, session.statement.GenIndexSQL()...)
+	}
+	return sqls, nil
+}
+
+// sortedIndexNames returns the names of the indices in order
+func sortedIndexNames(indexes map[string]*schemas.Index) []string {
+	names := make([]string, 0, len(indexes))
+	for name := range indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// pendingForeignKey represents a foreign key to be added after the tables
+type pendingForeignKey struct {
+	tableName  string
+	fk         *schemas.ForeignKey
+	isNewTable bool // the foreign keys of a new table may be created with it
+}
+
+// sortedForeignKeys returns the foreign keys of the table sorted by name
+func sortedForeignKeys(table *schemas.Table) []*schemas.ForeignKey {
+	names := make([]string, 0, len(table.ForeignKeys))
+	for name := range table.ForeignKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fks := make([]*schemas.ForeignKey, 0, len(names))
+	for _, name := range names {
+		fks = append(fks, table.ForeignKeys[name])
+	}
+	return fks
+}
@@ -281,6 +281,16 @@ func (parser *Parser) parseFieldWithTags(table *schemas.Table, fieldIndex int, f
 		addIndex(indexName, table, col, indexType)
 	}
 
+	if ctx.foreignKey != nil {
+		ctx.foreignKey.Name = col.Name
+		ctx.foreignKey.Cols = []string{col.Name}
+		ctx.foreignKey.OnDelete = ctx.onDelete
+		ctx.foreignKey.OnUpdate = ctx.onUpdate
+		table.AddForeignKey(ctx.foreignKey)
+	} else if ctx.onDelete != "" || ctx.onUpdate != "" {
+		return nil, fmt.Errorf("ondelete and onupdate of field %s should be used with fk", field.Name)
+	}
+
 	return col, nil
 }
 
@@ -617,3 +617,117 @@ func TestParseWithSQLType(t *testing.T) {
 	assert.EqualValues(t, "DATETIME", table.Columns()[3].SQLType.Name)
 	assert.EqualValues(t, "UUID", table.Columns()[4].SQLType.Name)
 }
+
+func TestParseWithForeignKey(t *testing.T) {
+	parser := NewParser(
+		"db",
+		dialects.QueryDialect("mysql"),
+		names.SnakeMapper{},
+		names.SnakeMapper{},
+		caches.NewManager(),
+	)
+
+	type StructWithForeignKey struct {
+		Id      int64
+		UserId  int64 `db:"fk(user.id) ondelete(cascade)"`
+		GroupId int64 `db:"fk(user_group.id) ondelete(set_null) onupdate('no action')"`
+	}
+
+	table, err := parser.Parse(reflect.ValueOf(new(StructWithForeignKey)))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, len(table.ForeignKeys))
+
+	fk := table.ForeignKeys["user_id"]
+	assert.NotNil(t, fk)
+	assert.EqualValues(t, []string{"user_id"}, fk.Cols)
+	assert.EqualValues(t, "user", fk.RefTable)
+	assert.EqualValues(t, []string{"id"}, fk.RefCols)
+	assert.EqualValues(t, schemas.Cascade, fk.OnDelete)
+	assert.EqualValues(t, "", fk.OnUpdate)
+	assert.EqualValues(t, "FK_struct_with_foreign_key_user_id", fk.XName(table.Name))
+
+	fk = table.ForeignKeys["group_id"]
+	assert.NotNil(t, fk)
+	assert.EqualValues(t, "user_group", fk.RefTable)
+	assert.EqualValues(t, schemas.SetNull, fk.OnDelete)
+	assert.EqualValues(t, schemas.NoAction, fk.OnUpdate)
+
+	type StructWithBadForeignKey struct {
+		UserId int64 `db:"fk(user)"`
+	}
+	_, err = parser.Parse(reflect.ValueOf(new(StructWithBadForeignKey)))
+	assert.Error(t, err)
+
+	type StructWithOnDeleteOnly struct {
+		UserId int64 `db:"ondelete(cascade)"`
+	}
+	_, err = parser.Parse(reflect.ValueOf(new(StructWithOnDeleteOnly)))
+	assert.Error(t, err)
+}
+
+type RelationItem struct {
+	Id      int64
+	OrderId int64
+}
+
+type RelationUser struct {
+	Id int64
+}
+
+type RelationGroup struct {
+	Id int64
+}
+
+func TestParseWithRelations(t *testing.T) {
+	parser := NewParser(
+		"db",
+		dialects.QueryDialect("mysql"),
+		names.SnakeMapper{},
+		names.SnakeMapper{},
+		caches.NewManager(),
+	)
+
+	type RelationOrder struct {
+		Id     int64
+		UserId int64
+		Items  []*RelationItem `db:"has_many(order_id)"`
+		User   *RelationUser   `db:"belongs_to"`
+		Groups []RelationGroup `db:"many2many(order_group)"`
+		Owners []RelationUser  `db:"many2many(order_owner,order_id,owner_id)"`
+	}
+
+	table, err := parser.Parse(reflect.ValueOf(new(RelationOrder)))
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"id", "user_id"}, table.ColumnsSeq())
+	assert.EqualValues(t, 4, len(table.Relations))
+
+	relation := table.GetRelation("items")
+	assert.NotNil(t, relation)
+	assert.EqualValues(t, schemas.HasMany, relation.Type)
+	assert.EqualValues(t, reflect.TypeOf(RelationItem{}), relation.RefType)
+	assert.EqualValues(t, "order_id", relation.ForeignKey)
+
+	relation = table.GetRelation("User")
+	assert.NotNil(t, relation)
+	assert.EqualValues(t, schemas.BelongsTo, relation.Type)
+	assert.EqualValues(t, "user_id", relation.ForeignKey)
+
+	relation = table.GetRelation("Groups")
+	assert.NotNil(t, relation)
+	assert.EqualValues(t, schemas.ManyToMany, relation.Type)
+	assert.EqualValues(t, "order_group", relation.JoinTable)
+	assert.EqualValues(t, "relation_order_id", relation.JoinForeignKey)
+	assert.EqualValues(t, "relation_group_id", relation.JoinRefKey)
+
+	relation = table.GetRelation("Owners")
+	assert.NotNil(t, relation)
+	assert.EqualValues(t, "order_id", relation.JoinForeignKey)
+	assert.EqualValues(t, "owner_id", relation.JoinRefKey)
+
+	type StructWithBadRelation struct {
+		Id    int64
+		Items *RelationItem `db:"has_many"`
+	}
+	_, err = parser.Parse(reflect.ValueOf(new(StructWithBadRelation)))
+	assert.Error(t, err)
+}
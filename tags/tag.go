@@ -5,12 +5,14 @@
 package tags
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
 	"time"
 
+	"xorm.io/xorm/names"
 	"xorm.io/xorm/schemas"
 )
 
@@ -94,6 +96,9 @@ type Context struct {
 	hasNoCacheTag   bool
 	ignoreNext      bool
 	isUnsigned      bool
+	foreignKey      *schemas.ForeignKey
+	onDelete        string
+	onUpdate        string
 }
 
 // Handler describes tag handler for XORM
@@ -124,6 +129,13 @@ var defaultTagHandlers = map[string]Handler{
 	"EXTENDS":  ExtendsTagHandler,
 	"UNSIGNED": UnsignedTagHandler,
 	"COLLATE":  CollateTagHandler,
+	"FK":       FKTagHandler,
+	"ONDELETE": OnDeleteTagHandler,
+	"ONUPDATE": OnUpdateTagHandler,
+
+	"HAS_MANY":   HasManyTagHandler,
+	"BELONGS_TO": BelongsToTagHandler,
+	"MANY2MANY":  ManyToManyTagHandler,
 }
 
 func init() {
@@ -269,6 +281,49 @@ func UniqueTagHandler(ctx *Context) error {
 	return nil
 }
 
+// FKTagHandler describes foreign key tag handler, the referenced column is
+// given as fk(table.column)
+func FKTagHandler(ctx *Context) error {
+	if len(ctx.params) == 0 {
+		return errors.New("fk tag needs the referenced table.column")
+	}
+	ref := strings.Trim(ctx.params[0], "' ")
+	idx := strings.LastIndex(ref, ".")
+	if idx <= 0 || idx == len(ref)-1 {
+		return fmt.Errorf("fk(%s) should be fk(table.column)", ref)
+	}
+	ctx.foreignKey = schemas.NewForeignKey("", nil, ref[:idx], []string{ref[idx+1:]})
+	return nil
+}
+
+// OnDeleteTagHandler describes the referential action of the foreign key on
+// delete, i.e. ondelete(cascade)
+func OnDeleteTagHandler(ctx *Context) error {
+	if len(ctx.params) == 0 {
+		return errors.New("ondelete tag needs a referential action")
+	}
+	action, err := schemas.ReferentialAction(ctx.params[0])
+	if err != nil {
+		return err
+	}
+	ctx.onDelete = action
+	return nil
+}
+
+// OnUpdateTagHandler describes the referential action of the foreign key on
+// update, i.e. onupdate(cascade)
+func OnUpdateTagHandler(ctx *Context) error {
+	if len(ctx.params) == 0 {
+		return errors.New("onupdate tag needs a referential action")
+	}
+	action, err := schemas.ReferentialAction(ctx.params[0])
+	if err != nil {
+		return err
+	}
+	ctx.onUpdate = action
+	return nil
+}
+
 // UnsignedTagHandler represents the column is unsigned
 func UnsignedTagHandler(ctx *Context) error {
 	ctx.isUnsigned = true
@@ -407,3 +462,95 @@ func NoCacheTagHandler(ctx *Context) error {
 	}
 	return nil
 }
+
+// relatedType returns the struct type of the related table of the field, the
+// field should be a slice of the structs or the pointers if isSlice is true,
+// or else a struct or a pointer
+func relatedType(ctx *Context, isSlice bool) (reflect.Type, error) {
+	t := ctx.fieldValue.Type()
+	if isSlice {
+		if t.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("field %s of %s should be a slice", ctx.col.FieldName, ctx.tagUname)
+		}
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("field %s of %s should be the structs of the related table", ctx.col.FieldName, ctx.tagUname)
+	}
+	return t, nil
+}
+
+// addRelation adds the relation of the field to the table, the field is not
+// a column
+func addRelation(ctx *Context, relation *schemas.Relation) error {
+	relation.FieldName = ctx.col.FieldName
+	relation.FieldIndex = ctx.col.FieldIndex
+	ctx.table.AddRelation(relation)
+	return ErrIgnoreField
+}
+
+// HasManyTagHandler describes the structs of another table referencing this
+// one by the column, i.e. has_many(user_id), the column is the name of this
+// table with _id by default
+func HasManyTagHandler(ctx *Context) error {
+	refType, err := relatedType(ctx, true)
+	if err != nil {
+		return err
+	}
+	foreignKey := ctx.table.Name + "_id"
+	if len(ctx.params) > 0 {
+		foreignKey = strings.Trim(ctx.params[0], "' ")
+	}
+	return addRelation(ctx, &schemas.Relation{
+		Type:       schemas.HasMany,
+		RefType:    refType,
+		ForeignKey: foreignKey,
+	})
+}
+
+// BelongsToTagHandler describes the struct of another table referenced by
+// the column of this one, i.e. belongs_to(user_id), the column is the name of
+// the field with _id by default
+func BelongsToTagHandler(ctx *Context) error {
+	refType, err := relatedType(ctx, false)
+	if err != nil {
+		return err
+	}
+	foreignKey := ctx.parser.columnMapper.Obj2Table(ctx.col.FieldName) + "_id"
+	if len(ctx.params) > 0 {
+		foreignKey = strings.Trim(ctx.params[0], "' ")
+	}
+	return addRelation(ctx, &schemas.Relation{
+		Type:       schemas.BelongsTo,
+		RefType:    refType,
+		ForeignKey: foreignKey,
+	})
+}
+
+// ManyToManyTagHandler describes the structs of another table joined by the
+// join table, i.e. many2many(user_group) or many2many(user_group,user_id,group_id),
+// the columns of the join table are the names of the tables with _id by default
+func ManyToManyTagHandler(ctx *Context) error {
+	if len(ctx.params) != 1 && len(ctx.params) != 3 {
+		return errors.New("many2many tag needs the join table and optional the two columns of it")
+	}
+	refType, err := relatedType(ctx, true)
+	if err != nil {
+		return err
+	}
+	relation := &schemas.Relation{
+		Type:           schemas.ManyToMany,
+		RefType:        refType,
+		JoinTable:      strings.Trim(ctx.params[0], "' "),
+		JoinForeignKey: ctx.table.Name + "_id",
+		JoinRefKey:     names.GetTableName(ctx.parser.tableMapper, reflect.New(refType).Elem()) + "_id",
+	}
+	if len(ctx.params) == 3 {
+		relation.JoinForeignKey = strings.Trim(ctx.params[1], "' ")
+		relation.JoinRefKey = strings.Trim(ctx.params[2], "' ")
+	}
+	return addRelation(ctx, relation)
+}
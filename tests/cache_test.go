@@ -181,3 +181,46 @@ func TestCacheGet(t *testing.T) {
 
 	testEngine.SetDefaultCacher(oldCacher)
 }
+
+func TestShardedCacher(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type MailBox5 struct {
+		Id       int64
+		Username string
+		Password string
+	}
+
+	oldCacher := testEngine.GetDefaultCacher()
+	cacher := caches.NewShardedCacher(0, 1<<20, time.Hour)
+	testEngine.SetDefaultCacher(cacher)
+	defer testEngine.SetDefaultCacher(oldCacher)
+
+	assert.NoError(t, testEngine.Sync(new(MailBox5)))
+
+	box := MailBox5{
+		Username: "user1",
+		Password: "pass1",
+	}
+	_, err := testEngine.Insert(&box)
+	assert.NoError(t, err)
+
+	tableName := testEngine.TableName(box, true)
+	for i := 0; i < 2; i++ {
+		var box2 MailBox5
+		has, err := testEngine.ID(box.Id).Get(&box2)
+		assert.NoError(t, err)
+		assert.True(t, has)
+		assert.EqualValues(t, "user1", box2.Username)
+	}
+	assert.True(t, cacher.Stats(tableName).Hits > 0)
+
+	_, err = testEngine.ID(box.Id).Update(&MailBox5{Password: "pass2"})
+	assert.NoError(t, err)
+
+	var box3 MailBox5
+	has, err := testEngine.ID(box.Id).Get(&box3)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, "pass2", box3.Password)
+}
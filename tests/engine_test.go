@@ -6,6 +6,8 @@ package tests
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"testing"
@@ -84,6 +86,112 @@ func TestAutoTransaction(t *testing.T) {
 	assert.EqualValues(t, false, has)
 }
 
+// retryableError looks like the deadlock errors of the drivers
+type retryableError struct {
+	Number int
+}
+
+func (e *retryableError) Error() string {
+	return fmt.Sprintf("deadlock %d", e.Number)
+}
+
+func (e *retryableError) SQLState() string {
+	return "40001"
+}
+
+func TestTransactionContext(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type TestTxContext struct {
+		Id  int64  `xorm:"autoincr pk"`
+		Msg string `xorm:"varchar(255)"`
+	}
+
+	assert.NoError(t, testEngine.Sync(new(TestTxContext)))
+
+	engine := testEngine.(*xorm.Engine)
+	ctx := context.Background()
+
+	// will commit
+	err := engine.TransactionContext(ctx, func(session *xorm.Session) error {
+		_, err := session.Insert(&TestTxContext{Msg: "hi"})
+		return err
+	}, xorm.TxIsolationLevel(sql.LevelDefault))
+	assert.NoError(t, err)
+
+	has, err := engine.Exist(&TestTxContext{Msg: "hi"})
+	assert.NoError(t, err)
+	assert.True(t, has)
+
+	// will rollback
+	err = engine.TransactionContext(ctx, func(session *xorm.Session) error {
+		_, err := session.Insert(&TestTxContext{Msg: "hello"})
+		assert.NoError(t, err)
+		return errors.New("rollback")
+	})
+	assert.EqualError(t, err, "rollback")
+
+	has, err = engine.Exist(&TestTxContext{Msg: "hello"})
+	assert.NoError(t, err)
+	assert.False(t, has)
+
+	// will rollback and panic again
+	assert.Panics(t, func() {
+		_ = engine.TransactionContext(ctx, func(session *xorm.Session) error {
+			_, err := session.Insert(&TestTxContext{Msg: "panic"})
+			assert.NoError(t, err)
+			panic("panic")
+		})
+	})
+
+	has, err = engine.Exist(&TestTxContext{Msg: "panic"})
+	assert.NoError(t, err)
+	assert.False(t, has)
+
+	// will retry twice then commit
+	var number int
+	switch testEngine.Dialect().URI().DBType {
+	case schemas.MYSQL:
+		number = 1213
+	case schemas.MSSQL:
+		number = 1205
+	case schemas.SQLITE:
+		number = 5
+	}
+	var attempts int
+	err = engine.TransactionContext(ctx, func(session *xorm.Session) error {
+		attempts++
+		if _, err := session.Insert(&TestTxContext{Msg: fmt.Sprintf("retry%d", attempts)}); err != nil {
+			return err
+		}
+		if attempts < 3 {
+			return &retryableError{number}
+		}
+		return nil
+	}, xorm.TxBackoff(func(int) time.Duration { return time.Millisecond }))
+
+	if testEngine.Dialect().URI().DBType == schemas.ORACLE || testEngine.Dialect().URI().DBType == schemas.DAMENG {
+		assert.Error(t, err)
+		assert.EqualValues(t, 1, attempts)
+		return
+	}
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, attempts)
+
+	cnt, err := engine.Where("`msg` LIKE ?", "retry%").Count(new(TestTxContext))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+
+	// will stop retrying
+	attempts = 0
+	err = engine.TransactionContext(ctx, func(session *xorm.Session) error {
+		attempts++
+		return &retryableError{number}
+	}, xorm.TxMaxRetries(1), xorm.TxBackoff(func(int) time.Duration { return time.Millisecond }))
+	assert.Error(t, err)
+	assert.EqualValues(t, 2, attempts)
+}
+
 func assertSync(t *testing.T, beans ...interface{}) {
 	for _, bean := range beans {
 		t.Run(testEngine.TableName(bean, true), func(t *testing.T) {
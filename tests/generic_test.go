@@ -0,0 +1,79 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tests
+
+import (
+	"testing"
+
+	"xorm.io/xorm"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenericQuery(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type GenericUser struct {
+		Id   int64
+		Name string
+		Age  int
+	}
+	assertSync(t, new(GenericUser))
+
+	for i, name := range []string{"a", "b", "c"} {
+		_, err := testEngine.Insert(&GenericUser{Name: name, Age: 10 * (i + 1)})
+		assert.NoError(t, err)
+	}
+
+	users, err := xorm.FindT[GenericUser](testEngine.Asc("id"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, len(users))
+	assert.EqualValues(t, "a", users[0].Name)
+
+	user, has, err := xorm.GetT[GenericUser](testEngine.Where("`name` = ?", "b"))
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, 20, user.Age)
+
+	user, has, err = xorm.GetT[GenericUser](testEngine.Where("`name` = ?", "d"))
+	assert.NoError(t, err)
+	assert.False(t, has)
+	assert.Nil(t, user)
+
+	var names []string
+	err = xorm.IterateT(testEngine.Asc("id"), func(idx int, user *GenericUser) error {
+		names = append(names, user.Name)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"a", "b", "c"}, names)
+
+	users, err = xorm.NewQuery[GenericUser](testEngine.NewSession()).
+		Cols("id", "name").Where("age", ">", 10).Desc("age").Find()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, len(users))
+	assert.EqualValues(t, "c", users[0].Name)
+	assert.EqualValues(t, 0, users[0].Age)
+
+	cnt, err := xorm.NewQuery[GenericUser](testEngine.NewSession()).In("name", "a", "b").Count()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, cnt)
+
+	user, has, err = xorm.NewQuery[GenericUser](testEngine.NewSession()).Where("name", "like", "c%").Get()
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, 30, user.Age)
+
+	_, err = xorm.NewQuery[GenericUser](testEngine.NewSession()).Cols("unknown").Find()
+	assert.Error(t, err)
+
+	_, err = xorm.NewQuery[GenericUser](testEngine.NewSession()).Where("age", "between", 1).Count()
+	assert.Error(t, err)
+
+	err = xorm.NewQuery[GenericUser](testEngine.NewSession()).Asc("unknown").Iterate(func(idx int, user *GenericUser) error {
+		return nil
+	})
+	assert.Error(t, err)
+}
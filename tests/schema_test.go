@@ -719,6 +719,77 @@ func TestSyncWithOptions(t *testing.T) {
 
 }
 
+type SyncDestructive1 struct {
+	Id       int64
+	Name     string `xorm:"varchar(20)"`
+	Age      int    `xorm:"index"`
+	Obsolete string `xorm:"varchar(20)"`
+}
+
+func (*SyncDestructive1) TableName() string {
+	return "sync_destructive"
+}
+
+type SyncDestructive2 struct {
+	Id   int64
+	Name string `xorm:"varchar(20) notnull default 'none'"`
+	Age  int
+}
+
+func (*SyncDestructive2) TableName() string {
+	return "sync_destructive"
+}
+
+func getColumnsOfBeanFromDB(t *testing.T, bean interface{}) []string {
+	dbm, err := testEngine.DBMetas()
+	assert.NoError(t, err)
+
+	tName := testEngine.TableName(bean)
+	for _, table := range dbm {
+		if table.Name == tName {
+			return table.ColumnsSeq()
+		}
+	}
+	return nil
+}
+
+func TestSyncWithOptionsDestructive(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+	assert.NoError(t, testEngine.DropTables(new(SyncDestructive1)))
+	assert.NoError(t, testEngine.Sync(new(SyncDestructive1)))
+
+	opts := xorm.SyncOptions{DropColumns: true, AlterColumns: true, DryRun: true}
+	result, err := testEngine.SyncWithOptions(opts, new(SyncDestructive2))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, result.SQLs)
+	plan := strings.Join(result.SQLs, "\n")
+	assert.Contains(t, plan, "DROP INDEX")
+	assert.Contains(t, plan, "DROP COLUMN")
+	assert.Contains(t, plan, "obsolete")
+	// the altering of the columns is planned or warned
+	if testEngine.Dialect().URI().DBType == schemas.SQLITE {
+		assert.NotEmpty(t, result.Warnings)
+	}
+
+	// nothing changed on a dry run
+	assert.Contains(t, getColumnsOfBeanFromDB(t, new(SyncDestructive1)), "obsolete")
+	assert.Len(t, getIndicesOfBeanFromDB(t, new(SyncDestructive1)), 1)
+
+	opts.DryRun = false
+	result2, err := testEngine.SyncWithOptions(opts, new(SyncDestructive2))
+	assert.NoError(t, err)
+	assert.EqualValues(t, result.SQLs, result2.SQLs)
+	assert.NotContains(t, getColumnsOfBeanFromDB(t, new(SyncDestructive1)), "obsolete")
+	assert.Len(t, getIndicesOfBeanFromDB(t, new(SyncDestructive1)), 0)
+
+	// the columns are left without the options
+	assert.NoError(t, testEngine.Sync(new(SyncDestructive1)))
+	result, err = testEngine.SyncWithOptions(xorm.SyncOptions{WarnIfDatabaseColumnMissed: true}, new(SyncDestructive2))
+	assert.NoError(t, err)
+	assert.Contains(t, getColumnsOfBeanFromDB(t, new(SyncDestructive1)), "obsolete")
+	assert.NotEmpty(t, result.Warnings)
+}
+
 func getIndicesOfBeanFromDB(t *testing.T, bean interface{}) map[string]*schemas.Index {
 	dbm, err := testEngine.DBMetas()
 	assert.NoError(t, err)
@@ -0,0 +1,140 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"xorm.io/xorm"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateMulti(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type UpdateMultiStruct struct {
+		Id      int64
+		Name    string
+		Age     int
+		Updated time.Time `xorm:"updated"`
+		Version int       `xorm:"version"`
+	}
+	assertSync(t, new(UpdateMultiStruct))
+
+	// enough rows to be split into batches
+	beans := make([]UpdateMultiStruct, 600)
+	for i := range beans {
+		beans[i].Name = fmt.Sprintf("name%d", i)
+		beans[i].Age = i
+	}
+	cnt, err := testEngine.Insert(&beans)
+	assert.NoError(t, err)
+	assert.EqualValues(t, len(beans), cnt)
+
+	beans = beans[:0]
+	assert.NoError(t, testEngine.Asc("id").Find(&beans))
+	for i := range beans {
+		beans[i].Name = fmt.Sprintf("new%d", i)
+		beans[i].Age = 0
+	}
+
+	cnt, err = testEngine.UpdateMulti(&beans)
+	assert.NoError(t, err)
+	assert.EqualValues(t, len(beans), cnt)
+	assert.EqualValues(t, 2, beans[0].Version)
+	assert.False(t, beans[0].Updated.IsZero())
+
+	var updated []UpdateMultiStruct
+	assert.NoError(t, testEngine.Asc("id").Find(&updated))
+	assert.Len(t, updated, len(beans))
+	for i, bean := range updated {
+		assert.EqualValues(t, fmt.Sprintf("new%d", i), bean.Name)
+		assert.EqualValues(t, 0, bean.Age)
+		assert.EqualValues(t, 2, bean.Version)
+	}
+
+	// only the given columns are updated
+	beans[0].Name = "only age"
+	beans[0].Age = 10
+	cnt, err = testEngine.Cols("age").UpdateMulti(beans[:1])
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+
+	var bean UpdateMultiStruct
+	has, err := testEngine.ID(beans[0].Id).Get(&bean)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, "new0", bean.Name)
+	assert.EqualValues(t, 10, bean.Age)
+	assert.EqualValues(t, 3, bean.Version)
+
+	// the stale version makes the batch fail and roll back
+	stale := []UpdateMultiStruct{updated[0], beans[1]}
+	stale[0].Name = "stale"
+	stale[1].Name = "fresh"
+	_, err = testEngine.UpdateMulti(&stale)
+	assert.ErrorIs(t, err, xorm.ErrVersionConflict)
+
+	var kept UpdateMultiStruct
+	has, err = testEngine.ID(beans[1].Id).Get(&kept)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.NotEqual(t, "fresh", kept.Name)
+	assert.EqualValues(t, beans[1].Version, kept.Version)
+}
+
+func TestDeleteMulti(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type DeleteMultiStruct struct {
+		Id   int64
+		Name string
+	}
+	type SoftDeleteMultiStruct struct {
+		Id      int64
+		Name    string
+		Deleted time.Time `xorm:"deleted"`
+	}
+	assertSync(t, new(DeleteMultiStruct), new(SoftDeleteMultiStruct))
+
+	beans := make([]DeleteMultiStruct, 1200)
+	softBeans := make([]*SoftDeleteMultiStruct, 3)
+	for i := range beans {
+		beans[i].Name = fmt.Sprintf("name%d", i)
+	}
+	for i := range softBeans {
+		softBeans[i] = &SoftDeleteMultiStruct{Name: fmt.Sprintf("name%d", i)}
+	}
+	_, err := testEngine.Insert(&beans, &softBeans)
+	assert.NoError(t, err)
+
+	beans = beans[:0]
+	assert.NoError(t, testEngine.Find(&beans))
+	cnt, err := testEngine.DeleteMulti(beans[:1100])
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1100, cnt)
+
+	total, err := testEngine.Count(new(DeleteMultiStruct))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 100, total)
+
+	softBeans = softBeans[:0]
+	assert.NoError(t, testEngine.Find(&softBeans))
+	cnt, err = testEngine.DeleteMulti(softBeans[:2])
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, cnt)
+	assert.False(t, softBeans[0].Deleted.IsZero())
+
+	total, err = testEngine.Count(new(SoftDeleteMultiStruct))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+
+	total, err = testEngine.Unscoped().Count(new(SoftDeleteMultiStruct))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, total)
+}
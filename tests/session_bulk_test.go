@@ -0,0 +1,78 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	_ "xorm.io/xorm/bulk"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkLoad(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type BulkRecord struct {
+		Id      int64
+		Name    string
+		Score   float64
+		Note    string    `xorm:"null"`
+		Created time.Time `xorm:"created"`
+	}
+	type BulkRecordCopy struct {
+		Id      int64
+		Name    string
+		Score   float64
+		Note    string    `xorm:"null"`
+		Created time.Time `xorm:"created"`
+	}
+	assertSync(t, new(BulkRecord), new(BulkRecordCopy))
+
+	records := make([]*BulkRecord, 0, 2500)
+	for i := 0; i < 2500; i++ {
+		records = append(records, &BulkRecord{
+			Name:  fmt.Sprintf("name\t%d", i),
+			Score: float64(i) / 2,
+		})
+	}
+	cnt, err := testEngine.BulkLoad(records)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2500, cnt)
+
+	total, err := testEngine.Count(new(BulkRecord))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2500, total)
+
+	var record BulkRecord
+	has, err := testEngine.Where("`name` = ?", "name\t10").Get(&record)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, 5, record.Score)
+	assert.False(t, record.Created.IsZero())
+
+	// copy the records by the rows of the table in a transaction
+	session := testEngine.NewSession()
+	defer session.Close()
+	assert.NoError(t, session.Begin())
+	rows, err := testEngine.Table(new(BulkRecord)).Where("`score` >= ?", 1000).Rows(new(BulkRecordCopy))
+	assert.NoError(t, err)
+	cnt, err = session.BulkLoad(rows)
+	assert.NoError(t, rows.Close())
+	assert.NoError(t, err)
+	assert.EqualValues(t, 500, cnt)
+	assert.NoError(t, session.Commit())
+
+	var copies []BulkRecordCopy
+	assert.NoError(t, testEngine.Asc("id").Find(&copies))
+	assert.EqualValues(t, 500, len(copies))
+	assert.EqualValues(t, "name\t2000", copies[0].Name)
+
+	cnt, err = testEngine.BulkLoad([]BulkRecord{})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, cnt)
+}
@@ -0,0 +1,55 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tests
+
+import (
+	"testing"
+
+	"xorm.io/xorm/schemas"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplain(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type ExplainRecord struct {
+		Id    int64
+		Name  string `xorm:"index"`
+		Score int
+	}
+	assertSync(t, new(ExplainRecord))
+
+	for i := 0; i < 10; i++ {
+		_, err := testEngine.Insert(&ExplainRecord{Name: string(rune('a' + i)), Score: i})
+		assert.NoError(t, err)
+	}
+
+	var records []ExplainRecord
+	plan, err := testEngine.Where("`score` > ?", 5).ExplainFind(&records)
+	assert.NoError(t, err)
+	assert.NotNil(t, plan.Root)
+	assert.NotEmpty(t, plan.Raw)
+	assert.Contains(t, plan.SQL, "score")
+	assert.EqualValues(t, []interface{}{5}, plan.Args)
+	fullScans := plan.FullScans()
+	if assert.EqualValues(t, 1, len(fullScans), plan.String()) {
+		assert.Contains(t, fullScans[0].Table, "explain_record")
+	}
+	assert.Empty(t, records)
+
+	plan, err = testEngine.ID(3).Explain(new(ExplainRecord))
+	assert.NoError(t, err)
+	assert.NotNil(t, plan.Root)
+	assert.Contains(t, plan.SQL, "id")
+
+	// the plan on SQLite is like SEARCH explain_record USING INDEX
+	if testEngine.Dialect().URI().DBType == schemas.SQLITE {
+		plan, err = testEngine.Where("`name` = ?", "c").Explain(new(ExplainRecord))
+		assert.NoError(t, err)
+		assert.Empty(t, plan.FullScans(), plan.String())
+		assert.NotEmpty(t, plan.Root.Index, plan.String())
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/gob"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"xorm.io/xorm"
+	"xorm.io/xorm/schemas"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExport(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type ExportRecord struct {
+		Id      int64
+		Name    string
+		Score   float64
+		Data    []byte
+		Created time.Time
+	}
+	assertSync(t, new(ExportRecord))
+
+	created := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	_, err := testEngine.Insert(&ExportRecord{Name: "a,\"b\"", Score: 1.5, Data: []byte{0, 1}, Created: created})
+	assert.NoError(t, err)
+	_, err = testEngine.Insert(&ExportRecord{Name: "c", Score: 2, Created: created})
+	assert.NoError(t, err)
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := testEngine.Table(new(ExportRecord)).Asc("id").Export(&buf, xorm.ExportCSV)
+		assert.NoError(t, err)
+
+		records, err := csv.NewReader(&buf).ReadAll()
+		assert.NoError(t, err)
+		assert.EqualValues(t, 3, len(records))
+		assert.EqualValues(t, []string{"id", "name", "score", "data", "created"}, records[0])
+		assert.EqualValues(t, "a,\"b\"", records[1][1])
+		assert.EqualValues(t, "1.5", records[1][2])
+		assert.EqualValues(t, "AAE=", records[1][3])
+		exported, err := time.Parse(time.RFC3339Nano, records[1][4])
+		assert.NoError(t, err)
+		assert.True(t, created.Equal(exported))
+		assert.EqualValues(t, "c", records[2][1])
+	})
+
+	t.Run("jsonl", func(t *testing.T) {
+		var buf bytes.Buffer
+		sess := testEngine.NewSession()
+		defer sess.Close()
+		err := sess.Export(&buf, xorm.ExportJSONLines,
+			"SELECT `name`, `score` FROM "+testEngine.Quote(testEngine.TableName(new(ExportRecord), true))+" ORDER BY `id`")
+		assert.NoError(t, err)
+
+		scanner := bufio.NewScanner(&buf)
+		var lines []map[string]interface{}
+		for scanner.Scan() {
+			var line map[string]interface{}
+			assert.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+			lines = append(lines, line)
+		}
+		assert.EqualValues(t, 2, len(lines))
+		assert.EqualValues(t, "a,\"b\"", lines[0]["name"])
+		assert.EqualValues(t, 1.5, lines[0]["score"])
+		assert.EqualValues(t, 2, lines[1]["score"])
+	})
+
+	t.Run("gob", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := testEngine.Cols("id", "name").Where("`score` > ?", 1.9).Table(new(ExportRecord)).Export(&buf, xorm.ExportGob)
+		assert.NoError(t, err)
+
+		dec := gob.NewDecoder(&buf)
+		var fields []string
+		assert.NoError(t, dec.Decode(&fields))
+		assert.EqualValues(t, []string{"id", "name"}, fields)
+		var values []interface{}
+		assert.NoError(t, dec.Decode(&values))
+		assert.EqualValues(t, 2, len(values))
+		assert.EqualValues(t, "c", values[1])
+		assert.Error(t, dec.Decode(&values))
+	})
+}
+
+func TestExportDecimal(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type ExportDecimal struct {
+		Id     int64
+		Amount string `xorm:"decimal(20,2)"`
+	}
+	assertSync(t, new(ExportDecimal))
+
+	_, err := testEngine.Insert(&ExportDecimal{Amount: "1.5"})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, testEngine.Table(new(ExportDecimal)).Export(&buf, xorm.ExportJSONLines))
+	var line map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	// the ids are numbers while the decimals are strings to keep the precision
+	assert.EqualValues(t, 1, line["id"])
+	amount, ok := line["amount"].(string)
+	assert.True(t, ok)
+	assert.True(t, amount == "1.5" || amount == "1.50", amount)
+}
+
+func TestExportTimeOffset(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+	if testEngine.Dialect().URI().DBType != schemas.SQLITE {
+		t.Skip("the time is inserted as a string with an offset")
+		return
+	}
+
+	type ExportTimeOffset struct {
+		Id      int64
+		Created time.Time
+	}
+	assertSync(t, new(ExportTimeOffset))
+
+	// the driver returns the time with its negative offset
+	_, err := testEngine.Exec("INSERT INTO "+testEngine.Quote(testEngine.TableName(new(ExportTimeOffset), true))+
+		" (`created`) VALUES (?)", "2023-01-02T03:04:05-05:00")
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, testEngine.Table(new(ExportTimeOffset)).Export(&buf, xorm.ExportCSV))
+	records, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(t, err)
+	if assert.EqualValues(t, 2, len(records)) {
+		exported, err := time.Parse(time.RFC3339Nano, records[1][1])
+		assert.NoError(t, err)
+		assert.True(t, time.Date(2023, 1, 2, 8, 4, 5, 0, time.UTC).Equal(exported), records[1][1])
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"xorm.io/xorm/contexts"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type hookFuncKey struct{}
+
+// ctxHook runs the function carried by the context of the statement, so it
+// only affects the statements of the test adding it
+type ctxHook struct{}
+
+func (ctxHook) BeforeProcess(c *contexts.ContextHook) (context.Context, error) {
+	if f, ok := c.Ctx.Value(hookFuncKey{}).(func(c *contexts.ContextHook)); ok {
+		f(c)
+	}
+	return c.Ctx, nil
+}
+
+func (ctxHook) AfterProcess(c *contexts.ContextHook) error {
+	return c.Err
+}
+
+var addCtxHookOnce sync.Once
+
+func withHookFunc(f func(c *contexts.ContextHook)) context.Context {
+	addCtxHookOnce.Do(func() {
+		testEngine.AddHook(ctxHook{})
+	})
+	return context.WithValue(context.Background(), hookFuncKey{}, f)
+}
+
+func TestHookMetadata(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type HookMetadata struct {
+		Id   int64
+		Name string
+	}
+
+	assertSync(t, new(HookMetadata))
+
+	var hooked []*contexts.ContextHook
+	ctx := withHookFunc(func(c *contexts.ContextHook) {
+		hooked = append(hooked, c)
+	})
+
+	bean := HookMetadata{Name: "a"}
+	_, err := testEngine.Context(ctx).Insert(&bean)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, hooked)
+	c := hooked[len(hooked)-1]
+	assert.EqualValues(t, contexts.OperationInsert, c.Operation)
+	if assert.NotNil(t, c.Table) {
+		assert.EqualValues(t, testEngine.TableName(bean, true), c.Table.Name)
+	}
+	if assert.EqualValues(t, 1, len(c.Beans)) {
+		assert.True(t, c.Beans[0] == &bean)
+	}
+	assert.NotEmpty(t, c.SessionID)
+
+	hooked = nil
+	var beans []HookMetadata
+	assert.NoError(t, testEngine.Context(ctx).Find(&beans))
+	assert.EqualValues(t, 1, len(hooked))
+	assert.EqualValues(t, contexts.OperationFind, hooked[0].Operation)
+
+	hooked = nil
+	_, err = testEngine.Context(ctx).Exec("DELETE FROM " + testEngine.Quote(testEngine.TableName(bean, true)))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, len(hooked))
+	assert.EqualValues(t, contexts.OperationExec, hooked[0].Operation)
+	assert.Nil(t, hooked[0].Table)
+}
+
+func TestHookRewrite(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type HookRewrite struct {
+		Id   int64
+		Name string
+	}
+
+	assertSync(t, new(HookRewrite))
+
+	_, err := testEngine.Insert([]HookRewrite{{Name: "a"}, {Name: "b"}})
+	assert.NoError(t, err)
+
+	// rewrite the argument of the condition
+	ctx := withHookFunc(func(c *contexts.ContextHook) {
+		if c.Operation == contexts.OperationFind && len(c.Args) == 1 && c.Args[0] == "a" {
+			c.Args = []interface{}{"b"}
+		}
+	})
+	var beans []HookRewrite
+	assert.NoError(t, testEngine.Context(ctx).Where("name = ?", "a").Find(&beans))
+	if assert.EqualValues(t, 1, len(beans)) {
+		assert.EqualValues(t, "b", beans[0].Name)
+	}
+}
+
+func TestHookShortCircuit(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type HookShortCircuit struct {
+		Id   int64
+		Name string
+	}
+
+	assertSync(t, new(HookShortCircuit))
+
+	ctx := withHookFunc(func(c *contexts.ContextHook) {
+		switch c.Operation {
+		case contexts.OperationDelete:
+			c.ShortCircuit(contexts.SyntheticResult{Affected: 5})
+		case contexts.OperationFind:
+			c.ShortCircuitRows([]string{"id", "name"}, [][]interface{}{
+				{int64(1), "x"},
+				{int64(2), "y"},
+			})
+		}
+	})
+
+	_, err := testEngine.Insert(&HookShortCircuit{Name: "a"})
+	assert.NoError(t, err)
+
+	affected, err := testEngine.Context(ctx).Where("1=1").Delete(new(HookShortCircuit))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 5, affected)
+
+	// nothing is deleted
+	cnt, err := testEngine.Count(new(HookShortCircuit))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+
+	var beans []HookShortCircuit
+	assert.NoError(t, testEngine.Context(ctx).Find(&beans))
+	if assert.EqualValues(t, 2, len(beans)) {
+		assert.EqualValues(t, 1, beans[0].Id)
+		assert.EqualValues(t, "x", beans[0].Name)
+		assert.EqualValues(t, "y", beans[1].Name)
+	}
+}
@@ -47,6 +47,44 @@ func TestInsertMulti(t *testing.T) {
 	assert.EqualValues(t, 3, num)
 }
 
+func TestInsertMultiBatches(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type InsertMultiBatch struct {
+		Id    int64
+		Name  string
+		Age   int
+		Email string
+	}
+	assertSync(t, new(InsertMultiBatch))
+
+	// more parameters than any database allows in one statement
+	beans := make([]InsertMultiBatch, 20000)
+	for i := range beans {
+		beans[i].Name = fmt.Sprintf("name%d", i)
+		beans[i].Age = i
+	}
+	cnt, err := testEngine.InsertMulti(&beans)
+	assert.NoError(t, err)
+	assert.EqualValues(t, len(beans), cnt)
+
+	total, err := testEngine.Count(new(InsertMultiBatch))
+	assert.NoError(t, err)
+	assert.EqualValues(t, len(beans), total)
+
+	// the ids are only written back if they're returned in order
+	if testEngine.Dialect().URI().DBType != schemas.POSTGRES {
+		return
+	}
+	for _, i := range []int{0, 999, len(beans) - 1} {
+		var bean InsertMultiBatch
+		has, err := testEngine.ID(beans[i].Id).Get(&bean)
+		assert.NoError(t, err)
+		assert.True(t, has)
+		assert.EqualValues(t, beans[i].Name, bean.Name)
+	}
+}
+
 func insertMultiDatas(step int, datas interface{}) (num int64, err error) {
 	sliceValue := reflect.Indirect(reflect.ValueOf(datas))
 	var iLen int64
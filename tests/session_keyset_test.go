@@ -0,0 +1,81 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tests
+
+import (
+	"testing"
+
+	"xorm.io/xorm"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeysetPagination(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type KeysetRecord struct {
+		Id    int64
+		Name  string
+		Score int
+	}
+	assertSync(t, new(KeysetRecord))
+
+	for i := 0; i < 10; i++ {
+		_, err := testEngine.Insert(&KeysetRecord{Name: string(rune('a' + i)), Score: i % 3})
+		assert.NoError(t, err)
+	}
+
+	var expected []KeysetRecord
+	assert.NoError(t, testEngine.Desc("score").Asc("id").Find(&expected))
+	assert.EqualValues(t, 10, len(expected))
+
+	var (
+		pages  int
+		cursor string
+		found  []KeysetRecord
+	)
+	for {
+		var records []KeysetRecord
+		next, err := testEngine.Desc("score").Asc("id").Seek(cursor).Limit(3).FindPage(&records)
+		assert.NoError(t, err)
+		found = append(found, records...)
+		pages++
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	assert.EqualValues(t, 4, pages)
+	assert.EqualValues(t, expected, found)
+
+	// the same direction uses the row values on the databases supporting them
+	var records []KeysetRecord
+	cursor, err := testEngine.Asc("score", "id").Limit(4).FindPage(&records)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, cursor)
+	records = nil
+	_, err = testEngine.Asc("score", "id").Seek(cursor).Limit(4).FindPage(&records)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 4, len(records))
+	assert.EqualValues(t, 1, records[0].Score)
+
+	records = nil
+	err = testEngine.PageAfter([]string{"id"}, []interface{}{expected[0].Id}).Find(&records)
+	assert.NoError(t, err)
+	var greater int
+	for _, record := range expected {
+		if record.Id > expected[0].Id {
+			greater++
+		}
+	}
+	assert.EqualValues(t, greater, len(records))
+
+	_, err = testEngine.Desc("score").Asc("id").Seek(cursor[:len(cursor)-2] + "xx").FindPage(&records)
+	assert.ErrorIs(t, err, xorm.ErrInvalidCursor)
+
+	// the cursor of other order by columns
+	_, err = testEngine.Desc("id").Seek(cursor).FindPage(&records)
+	assert.Error(t, err)
+}
@@ -0,0 +1,167 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type PreloadUser struct {
+	Id     int64
+	Name   string
+	Orders []*PreloadOrder `xorm:"has_many(user_id)"`
+	Groups []PreloadGroup  `xorm:"many2many(preload_user_group)"`
+}
+
+type PreloadOrder struct {
+	Id     int64
+	UserId int64
+	Title  string
+	User   *PreloadUser  `xorm:"belongs_to"`
+	Items  []PreloadItem `xorm:"has_many(order_id)"`
+}
+
+type PreloadItem struct {
+	Id      int64
+	OrderId int64
+	Name    string
+}
+
+type PreloadGroup struct {
+	Id   int64
+	Name string
+}
+
+type PreloadUserGroup struct {
+	PreloadUserId  int64 `xorm:"pk"`
+	PreloadGroupId int64 `xorm:"pk"`
+}
+
+func TestPreload(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+	assertSync(t, new(PreloadUser), new(PreloadOrder), new(PreloadItem), new(PreloadGroup), new(PreloadUserGroup))
+
+	users := []*PreloadUser{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	for _, user := range users {
+		_, err := testEngine.Insert(user)
+		assert.NoError(t, err)
+	}
+	orders := []*PreloadOrder{
+		{UserId: users[0].Id, Title: "a1"},
+		{UserId: users[0].Id, Title: "a2"},
+		{UserId: users[1].Id, Title: "b1"},
+	}
+	for _, order := range orders {
+		_, err := testEngine.Insert(order)
+		assert.NoError(t, err)
+	}
+	for i, order := range orders {
+		for j := 0; j <= i; j++ {
+			_, err := testEngine.Insert(&PreloadItem{OrderId: order.Id, Name: order.Title})
+			assert.NoError(t, err)
+		}
+	}
+	groups := []*PreloadGroup{{Name: "g1"}, {Name: "g2"}}
+	for _, group := range groups {
+		_, err := testEngine.Insert(group)
+		assert.NoError(t, err)
+	}
+	for _, userGroup := range []PreloadUserGroup{
+		{users[0].Id, groups[0].Id},
+		{users[0].Id, groups[1].Id},
+		{users[1].Id, groups[1].Id},
+	} {
+		_, err := testEngine.Insert(&userGroup)
+		assert.NoError(t, err)
+	}
+
+	var found []PreloadUser
+	err := testEngine.Preload("Orders.Items", "Groups").Asc("id").Find(&found)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, len(found))
+
+	assert.EqualValues(t, 2, len(found[0].Orders))
+	assert.EqualValues(t, 1, len(found[0].Orders[0].Items))
+	assert.EqualValues(t, 2, len(found[0].Orders[1].Items))
+	assert.EqualValues(t, "a2", found[0].Orders[1].Items[0].Name)
+	assert.EqualValues(t, 2, len(found[0].Groups))
+
+	assert.EqualValues(t, 1, len(found[1].Orders))
+	assert.EqualValues(t, 3, len(found[1].Orders[0].Items))
+	assert.EqualValues(t, 1, len(found[1].Groups))
+	assert.EqualValues(t, "g2", found[1].Groups[0].Name)
+
+	assert.NotNil(t, found[2].Orders)
+	assert.EqualValues(t, 0, len(found[2].Orders))
+	assert.EqualValues(t, 0, len(found[2].Groups))
+
+	var order PreloadOrder
+	has, err := testEngine.Preload("User.Groups").ID(orders[2].Id).Get(&order)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.NotNil(t, order.User)
+	assert.EqualValues(t, "b", order.User.Name)
+	assert.EqualValues(t, 1, len(order.User.Groups))
+	assert.Nil(t, order.Items)
+
+	var pagedOrders []*PreloadOrder
+	cnt, err := testEngine.Preload("User").Where("`user_id` = ?", users[0].Id).Limit(1).FindAndCount(&pagedOrders)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, cnt)
+	assert.EqualValues(t, 1, len(pagedOrders))
+	assert.EqualValues(t, "a", pagedOrders[0].User.Name)
+
+	mapUsers := make(map[int64]*PreloadUser)
+	assert.NoError(t, testEngine.Preload("Orders").Find(&mapUsers))
+	assert.EqualValues(t, 2, len(mapUsers[users[0].Id].Orders))
+
+	err = testEngine.Preload("Unknown").Find(&found)
+	assert.Error(t, err)
+}
+
+func TestPreloadManyParents(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+	assertSync(t, new(PreloadUser), new(PreloadOrder), new(PreloadGroup), new(PreloadUserGroup))
+
+	// more keys than the bind parameters of SQLite and the IN list of Oracle
+	users := make([]*PreloadUser, 1200)
+	for i := range users {
+		users[i] = &PreloadUser{Name: fmt.Sprintf("u%d", i)}
+	}
+	_, err := testEngine.InsertMulti(&users)
+	assert.NoError(t, err)
+	group := PreloadGroup{Name: "g"}
+	_, err = testEngine.Insert(&group)
+	assert.NoError(t, err)
+
+	var ids []int64
+	assert.NoError(t, testEngine.Table(new(PreloadUser)).Cols("id").Asc("id").Find(&ids))
+	assert.Len(t, ids, len(users))
+	userGroups := make([]*PreloadUserGroup, 0, len(ids))
+	for _, id := range ids {
+		userGroups = append(userGroups, &PreloadUserGroup{id, group.Id})
+	}
+	_, err = testEngine.InsertMulti(&userGroups)
+	assert.NoError(t, err)
+	for _, id := range []int64{ids[0], ids[len(ids)-1]} {
+		_, err = testEngine.Insert(&PreloadOrder{UserId: id, Title: "o"})
+		assert.NoError(t, err)
+	}
+
+	var found []PreloadUser
+	assert.NoError(t, testEngine.Preload("Orders", "Groups").Asc("id").Find(&found))
+	assert.Len(t, found, len(users))
+	for i, user := range found {
+		assert.Len(t, user.Groups, 1)
+		if i == 0 || i == len(found)-1 {
+			assert.Len(t, user.Orders, 1)
+		} else {
+			assert.Empty(t, user.Orders)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"xorm.io/xorm/core"
+)
+
+type QueryCacheUser struct {
+	Id   int64
+	Name string
+	Age  int
+}
+
+type QueryCacheOrder struct {
+	Id     int64
+	UserId int64
+	Amount int
+}
+
+func prepareQueryCache(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+	assertSync(t, new(QueryCacheUser), new(QueryCacheOrder))
+	testEngine.QueryCache().Clear()
+
+	_, err := testEngine.Insert([]QueryCacheUser{
+		{Name: "a", Age: 10},
+		{Name: "b", Age: 20},
+	})
+	assert.NoError(t, err)
+}
+
+// updateBehindCache changes the table without the session, so the cached
+// results are not invalidated
+func updateBehindCache(t *testing.T, bean interface{}, set string) {
+	execBehindCache(t, fmt.Sprintf("UPDATE %s SET %s",
+		testEngine.Quote(testEngine.TableName(bean, true)), set))
+}
+
+func execBehindCache(t *testing.T, sqlStr string) {
+	db := testEngine.(interface{ DB() *core.DB }).DB()
+	_, err := db.Exec(sqlStr)
+	assert.NoError(t, err)
+}
+
+func TestCacheForFind(t *testing.T) {
+	prepareQueryCache(t)
+
+	var users []QueryCacheUser
+	assert.NoError(t, testEngine.CacheFor(time.Minute).Asc("id").Find(&users))
+	assert.EqualValues(t, 2, len(users))
+
+	updateBehindCache(t, new(QueryCacheUser), testEngine.Quote("name")+" = 'c'")
+
+	// the result is cached
+	sess := testEngine.NewSession()
+	defer sess.Close()
+	var users2 []QueryCacheUser
+	assert.NoError(t, sess.CacheFor(time.Minute).Asc("id").Find(&users2))
+	assert.EqualValues(t, users, users2)
+	sql, _ := sess.LastSQL()
+	assert.EqualValues(t, "", sql)
+
+	// the cached result is a copy
+	users2[0].Name = "changed"
+	var users3 []QueryCacheUser
+	assert.NoError(t, testEngine.CacheFor(time.Minute).Asc("id").Find(&users3))
+	assert.EqualValues(t, "a", users3[0].Name)
+
+	// the records are appended to the slice
+	assert.NoError(t, testEngine.CacheFor(time.Minute).Asc("id").Find(&users3))
+	assert.EqualValues(t, 4, len(users3))
+
+	// a write invalidates the result
+	_, err := testEngine.Insert(&QueryCacheUser{Name: "d", Age: 30})
+	assert.NoError(t, err)
+	users = nil
+	assert.NoError(t, testEngine.CacheFor(time.Minute).Asc("id").Find(&users))
+	assert.EqualValues(t, 3, len(users))
+	assert.EqualValues(t, "c", users[0].Name)
+
+	// without CacheFor the result is not cached
+	users = nil
+	assert.NoError(t, testEngine.Asc("id").Find(&users))
+	assert.EqualValues(t, 3, len(users))
+
+	usersMap := make(map[int64]QueryCacheUser)
+	assert.NoError(t, testEngine.CacheFor(time.Minute).Find(&usersMap))
+	assert.EqualValues(t, 3, len(usersMap))
+	updateBehindCache(t, new(QueryCacheUser), testEngine.Quote("age")+" = 1")
+	usersMap2 := make(map[int64]QueryCacheUser)
+	assert.NoError(t, testEngine.CacheFor(time.Minute).Find(&usersMap2))
+	assert.EqualValues(t, usersMap, usersMap2)
+}
+
+func TestCacheForGet(t *testing.T) {
+	prepareQueryCache(t)
+
+	var user QueryCacheUser
+	has, err := testEngine.CacheFor(time.Minute, "user", "a").Where("name = ?", "a").Get(&user)
+	assert.NoError(t, err)
+	assert.True(t, has)
+
+	updateBehindCache(t, new(QueryCacheUser), testEngine.Quote("age")+" = 11")
+
+	// the key is given, so the result is got by the key
+	var user2 QueryCacheUser
+	has, err = testEngine.CacheFor(time.Minute, "user", "a").Where("name = ?", "a").Get(&user2)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, 10, user2.Age)
+
+	testEngine.QueryCache().Del("user:a")
+	var user3 QueryCacheUser
+	has, err = testEngine.CacheFor(time.Minute, "user", "a").Where("name = ?", "a").Get(&user3)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, 11, user3.Age)
+
+	// not found is cached too
+	var user4 QueryCacheUser
+	has, err = testEngine.CacheFor(time.Minute).Where("name = ?", "x").Get(&user4)
+	assert.NoError(t, err)
+	assert.False(t, has)
+	updateBehindCache(t, new(QueryCacheUser), testEngine.Quote("name")+" = 'x'")
+	has, err = testEngine.CacheFor(time.Minute).Where("name = ?", "x").Get(&user4)
+	assert.NoError(t, err)
+	assert.False(t, has)
+
+	// a value
+	var name string
+	has, err = testEngine.CacheFor(time.Minute).Table(new(QueryCacheUser)).Cols("name").Where("id = ?", user.Id).Get(&name)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, "x", name)
+}
+
+func TestCacheForCountAndSum(t *testing.T) {
+	prepareQueryCache(t)
+
+	cnt, err := testEngine.CacheFor(time.Minute).Count(new(QueryCacheUser))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, cnt)
+	sum, err := testEngine.CacheFor(time.Minute).SumInt(new(QueryCacheUser), "age")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 30, sum)
+	sums, err := testEngine.CacheFor(time.Minute).Sums(new(QueryCacheUser), "age", "id")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, len(sums))
+
+	updateBehindCache(t, new(QueryCacheUser), testEngine.Quote("age")+" = 1")
+
+	cnt, err = testEngine.CacheFor(time.Minute).Count(new(QueryCacheUser))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, cnt)
+	sum, err = testEngine.CacheFor(time.Minute).SumInt(new(QueryCacheUser), "age")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 30, sum)
+	sums2, err := testEngine.CacheFor(time.Minute).Sums(new(QueryCacheUser), "age", "id")
+	assert.NoError(t, err)
+	assert.EqualValues(t, sums, sums2)
+
+	_, err = testEngine.Where("name = ?", "a").Delete(new(QueryCacheUser))
+	assert.NoError(t, err)
+	cnt, err = testEngine.CacheFor(time.Minute).Count(new(QueryCacheUser))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+	sum, err = testEngine.CacheFor(time.Minute).SumInt(new(QueryCacheUser), "age")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, sum)
+
+	var users []QueryCacheUser
+	cnt, err = testEngine.CacheFor(time.Minute, "users").FindAndCount(&users)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+	users = nil
+	cnt, err = testEngine.CacheFor(time.Minute, "users").FindAndCount(&users)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+	assert.EqualValues(t, 1, len(users))
+}
+
+func TestCacheForJoinAndRawSQL(t *testing.T) {
+	prepareQueryCache(t)
+
+	var user QueryCacheUser
+	has, err := testEngine.Where("name = ?", "a").Get(&user)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	_, err = testEngine.Insert(&QueryCacheOrder{UserId: user.Id, Amount: 5})
+	assert.NoError(t, err)
+
+	userTable := testEngine.Quote(testEngine.TableName(new(QueryCacheUser), true))
+	orderTable := testEngine.Quote(testEngine.TableName(new(QueryCacheOrder), true))
+	rawSQL := fmt.Sprintf("SELECT u.%s, o.%s FROM %s u INNER JOIN %s o ON u.%s = o.%s",
+		testEngine.Quote("name"), testEngine.Quote("amount"), userTable, orderTable,
+		testEngine.Quote("id"), testEngine.Quote("user_id"))
+
+	results, err := testEngine.CacheFor(time.Minute).QueryInterface(rawSQL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, len(results))
+
+	type UserOrder struct {
+		QueryCacheUser  `xorm:"extends"`
+		QueryCacheOrder `xorm:"extends"`
+	}
+	var userOrders []UserOrder
+	assert.NoError(t, testEngine.CacheFor(time.Minute).Table(new(QueryCacheUser)).
+		Join("INNER", new(QueryCacheOrder), "`query_cache_order`.`user_id` = `query_cache_user`.`id`").
+		Find(&userOrders))
+	assert.EqualValues(t, 1, len(userOrders))
+
+	updateBehindCache(t, new(QueryCacheOrder), testEngine.Quote("amount")+" = 6")
+
+	results, err = testEngine.CacheFor(time.Minute).QueryInterface(rawSQL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, len(results))
+	strs, err := testEngine.CacheFor(time.Minute).QueryString(rawSQL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "6", strs[0]["amount"])
+
+	// a write of the joined table invalidates the results
+	_, err = testEngine.Insert(&QueryCacheOrder{UserId: user.Id, Amount: 7})
+	assert.NoError(t, err)
+	results, err = testEngine.CacheFor(time.Minute).QueryInterface(rawSQL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, len(results))
+	userOrders = nil
+	assert.NoError(t, testEngine.CacheFor(time.Minute).Table(new(QueryCacheUser)).
+		Join("INNER", new(QueryCacheOrder), "`query_cache_order`.`user_id` = `query_cache_user`.`id`").
+		Find(&userOrders))
+	assert.EqualValues(t, 2, len(userOrders))
+
+	// a raw write too
+	_, err = testEngine.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s = ?", orderTable, testEngine.Quote("amount")), 7)
+	assert.NoError(t, err)
+	results, err = testEngine.CacheFor(time.Minute).QueryInterface(rawSQL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, len(results))
+}
+
+func TestCacheForTTL(t *testing.T) {
+	prepareQueryCache(t)
+
+	cnt, err := testEngine.CacheFor(50 * time.Millisecond).Count(new(QueryCacheUser))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, cnt)
+
+	execBehindCache(t, fmt.Sprintf("DELETE FROM %s", testEngine.Quote(testEngine.TableName(new(QueryCacheUser), true))))
+
+	cnt, err = testEngine.CacheFor(50 * time.Millisecond).Count(new(QueryCacheUser))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, cnt)
+
+	time.Sleep(100 * time.Millisecond)
+	cnt, err = testEngine.CacheFor(50 * time.Millisecond).Count(new(QueryCacheUser))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, cnt)
+}
+
+func TestCacheForTransaction(t *testing.T) {
+	prepareQueryCache(t)
+
+	cnt, err := testEngine.CacheFor(time.Minute).Count(new(QueryCacheUser))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, cnt)
+
+	sess := testEngine.NewSession()
+	defer sess.Close()
+	assert.NoError(t, sess.Begin())
+	_, err = sess.Insert(&QueryCacheUser{Name: "c"})
+	assert.NoError(t, err)
+
+	// the cache is not used in the transaction
+	cnt, err = sess.CacheFor(time.Minute).Count(new(QueryCacheUser))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, cnt)
+
+	assert.NoError(t, sess.Commit())
+
+	cnt, err = testEngine.CacheFor(time.Minute).Count(new(QueryCacheUser))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, cnt)
+}
@@ -0,0 +1,124 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tests
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"xorm.io/xorm/schemas"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ReturningStruct struct {
+	Id     int64
+	Name   string
+	Status string `xorm:"default 'new'"`
+	Score  int    `xorm:"default 10"`
+}
+
+func skipReturning(t *testing.T, isUpdate bool) bool {
+	switch testEngine.Dialect().URI().DBType {
+	case schemas.POSTGRES, schemas.SQLITE, schemas.MSSQL:
+		return false
+	case schemas.MYSQL:
+		// only MariaDB supports RETURNING on INSERT and DELETE
+		if !isUpdate {
+			res, err := testEngine.QueryString("SELECT VERSION()")
+			if err == nil && len(res) > 0 {
+				for _, v := range res[0] {
+					if strings.Contains(v, "MariaDB") {
+						return false
+					}
+				}
+			}
+		}
+	}
+	t.Skip("returning is not supported")
+	return true
+}
+
+func TestInsertReturning(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+	assertSync(t, new(ReturningStruct))
+	if skipReturning(t, false) {
+		return
+	}
+
+	bean := ReturningStruct{Name: "lunny"}
+	cnt, err := testEngine.Omit("status", "score").Returning().Insert(&bean)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+	assert.True(t, bean.Id > 0)
+	assert.EqualValues(t, "new", bean.Status)
+	assert.EqualValues(t, 10, bean.Score)
+
+	beans := make([]*ReturningStruct, 3)
+	for i := range beans {
+		beans[i] = &ReturningStruct{Name: fmt.Sprintf("name%d", i)}
+	}
+	cnt, err = testEngine.Omit("status", "score").Returning().InsertMulti(&beans)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, cnt)
+	switch testEngine.Dialect().URI().DBType {
+	case schemas.SQLITE, schemas.MSSQL:
+		// the returned rows are in an arbitrary order so they are not written back
+		for _, b := range beans {
+			assert.EqualValues(t, 0, b.Id)
+			assert.EqualValues(t, "", b.Status)
+		}
+		return
+	}
+	for _, b := range beans {
+		var got ReturningStruct
+		has, err := testEngine.ID(b.Id).Get(&got)
+		assert.NoError(t, err)
+		assert.True(t, has)
+		assert.EqualValues(t, b.Name, got.Name)
+		assert.EqualValues(t, "new", b.Status)
+		assert.EqualValues(t, 10, b.Score)
+	}
+}
+
+func TestUpdateReturning(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+	assertSync(t, new(ReturningStruct))
+	if skipReturning(t, true) {
+		return
+	}
+
+	_, err := testEngine.Insert(&ReturningStruct{Name: "lunny", Status: "new", Score: 1})
+	assert.NoError(t, err)
+
+	var bean ReturningStruct
+	cnt, err := testEngine.Table(new(ReturningStruct)).Where("name = ?", "lunny").
+		Returning().Incr("score", 5).Update(&bean)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+	assert.True(t, bean.Id > 0)
+	assert.EqualValues(t, "lunny", bean.Name)
+	assert.EqualValues(t, 6, bean.Score)
+}
+
+func TestDeleteReturning(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+	assertSync(t, new(ReturningStruct))
+	if skipReturning(t, false) {
+		return
+	}
+
+	inserted := ReturningStruct{Name: "lunny", Status: "done", Score: 3}
+	_, err := testEngine.Insert(&inserted)
+	assert.NoError(t, err)
+
+	bean := ReturningStruct{Id: inserted.Id}
+	cnt, err := testEngine.Returning("status", "score").Delete(&bean)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+	assert.EqualValues(t, "done", bean.Status)
+	assert.EqualValues(t, 3, bean.Score)
+}
@@ -217,3 +217,100 @@ func TestInsertMulti2InterfaceTransaction(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestNestedTransaction(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type NestedTransaction struct {
+		Id   int64
+		Name string
+	}
+
+	assertSync(t, new(NestedTransaction))
+
+	session := testEngine.NewSession()
+	defer session.Close()
+
+	assert.NoError(t, session.BeginNested())
+	assert.EqualValues(t, 0, session.TxDepth())
+
+	_, err := session.Insert(&NestedTransaction{Name: "outer"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, session.BeginNested())
+	assert.EqualValues(t, 1, session.TxDepth())
+
+	_, err = session.Insert(&NestedTransaction{Name: "rollbacked"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, session.Rollback())
+	assert.EqualValues(t, 0, session.TxDepth())
+	assert.True(t, session.IsInTx())
+
+	assert.NoError(t, session.BeginNested())
+	_, err = session.Insert(&NestedTransaction{Name: "released"})
+	assert.NoError(t, err)
+	assert.NoError(t, session.Commit())
+	assert.True(t, session.IsInTx())
+
+	assert.NoError(t, session.Commit())
+	assert.False(t, session.IsInTx())
+
+	var ms []NestedTransaction
+	assert.NoError(t, session.Asc("id").Find(&ms))
+	assert.EqualValues(t, 2, len(ms))
+	assert.EqualValues(t, "outer", ms[0].Name)
+	assert.EqualValues(t, "released", ms[1].Name)
+
+	// closing the session rollbacks the whole transaction
+	session2 := testEngine.NewSession()
+	assert.NoError(t, session2.BeginNested())
+	assert.NoError(t, session2.BeginNested())
+	_, err = session2.Insert(&NestedTransaction{Name: "closed"})
+	assert.NoError(t, err)
+	assert.NoError(t, session2.Close())
+
+	cnt, err := testEngine.Count(new(NestedTransaction))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, cnt)
+
+	// a helper calling Begin and Commit doesn't release the savepoint of
+	// the caller
+	session3 := testEngine.NewSession()
+	defer session3.Close()
+	assert.NoError(t, session3.Begin())
+	assert.NoError(t, session3.BeginNested())
+	assert.EqualValues(t, 1, session3.TxDepth())
+	sql, _ := session3.LastSQL()
+	assert.Contains(t, sql, "SAVEPOINT")
+
+	assert.NoError(t, session3.Begin())
+	_, err = session3.Insert(&NestedTransaction{Name: "helper"})
+	assert.NoError(t, err)
+	assert.NoError(t, session3.Commit())
+	assert.EqualValues(t, 1, session3.TxDepth())
+
+	assert.NoError(t, session3.Rollback())
+	assert.EqualValues(t, 0, session3.TxDepth())
+	assert.NoError(t, session3.Commit())
+	assert.False(t, session3.IsInTx())
+
+	cnt, err = testEngine.Count(new(NestedTransaction))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, cnt)
+
+	// a helper calling Begin and Rollback rollbacks the whole transaction
+	assert.NoError(t, session3.Begin())
+	assert.NoError(t, session3.BeginNested())
+	_, err = session3.Insert(&NestedTransaction{Name: "helper"})
+	assert.NoError(t, err)
+	assert.NoError(t, session3.Begin())
+	assert.NoError(t, session3.Rollback())
+	assert.False(t, session3.IsInTx())
+	assert.EqualValues(t, 0, session3.TxDepth())
+	assert.NoError(t, session3.Commit())
+
+	cnt, err = testEngine.Count(new(NestedTransaction))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, cnt)
+}
@@ -0,0 +1,110 @@
+// Copyright 2023 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpsert(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type UpsertUser struct {
+		Id      int64
+		Name    string    `xorm:"varchar(100) unique"`
+		Email   string    `xorm:"varchar(100)"`
+		Created time.Time `xorm:"created"`
+		Updated time.Time `xorm:"updated"`
+		Version int       `xorm:"version"`
+	}
+	assertSync(t, new(UpsertUser))
+
+	user := UpsertUser{Name: "lunny", Email: "lunny@a.com"}
+	cnt, err := testEngine.Upsert(&user)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+	assert.True(t, user.Id > 0)
+
+	var inserted UpsertUser
+	has, err := testEngine.ID(user.Id).Get(&inserted)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, 1, inserted.Version)
+
+	user2 := UpsertUser{Name: "lunny", Email: "lunny@b.com"}
+	cnt, err = testEngine.Upsert(&user2)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+
+	total, err := testEngine.Count(new(UpsertUser))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+
+	var updated UpsertUser
+	updated = UpsertUser{}
+	has, err = testEngine.ID(user.Id).Get(&updated)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, "lunny@b.com", updated.Email)
+	assert.EqualValues(t, 2, updated.Version)
+	assert.EqualValues(t, inserted.Created.Unix(), updated.Created.Unix())
+
+	// only the email is kept as is
+	cnt, err = testEngine.OnConflict("name").DoUpdate("updated").Insert(&UpsertUser{Name: "lunny", Email: "lunny@c.com"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+
+	updated = UpsertUser{}
+	has, err = testEngine.ID(user.Id).Get(&updated)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, "lunny@b.com", updated.Email)
+
+	cnt, err = testEngine.OnConflict("name").DoNothing().Insert(&UpsertUser{Name: "lunny", Email: "lunny@d.com"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, cnt)
+
+	updated = UpsertUser{}
+	has, err = testEngine.ID(user.Id).Get(&updated)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, "lunny@b.com", updated.Email)
+
+	users := []UpsertUser{
+		{Name: "lunny", Email: "lunny@e.com"},
+		{Name: "xlw", Email: "xlw@a.com"},
+	}
+	cnt, err = testEngine.Upsert(users)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, cnt)
+
+	total, err = testEngine.Count(new(UpsertUser))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+}
+
+func TestUpsertIncr(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type UpsertCounter struct {
+		Name  string `xorm:"varchar(100) pk"`
+		Count int
+	}
+	assertSync(t, new(UpsertCounter))
+
+	for i := 0; i < 3; i++ {
+		_, err := testEngine.Incr("count").Upsert(&UpsertCounter{Name: "visits", Count: 1})
+		assert.NoError(t, err)
+	}
+
+	var counter UpsertCounter
+	has, err := testEngine.ID("visits").Get(&counter)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, 3, counter.Count)
+}
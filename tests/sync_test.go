@@ -5,8 +5,13 @@
 package tests
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 
+	"xorm.io/xorm"
+	"xorm.io/xorm/schemas"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -32,3 +37,74 @@ func TestSync(t *testing.T) {
 	assert.NoError(t, testEngine.Sync(new(TestSync1)))
 	assert.NoError(t, testEngine.Sync(new(TestSync2)))
 }
+
+type SyncForeignKeyUser struct {
+	Id   int64 `xorm:"pk autoincr 'id'"`
+	Name string
+}
+
+func (SyncForeignKeyUser) TableName() string {
+	return "sync_fk_user"
+}
+
+type SyncForeignKeyOrder struct {
+	Id     int64 `xorm:"pk autoincr 'id'"`
+	UserId int64 `xorm:"'user_id' fk(sync_fk_user.id) ondelete(cascade)"`
+}
+
+func (SyncForeignKeyOrder) TableName() string {
+	return "sync_fk_order"
+}
+
+func dbForeignKeys(t *testing.T, tableName string) map[string]*schemas.ForeignKey {
+	tables, err := testEngine.DBMetas()
+	assert.NoError(t, err)
+	for _, table := range tables {
+		if table.Name == tableName {
+			return table.ForeignKeys
+		}
+	}
+	return nil
+}
+
+func TestSyncForeignKeys(t *testing.T) {
+	if testEngine.Dialect().URI().DBType == schemas.YDB {
+		t.Skip("YDB has no foreign keys")
+		return
+	}
+	assert.NoError(t, PrepareEngine())
+	assert.NoError(t, testEngine.DropTables(new(SyncForeignKeyOrder), new(SyncForeignKeyUser)))
+
+	// the referenced table is created first
+	assert.NoError(t, testEngine.Sync(new(SyncForeignKeyOrder), new(SyncForeignKeyUser)))
+	defer func() {
+		assert.NoError(t, testEngine.DropTables(new(SyncForeignKeyOrder), new(SyncForeignKeyUser)))
+	}()
+
+	fks := dbForeignKeys(t, "sync_fk_order")
+	assert.Len(t, fks, 1)
+	for _, fk := range fks {
+		assert.EqualValues(t, []string{"user_id"}, fk.Cols)
+		assert.EqualValues(t, "sync_fk_user", fk.RefTable)
+		assert.EqualValues(t, []string{"id"}, fk.RefCols)
+		assert.EqualValues(t, schemas.Cascade, fk.OnDelete)
+	}
+
+	// nothing changes when synchronized again
+	result, err := testEngine.SyncWithOptions(xorm.SyncOptions{WarnIfDatabaseColumnMissed: true},
+		new(SyncForeignKeyUser), new(SyncForeignKeyOrder))
+	assert.NoError(t, err)
+	assert.Empty(t, result.Warnings)
+	assert.Len(t, dbForeignKeys(t, "sync_fk_order"), 1)
+
+	orderTable, err := testEngine.TableInfo(new(SyncForeignKeyOrder))
+	assert.NoError(t, err)
+	userTable, err := testEngine.TableInfo(new(SyncForeignKeyUser))
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, testEngine.(*xorm.Engine).DumpTables([]*schemas.Table{orderTable, userTable}, &buf))
+	dump := buf.String()
+	assert.True(t, strings.Index(dump, "sync_fk_user") < strings.Index(dump, "sync_fk_order"))
+	assert.Contains(t, dump, "FK_sync_fk_order_user_id")
+}